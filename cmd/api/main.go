@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/yomek33/talki/internal/config"
+	"github.com/yomek33/talki/internal/dictionary"
 	"github.com/yomek33/talki/internal/gemini"
 	"github.com/yomek33/talki/internal/handler"
 	"github.com/yomek33/talki/internal/models"
@@ -45,7 +46,7 @@ func main() {
 	)
 
 	// Connect to the database
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Failed to connect DB: %v", err)
 	}
@@ -68,15 +69,29 @@ func main() {
 		Firebase:     firebaseInstance,
 	}
 
+	dict := dictionary.New()
+	if cfg.DictionaryPath != "" {
+		dict, err = dictionary.Load(cfg.DictionaryPath)
+		if err != nil {
+			log.Fatalf("Failed to load dictionary: %v", err)
+		}
+		log.Printf("Loaded %d dictionary headwords", dict.Len())
+	}
+
 	stores := stores.NewStores(app.DB)
-	services := services.NewServices(stores, app.GeminiClient)
+	services := services.NewServices(stores, app.GeminiClient, dict, cfg)
 	h := handler.NewHandler(services, cfg.JWTSecretKey, app.Firebase)
 
 	e.Use(handler.FirebaseAuthMiddleware(app.Firebase.AuthClient))
 	h.SetDefault(e)
 	h.SetAPIRoutes(e)
 
-	err = db.AutoMigrate(&models.Material{})
+	err = db.AutoMigrate(&models.User{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+
+	err = db.AutoMigrate(&models.Material{}, &models.Tag{}, &models.Collection{})
 	if err != nil {
 		panic("failed to migrate database")
 	}
@@ -93,6 +108,88 @@ func main() {
 	if err != nil {
 		panic("failed to migrate database")
 	}
+	err = db.AutoMigrate(&models.Highlight{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.Word{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.Embedding{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.MaterialRevision{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.Job{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.VocabularyEntry{}, &models.VocabularySource{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	// notebook entries became unique per language; the old index would still
+	// merge a word of two languages into one entry
+	if db.Migrator().HasIndex(&models.VocabularyEntry{}, "idx_vocabulary_user_key") {
+		if err := db.Migrator().DropIndex(&models.VocabularyEntry{}, "idx_vocabulary_user_key"); err != nil {
+			panic("failed to migrate database")
+		}
+	}
+	err = db.AutoMigrate(&models.KnownWord{}, &models.MaterialLemma{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	// known words became unique per language; the old index would still reject
+	// the same lemma in two languages
+	if db.Migrator().HasIndex(&models.KnownWord{}, "idx_known_user_lemma") {
+		if err := db.Migrator().DropIndex(&models.KnownWord{}, "idx_known_user_lemma"); err != nil {
+			panic("failed to migrate database")
+		}
+	}
+	err = db.AutoMigrate(&models.Translation{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	// translations became unique per source language; the old index would
+	// still reject the same text translated from two languages
+	if db.Migrator().HasIndex(&models.Translation{}, "idx_translation_key") {
+		if err := db.Migrator().DropIndex(&models.Translation{}, "idx_translation_key"); err != nil {
+			panic("failed to migrate database")
+		}
+	}
+	err = db.AutoMigrate(&models.Scenario{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.ChatReport{}, &models.ReportMistake{}, &models.ReportSuggestion{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	err = db.AutoMigrate(&models.Progress{}, &models.UsageEvent{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+
+	if cfg.SearchBackend == config.SearchBackendMemory {
+		if err := services.SearchService.Reindex(); err != nil {
+			log.Fatalf("Failed to build search index: %v", err)
+		}
+	} else if err := stores.SearchStore.EnsureFulltextIndexes(); err != nil {
+		log.Fatalf("Failed to create fulltext indexes: %v", err)
+	}
+
+	go services.TrashService.RunScheduledPurge(context.Background(), cfg.TrashRetention)
+	go func() {
+		if n, err := services.MaterialService.AnalyzeMissing(); err != nil {
+			log.Printf("Failed to analyze materials: %v", err)
+		} else if n > 0 {
+			log.Printf("Analyzed %d materials", n)
+		}
+	}()
 
 	port, err := strconv.Atoi(cfg.Port)
 	if err != nil {
@@ -0,0 +1,310 @@
+// Package annotate segments a text into paragraphs, sentences and tokens and
+// finds where phrases and words occur in it, for the annotated reading view.
+// All offsets count Unicode code points from the start of the text, with End
+// exclusive.
+package annotate
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/yomek33/talki/internal/nlp"
+)
+
+type Token struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+	Lemma string `json:"lemma"`
+}
+
+type Sentence struct {
+	Start  int     `json:"start"`
+	End    int     `json:"end"`
+	Tokens []Token `json:"tokens"`
+}
+
+type Paragraph struct {
+	Start     int        `json:"start"`
+	End       int        `json:"end"`
+	Sentences []Sentence `json:"sentences"`
+}
+
+// Item is a phrase or word to look for in the text
+type Item struct {
+	Kind string
+	ID   uint
+	Text string
+}
+
+// Occurrence is one place an item was found. Exact is false when the item
+// only matched through the lemmas of inflected forms.
+type Occurrence struct {
+	Kind  string `json:"kind"`
+	ID    uint   `json:"id"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Exact bool   `json:"exact"`
+}
+
+// abbreviations end with a period without ending the sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "st": true,
+	"vs": true, "etc": true, "e.g": true, "i.e": true, "no": true, "jan": true, "feb": true,
+}
+
+// Segment splits text in the language of code into paragraphs at line
+// breaks, paragraphs into sentences at sentence-ending punctuation, and
+// sentences into tokens
+func Segment(text, code string) []Paragraph {
+	runes := []rune(text)
+	var paragraphs []Paragraph
+	start := 0
+	for start < len(runes) {
+		end := start
+		for end < len(runes) && runes[end] != '\n' {
+			end++
+		}
+		if p, ok := segmentParagraph(runes, start, end, code); ok {
+			paragraphs = append(paragraphs, p)
+		}
+		start = end + 1
+	}
+	return paragraphs
+}
+
+func segmentParagraph(runes []rune, start, end int, code string) (Paragraph, bool) {
+	start, end = trimSpace(runes, start, end)
+	if start >= end {
+		return Paragraph{}, false
+	}
+	p := Paragraph{Start: start, End: end}
+	sentenceStart := start
+	for i := start; i < end; i++ {
+		if !isTerminal(runes[i]) {
+			continue
+		}
+		// take the whole run of terminal punctuation and closing quotes
+		j := i + 1
+		for j < end && (isTerminal(runes[j]) || strings.ContainsRune("\"'”’)]」』", runes[j])) {
+			j++
+		}
+		// full-width punctuation ends a sentence without a following space
+		if j < end && !unicode.IsSpace(runes[j]) && !isFullWidthTerminal(runes[i]) {
+			i = j - 1
+			continue
+		}
+		if runes[i] == '.' && isAbbreviation(runes, sentenceStart, i) {
+			continue
+		}
+		p.Sentences = appendSentence(p.Sentences, runes, sentenceStart, j, code)
+		sentenceStart = j
+		i = j - 1
+	}
+	p.Sentences = appendSentence(p.Sentences, runes, sentenceStart, end, code)
+	return p, true
+}
+
+func appendSentence(sentences []Sentence, runes []rune, start, end int, code string) []Sentence {
+	start, end = trimSpace(runes, start, end)
+	if start >= end {
+		return sentences
+	}
+	s := Sentence{Start: start, End: end, Tokens: []Token{}}
+	for _, span := range nlp.TokenizeSpans(string(runes[start:end])) {
+		s.Tokens = append(s.Tokens, Token{
+			Start: start + span.Start,
+			End:   start + span.End,
+			Text:  string(runes[start+span.Start : start+span.End]),
+			Lemma: nlp.LemmaIn(span.Text, code),
+		})
+	}
+	return append(sentences, s)
+}
+
+func isTerminal(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || isFullWidthTerminal(r)
+}
+
+func isFullWidthTerminal(r rune) bool {
+	return r == '。' || r == '！' || r == '？'
+}
+
+// isAbbreviation reports whether the period at i ends a known abbreviation
+// or a single initial such as the "J." of "J. Smith"
+func isAbbreviation(runes []rune, sentenceStart, i int) bool {
+	j := i
+	for j > sentenceStart && (unicode.IsLetter(runes[j-1]) || runes[j-1] == '.') {
+		j--
+	}
+	word := strings.ToLower(string(runes[j:i]))
+	return abbreviations[word] || (len([]rune(word)) == 1 && unicode.IsUpper(runes[j]))
+}
+
+func trimSpace(runes []rune, start, end int) (int, int) {
+	for start < end && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	for end > start && unicode.IsSpace(runes[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+// Match finds the occurrences of items in the text segmented for the language
+// of code. An item matches a run of tokens within one sentence whose lemmas
+// equal the item's lemmas, so "took the plunge" is found for the phrase "take
+// the plunge".
+func Match(paragraphs []Paragraph, items []Item, code string) []Occurrence {
+	occurrences := []Occurrence{}
+	for _, item := range items {
+		spans := nlp.TokenizeSpans(item.Text)
+		if len(spans) == 0 {
+			continue
+		}
+		lemmas := make([]string, len(spans))
+		for i, span := range spans {
+			lemmas[i] = nlp.LemmaIn(span.Text, code)
+		}
+
+		for _, p := range paragraphs {
+			for _, s := range p.Sentences {
+				for i := 0; i+len(lemmas) <= len(s.Tokens); i++ {
+					if !matchesAt(s.Tokens[i:], lemmas) {
+						continue
+					}
+					exact := true
+					for k, span := range spans {
+						if strings.ToLower(s.Tokens[i+k].Text) != span.Text {
+							exact = false
+							break
+						}
+					}
+					occurrences = append(occurrences, Occurrence{
+						Kind:  item.Kind,
+						ID:    item.ID,
+						Start: s.Tokens[i].Start,
+						End:   s.Tokens[i+len(lemmas)-1].End,
+						Exact: exact,
+					})
+				}
+			}
+		}
+	}
+	return occurrences
+}
+
+func matchesAt(tokens []Token, lemmas []string) bool {
+	for k, lemma := range lemmas {
+		if tokens[k].Lemma != lemma {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	// maxGap is how many extra tokens MatchFuzzy lets the learner put between
+	// two tokens of an item
+	maxGap = 1
+	// minTypoLength is the shortest lemma MatchFuzzy forgives a typo in
+	minTypoLength = 5
+)
+
+// MatchFuzzy finds items like Match but forgives what learners do when they
+// write: up to maxGap extra tokens inside an item, so "make a quick decision"
+// is found for "make a decision", and one typo in lemmas of minTypoLength or
+// more letters. It reports each item at most once per sentence; occurrences
+// that needed either allowance or matched through lemmas are not Exact.
+func MatchFuzzy(paragraphs []Paragraph, items []Item, code string) []Occurrence {
+	occurrences := []Occurrence{}
+	for _, item := range items {
+		spans := nlp.TokenizeSpans(item.Text)
+		if len(spans) == 0 {
+			continue
+		}
+		want := make([]Token, len(spans))
+		for i, span := range spans {
+			want[i] = Token{Text: span.Text, Lemma: nlp.LemmaIn(span.Text, code)}
+		}
+
+		for _, p := range paragraphs {
+			for _, s := range p.Sentences {
+				if o, ok := matchFuzzyIn(s.Tokens, want); ok {
+					o.Kind, o.ID = item.Kind, item.ID
+					occurrences = append(occurrences, o)
+				}
+			}
+		}
+	}
+	return occurrences
+}
+
+func matchFuzzyIn(tokens, want []Token) (Occurrence, bool) {
+	for i := range tokens {
+		exact, ok := tokenMatches(tokens[i], want[0])
+		if !ok {
+			continue
+		}
+		last, matched := i, true
+		for _, w := range want[1:] {
+			next := -1
+			for j := last + 1; j <= last+1+maxGap && j < len(tokens); j++ {
+				if e, ok := tokenMatches(tokens[j], w); ok {
+					next, exact = j, exact && e && j == last+1
+					break
+				}
+			}
+			if next < 0 {
+				matched = false
+				break
+			}
+			last = next
+		}
+		if matched {
+			return Occurrence{Start: tokens[i].Start, End: tokens[last].End, Exact: exact}, true
+		}
+	}
+	return Occurrence{}, false
+}
+
+// tokenMatches reports whether got matches the lemma of want and whether it
+// is the very same word
+func tokenMatches(got, want Token) (exact, ok bool) {
+	if got.Lemma == want.Lemma {
+		return strings.ToLower(got.Text) == want.Text, true
+	}
+	if len([]rune(want.Lemma)) >= minTypoLength && withinOneEdit(got.Lemma, want.Lemma) {
+		return false, true
+	}
+	return false, false
+}
+
+// withinOneEdit reports whether a becomes b by inserting, deleting or
+// substituting at most one rune
+func withinOneEdit(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(ra) && j < len(rb) {
+		if ra[i] == rb[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(ra) == len(rb) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(rb)-j)-(len(ra)-i) <= 1
+}
@@ -0,0 +1,71 @@
+package annotate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegment(t *testing.T) {
+	text := "Mr. Smith took the plunge. Did it work?\n\n  It did!"
+	paragraphs := Segment(text, "en")
+	assert.Len(t, paragraphs, 2)
+
+	first := paragraphs[0]
+	assert.Equal(t, 0, first.Start)
+	assert.Equal(t, 39, first.End)
+	assert.Len(t, first.Sentences, 2)
+	assert.Equal(t, "Smith", first.Sentences[0].Tokens[1].Text)
+	assert.Equal(t, "take", first.Sentences[0].Tokens[2].Lemma)
+
+	second := paragraphs[1]
+	assert.Equal(t, 43, second.Start)
+	assert.Len(t, second.Sentences, 1)
+	assert.Equal(t, Token{Start: 43, End: 45, Text: "It", Lemma: "it"}, second.Sentences[0].Tokens[0])
+}
+
+func TestMatch(t *testing.T) {
+	text := "She took the plunge. Then she takes the plunge again!"
+	occurrences := Match(Segment(text, "en"), []Item{
+		{Kind: "phrase", ID: 1, Text: "take the plunge"},
+		{Kind: "word", ID: 2, Text: "again"},
+		{Kind: "phrase", ID: 3, Text: "plunge. Then"},
+	}, "en")
+	assert.Equal(t, []Occurrence{
+		{Kind: "phrase", ID: 1, Start: 4, End: 19, Exact: false},
+		{Kind: "phrase", ID: 1, Start: 30, End: 46, Exact: false},
+		{Kind: "word", ID: 2, Start: 47, End: 52, Exact: true},
+	}, occurrences)
+	assert.Equal(t, "took the plunge", string([]rune(text)[4:19]))
+}
+
+func TestSegmentJapanese(t *testing.T) {
+	paragraphs := Segment("東京に行った。コーヒーを飲んだ！", "ja")
+	assert.Len(t, paragraphs, 1)
+	assert.Len(t, paragraphs[0].Sentences, 2)
+	assert.Equal(t, 7, paragraphs[0].Sentences[1].Start)
+
+	occurrences := Match(paragraphs, []Item{{Kind: "word", ID: 1, Text: "コーヒー"}}, "ja")
+	assert.Equal(t, []Occurrence{{Kind: "word", ID: 1, Start: 7, End: 11, Exact: true}}, occurrences)
+}
+
+func TestMatchFuzzy(t *testing.T) {
+	paragraphs := Segment("I made a quick decision. Then I took the plunge. We discused it.", "en")
+	occurrences := MatchFuzzy(paragraphs, []Item{
+		{Kind: "phrase", ID: 1, Text: "make a decision"},
+		{Kind: "phrase", ID: 2, Text: "take the plunge"},
+		{Kind: "word", ID: 3, Text: "discussed"},
+		{Kind: "phrase", ID: 4, Text: "make the decision"},
+		{Kind: "phrase", ID: 5, Text: "then I took"},
+	}, "en")
+	assert.Equal(t, []Occurrence{
+		{Kind: "phrase", ID: 1, Start: 2, End: 23, Exact: false},
+		{Kind: "phrase", ID: 2, Start: 32, End: 47, Exact: false},
+		{Kind: "word", ID: 3, Start: 52, End: 60, Exact: false},
+		{Kind: "phrase", ID: 5, Start: 25, End: 36, Exact: true},
+	}, occurrences)
+
+	assert.True(t, withinOneEdit("discuss", "discus"))
+	assert.True(t, withinOneEdit("grammer", "grammar"))
+	assert.False(t, withinOneEdit("recieve", "receive"))
+}
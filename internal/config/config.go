@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -19,11 +20,28 @@ type Config struct {
 	Port         string
 	GeminiAPIKey string
 	JWTSecretKey string
+	// SearchBackend is "memory" (in-process index, the default) or "fulltext"
+	// (MySQL FULLTEXT indexes, which TiDB does not support)
+	SearchBackend string
+	// EmbeddingProvider is "gemini" or "fake" (offline hashing, for tests and local runs)
+	EmbeddingProvider string
+	// TrashRetention is how long deleted materials stay in the trash before being purged
+	TrashRetention time.Duration
+	// DictionaryPath is an optional dictionary dump (.jsonl or .tsv, optionally .gz) for offline lookups
+	DictionaryPath string
 }
 
 const (
 	// expires cookie expiration time
 	SessionDuration = time.Hour * 10
+
+	SearchBackendFulltext = "fulltext"
+	SearchBackendMemory   = "memory"
+
+	EmbeddingProviderGemini = "gemini"
+	EmbeddingProviderFake   = "fake"
+
+	DefaultTrashRetentionDays = 30
 )
 
 // LoadConfig loads configuration from environment variables
@@ -33,20 +51,42 @@ func LoadConfig() (*Config, error) {
 	}
 
 	cfg := &Config{
-		TiDBUser:     os.Getenv("TIDB_USER"),
-		TiDBPassword: os.Getenv("TIDB_PASSWORD"),
-		TiDBHost:     os.Getenv("TIDB_HOST"),
-		TiDBPort:     os.Getenv("TIDB_PORT"),
-		TiDBDBName:   os.Getenv("TIDB_DB_NAME"),
-		UseSSL:       os.Getenv("USE_SSL"),
-		Port:         os.Getenv("PORT"),
-		GeminiAPIKey: os.Getenv("GEMINI_API_KEY"),
-		JWTSecretKey: os.Getenv("JWT_SECRET_KEY"),
+		TiDBUser:          os.Getenv("TIDB_USER"),
+		TiDBPassword:      os.Getenv("TIDB_PASSWORD"),
+		TiDBHost:          os.Getenv("TIDB_HOST"),
+		TiDBPort:          os.Getenv("TIDB_PORT"),
+		TiDBDBName:        os.Getenv("TIDB_DB_NAME"),
+		UseSSL:            os.Getenv("USE_SSL"),
+		Port:              os.Getenv("PORT"),
+		GeminiAPIKey:      os.Getenv("GEMINI_API_KEY"),
+		JWTSecretKey:      os.Getenv("JWT_SECRET_KEY"),
+		SearchBackend:     getEnvDefault("SEARCH_BACKEND", SearchBackendMemory),
+		EmbeddingProvider: getEnvDefault("EMBEDDING_PROVIDER", EmbeddingProviderGemini),
+		DictionaryPath:    os.Getenv("DICTIONARY_PATH"),
 	}
 
 	if cfg.TiDBUser == "" || cfg.TiDBPassword == "" || cfg.TiDBHost == "" || cfg.TiDBPort == "" || cfg.TiDBDBName == "" || cfg.Port == "" || cfg.UseSSL == "" || cfg.GeminiAPIKey == "" || cfg.JWTSecretKey == "" {
 		return nil, fmt.Errorf("one or more required environment variables are missing")
 	}
+	retentionDays, err := strconv.Atoi(getEnvDefault("TRASH_RETENTION_DAYS", strconv.Itoa(DefaultTrashRetentionDays)))
+	if err != nil || retentionDays < 1 {
+		return nil, fmt.Errorf("invalid TRASH_RETENTION_DAYS %q", os.Getenv("TRASH_RETENTION_DAYS"))
+	}
+	cfg.TrashRetention = time.Duration(retentionDays) * 24 * time.Hour
+
+	if cfg.SearchBackend != SearchBackendFulltext && cfg.SearchBackend != SearchBackendMemory {
+		return nil, fmt.Errorf("invalid SEARCH_BACKEND %q", cfg.SearchBackend)
+	}
+	if cfg.EmbeddingProvider != EmbeddingProviderGemini && cfg.EmbeddingProvider != EmbeddingProviderFake {
+		return nil, fmt.Errorf("invalid EMBEDDING_PROVIDER %q", cfg.EmbeddingProvider)
+	}
 
 	return cfg, nil
 }
+
+func getEnvDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,231 @@
+// Package dictionary serves word definitions from an open dictionary dump
+// loaded from disk, so lookups need no network call.
+//
+// Two formats are read:
+//   - JSON lines as produced by wiktextract (the kaikki.org Wiktionary dumps),
+//     one object per word and part of speech, in files ending in .jsonl or .json
+//   - tab-separated lines of word, part of speech, definition, examples
+//     separated by " | " and pronunciation, in files ending in .tsv; WordNet
+//     and other sources are easily converted to it
+//
+// Either may be gzip-compressed, with a further .gz suffix.
+package dictionary
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yomek33/talki/internal/nlp"
+)
+
+// maxLineSize is the longest dump line read; wiktextract entries can be large
+const maxLineSize = 16 << 20
+
+type Sense struct {
+	PartOfSpeech string   `json:"part_of_speech"`
+	Definition   string   `json:"definition"`
+	Examples     []string `json:"examples,omitempty"`
+}
+
+type Entry struct {
+	Word string `json:"word"`
+	// Lemma is the headword the entry was found under, which differs from
+	// Word for inflected forms
+	Lemma          string   `json:"lemma"`
+	Pronunciations []string `json:"pronunciations,omitempty"`
+	Senses         []Sense  `json:"senses"`
+}
+
+// Dictionary is safe for concurrent lookups once loaded
+type Dictionary struct {
+	entries map[string]*Entry
+}
+
+// New returns an empty dictionary
+func New() *Dictionary {
+	return &Dictionary{entries: make(map[string]*Entry)}
+}
+
+// Load reads the dictionary file at path, picking the format from its extension
+func Load(path string) (*Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	name := path
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open dictionary: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	d := New()
+	switch {
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".json"):
+		err = d.ReadJSONL(r)
+	case strings.HasSuffix(name, ".tsv"):
+		err = d.ReadTSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported dictionary format: %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Len returns the number of headwords
+func (d *Dictionary) Len() int {
+	return len(d.entries)
+}
+
+type wiktextractEntry struct {
+	Word   string `json:"word"`
+	POS    string `json:"pos"`
+	Senses []struct {
+		Glosses  []string `json:"glosses"`
+		Examples []struct {
+			Text string `json:"text"`
+		} `json:"examples"`
+	} `json:"senses"`
+	Sounds []struct {
+		IPA string `json:"ipa"`
+	} `json:"sounds"`
+}
+
+// ReadJSONL adds the entries of a wiktextract JSON lines dump
+func (d *Dictionary) ReadJSONL(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var w wiktextractEntry
+		if err := json.Unmarshal([]byte(raw), &w); err != nil {
+			return fmt.Errorf("dictionary line %d: %w", line, err)
+		}
+
+		entry := d.entry(w.Word)
+		if entry == nil {
+			continue
+		}
+		for _, sound := range w.Sounds {
+			entry.addPronunciation(sound.IPA)
+		}
+		for _, s := range w.Senses {
+			if len(s.Glosses) == 0 {
+				continue
+			}
+			sense := Sense{PartOfSpeech: w.POS, Definition: strings.Join(s.Glosses, "; ")}
+			for _, example := range s.Examples {
+				if text := strings.TrimSpace(example.Text); text != "" {
+					sense.Examples = append(sense.Examples, text)
+				}
+			}
+			entry.Senses = append(entry.Senses, sense)
+		}
+	}
+	return scanner.Err()
+}
+
+// ReadTSV adds the entries of a tab-separated dump. Lines starting with # are
+// comments; trailing columns may be left out.
+func (d *Dictionary) ReadTSV(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		raw := scanner.Text()
+		if strings.TrimSpace(raw) == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+		fields := strings.Split(raw, "\t")
+		for len(fields) < 5 {
+			fields = append(fields, "")
+		}
+		entry := d.entry(fields[0])
+		if entry == nil {
+			continue
+		}
+		entry.addPronunciation(fields[4])
+		if definition := strings.TrimSpace(fields[2]); definition != "" {
+			sense := Sense{PartOfSpeech: strings.TrimSpace(fields[1]), Definition: definition}
+			for _, example := range strings.Split(fields[3], " | ") {
+				if example = strings.TrimSpace(example); example != "" {
+					sense.Examples = append(sense.Examples, example)
+				}
+			}
+			entry.Senses = append(entry.Senses, sense)
+		}
+	}
+	return scanner.Err()
+}
+
+// entry returns the entry of word, creating it
+func (d *Dictionary) entry(word string) *Entry {
+	word = strings.TrimSpace(word)
+	key := strings.ToLower(word)
+	if key == "" {
+		return nil
+	}
+	entry, ok := d.entries[key]
+	if !ok {
+		entry = &Entry{Word: word, Lemma: word, Senses: []Sense{}}
+		d.entries[key] = entry
+	}
+	return entry
+}
+
+func (e *Entry) addPronunciation(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, p := range e.Pronunciations {
+		if p == text {
+			return
+		}
+	}
+	e.Pronunciations = append(e.Pronunciations, text)
+}
+
+// Lookup finds word, falling back to its lemma for inflected forms
+func (d *Dictionary) Lookup(word string) (*Entry, bool) {
+	key := strings.ToLower(strings.TrimSpace(word))
+	if key == "" {
+		return nil, false
+	}
+	entry, ok := d.entries[key]
+	if !ok || len(entry.Senses) == 0 {
+		// the lemmatizer strips suffixes without restoring every silent e,
+		// so "plunging" is tried as "plung" and "plunge"
+		lemma := nlp.Lemma(key)
+		for _, candidate := range []string{lemma, lemma + "e"} {
+			if headword, found := d.entries[candidate]; found && len(headword.Senses) > 0 {
+				entry, ok = headword, true
+				break
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	found := *entry
+	found.Word = strings.TrimSpace(word)
+	return &found, true
+}
@@ -0,0 +1,42 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadJSONL(t *testing.T) {
+	dump := `{"word": "plunge", "pos": "noun", "senses": [{"glosses": ["A dive or leap."], "examples": [{"text": "She took the plunge."}]}], "sounds": [{"ipa": "/plʌndʒ/"}, {"enpr": "plŭnj"}]}
+{"word": "plunge", "pos": "verb", "senses": [{"glosses": ["To dive."]}], "sounds": [{"ipa": "/plʌndʒ/"}]}
+`
+	d := New()
+	assert.NoError(t, d.ReadJSONL(strings.NewReader(dump)))
+	assert.Equal(t, 1, d.Len())
+
+	entry, ok := d.Lookup("Plunging")
+	assert.True(t, ok)
+	assert.Equal(t, "Plunging", entry.Word)
+	assert.Equal(t, "plunge", entry.Lemma)
+	assert.Equal(t, []string{"/plʌndʒ/"}, entry.Pronunciations)
+	assert.Equal(t, []Sense{
+		{PartOfSpeech: "noun", Definition: "A dive or leap.", Examples: []string{"She took the plunge."}},
+		{PartOfSpeech: "verb", Definition: "To dive."},
+	}, entry.Senses)
+
+	_, ok = d.Lookup("zyzzyva")
+	assert.False(t, ok)
+}
+
+func TestReadTSV(t *testing.T) {
+	dump := "# word\tpos\tdefinition\texamples\tpronunciation\nice\tnoun\tFrozen water.\tBreak the ice. | Thin ice\t/aɪs/\nice\tverb\tTo cool with ice.\n"
+	d := New()
+	assert.NoError(t, d.ReadTSV(strings.NewReader(dump)))
+
+	entry, ok := d.Lookup("ice")
+	assert.True(t, ok)
+	assert.Len(t, entry.Senses, 2)
+	assert.Equal(t, []string{"Break the ice.", "Thin ice"}, entry.Senses[0].Examples)
+	assert.Equal(t, []string{"/aɪs/"}, entry.Pronunciations)
+}
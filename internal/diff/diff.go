@@ -0,0 +1,190 @@
+// Package diff computes line diffs between two versions of a text.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// maxCells bounds the LCS table. Larger inputs are diffed as a full replacement
+// of the lines between the common prefix and suffix.
+const maxCells = 4_000_000
+
+// Lines diffs a and b line by line
+func Lines(a, b string) []Line {
+	return diff(splitLines(a), splitLines(b))
+}
+
+func diff(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []Line
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	lines = append(lines, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	return lines
+}
+
+func middle(a, b []string) []Line {
+	if len(a)*len(b) > maxCells {
+		lines := make([]Line, 0, len(a)+len(b))
+		for _, text := range a {
+			lines = append(lines, Line{Op: Delete, Text: text})
+		}
+		for _, text := range b {
+			lines = append(lines, Line{Op: Insert, Text: text})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Op: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: Delete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{Op: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{Op: Insert, Text: b[j]})
+	}
+	return lines
+}
+
+// Stats counts the inserted and deleted lines of a diff
+func Stats(lines []Line) (inserted, deleted int) {
+	for _, line := range lines {
+		switch line.Op {
+		case Insert:
+			inserted++
+		case Delete:
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+// Unified renders the changed lines of a diff in unified format, with context
+// unchanged lines around each hunk. An unchanged text renders as "".
+func Unified(lines []Line, context int) string {
+	var sb strings.Builder
+	aLine, bLine := 1, 1
+	for start := 0; start < len(lines); {
+		if lines[start].Op == Equal {
+			aLine++
+			bLine++
+			start++
+			continue
+		}
+
+		// extend the hunk while changes are closer than 2*context lines apart
+		end := start
+		for gap := 0; end < len(lines) && gap <= 2*context; end++ {
+			if lines[end].Op == Equal {
+				gap++
+			} else {
+				gap = 0
+			}
+		}
+		for end > start && lines[end-1].Op == Equal {
+			end--
+		}
+
+		from := max(start-context, 0)
+		to := min(end+context, len(lines))
+		aStart, bStart := aLine-(start-from), bLine-(start-from)
+		var aCount, bCount int
+		for _, line := range lines[from:to] {
+			if line.Op != Insert {
+				aCount++
+			}
+			if line.Op != Delete {
+				bCount++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, line := range lines[from:to] {
+			switch line.Op {
+			case Equal:
+				sb.WriteString(" ")
+			case Insert:
+				sb.WriteString("+")
+			case Delete:
+				sb.WriteString("-")
+			}
+			sb.WriteString(line.Text)
+			sb.WriteString("\n")
+		}
+
+		for _, line := range lines[start:to] {
+			if line.Op != Insert {
+				aLine++
+			}
+			if line.Op != Delete {
+				bLine++
+			}
+		}
+		start = to
+	}
+	return sb.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,30 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	lines := Lines("a\nb\nc\nd\n", "a\nc\nd\ne")
+	assert.Equal(t, []Line{
+		{Op: Equal, Text: "a"},
+		{Op: Delete, Text: "b"},
+		{Op: Equal, Text: "c"},
+		{Op: Equal, Text: "d"},
+		{Op: Insert, Text: "e"},
+	}, lines)
+
+	inserted, deleted := Stats(lines)
+	assert.Equal(t, 1, inserted)
+	assert.Equal(t, 1, deleted)
+}
+
+func TestUnified(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine"
+
+	assert.Equal(t, "@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n@@ -8,1 +8,2 @@\n eight\n+nine\n", Unified(Lines(a, b), 1))
+	assert.Equal(t, "", Unified(Lines(a, a), 3))
+}
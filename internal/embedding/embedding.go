@@ -0,0 +1,124 @@
+package embedding
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	KindMaterial = "material"
+	KindPhrase   = "phrase"
+)
+
+// Provider turns texts into embedding vectors, one per text and in the same order
+type Provider interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	EmbeddingModelName() string
+}
+
+// Vector is a stored embedding of a material or phrase
+type Vector struct {
+	Kind       string
+	ID         uint
+	UserUID    string
+	MaterialID uint
+	Values     []float32
+}
+
+// Match is a nearest-neighbour hit ranked by cosine similarity
+type Match struct {
+	Kind       string  `json:"kind"`
+	ID         uint    `json:"id"`
+	MaterialID uint    `json:"material_id"`
+	Score      float64 `json:"score"`
+}
+
+// Filter narrows a nearest-neighbour query
+type Filter struct {
+	Kinds             []string
+	ExcludeMaterialID uint
+}
+
+// VectorStore persists vectors and answers nearest-neighbour queries scoped to one user
+type VectorStore interface {
+	Upsert(model string, vectors ...Vector) error
+	Delete(kind string, ids ...uint) error
+	GetVector(model, kind string, id uint) (*Vector, error)
+	Nearest(model, UserUID string, query []float32, k int, filter Filter) ([]Match, error)
+}
+
+var ErrVectorNotFound = errors.New("vector not found")
+
+// Cosine returns the cosine similarity of a and b, or 0 when they cannot be compared
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Rank scores candidates against query by brute force and returns the best k
+func Rank(query []float32, candidates []Vector, k int, filter Filter) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !filter.allows(candidate) {
+			continue
+		}
+		matches = append(matches, Match{
+			Kind:       candidate.Kind,
+			ID:         candidate.ID,
+			MaterialID: candidate.MaterialID,
+			Score:      Cosine(query, candidate.Values),
+		})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+func (f Filter) allows(v Vector) bool {
+	if f.ExcludeMaterialID != 0 && v.MaterialID == f.ExcludeMaterialID {
+		return false
+	}
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range f.Kinds {
+		if kind == v.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeVector packs values as little-endian float32 for storage
+func EncodeVector(values []float32) []byte {
+	buf := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// DecodeVector is the inverse of EncodeVector
+func DecodeVector(buf []byte) []float32 {
+	values := make([]float32, len(buf)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return values
+}
@@ -0,0 +1,47 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeProviderSimilarity(t *testing.T) {
+	provider := NewFakeProvider()
+	vectors, err := provider.Embed(context.Background(), []string{
+		"Rising sea levels threaten coastal cities",
+		"Coastal cities face rising sea levels",
+		"The recipe needs two eggs and flour",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], fakeDimensions)
+
+	assert.InDelta(t, 1.0, Cosine(vectors[0], vectors[0]), 1e-6)
+	assert.Greater(t, Cosine(vectors[0], vectors[1]), Cosine(vectors[0], vectors[2]))
+}
+
+func TestRank(t *testing.T) {
+	provider := NewFakeProvider()
+	texts := []string{"climate change and global warming", "warming oceans and climate", "football match results"}
+	vectors, err := provider.Embed(context.Background(), texts)
+	assert.NoError(t, err)
+
+	candidates := []Vector{
+		{Kind: KindMaterial, ID: 1, MaterialID: 1, Values: vectors[0]},
+		{Kind: KindMaterial, ID: 2, MaterialID: 2, Values: vectors[1]},
+		{Kind: KindMaterial, ID: 3, MaterialID: 3, Values: vectors[2]},
+		{Kind: KindPhrase, ID: 7, MaterialID: 2, Values: vectors[1]},
+	}
+
+	matches := Rank(vectors[0], candidates, 2, Filter{Kinds: []string{KindMaterial}, ExcludeMaterialID: 1})
+	assert.Len(t, matches, 2)
+	assert.Equal(t, uint(2), matches[0].ID)
+	assert.Equal(t, uint(3), matches[1].ID)
+}
+
+func TestVectorEncoding(t *testing.T) {
+	values := []float32{0.5, -1.25, 3}
+	assert.Equal(t, values, DecodeVector(EncodeVector(values)))
+}
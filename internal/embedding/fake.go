@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const fakeDimensions = 256
+
+// FakeProvider embeds texts offline by hashing their words into a fixed number of
+// buckets. Texts sharing vocabulary end up close together, which is enough for
+// tests and for running without an API key.
+type FakeProvider struct{}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = fakeVector(text)
+	}
+	return vectors, nil
+}
+
+func (p *FakeProvider) EmbeddingModelName() string {
+	return "fake-hash-256"
+}
+
+func fakeVector(text string) []float32 {
+	values := make([]float32, fakeDimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		sum := h.Sum32()
+		sign := float32(1)
+		if sum&(1<<31) != 0 {
+			sign = -1
+		}
+		values[sum%fakeDimensions] += sign
+	}
+
+	var norm float64
+	for _, v := range values {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range values {
+			values[i] *= scale
+		}
+	}
+	return values
+}
@@ -0,0 +1,48 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// AdaptText rewrites text in language, given by its English name, for a
+// learner at level, described with its scale such as "CEFR level B1" or
+// "JLPT N3", keeping its meaning and structure
+func (c *Client) AdaptText(ctx context.Context, text, language, level string) (string, error) {
+	log.Printf("Adapting %s text to %s", language, level)
+	model := c.client.GenerativeModel("gemini-1.5-flash")
+	res, err := model.GenerateContent(ctx, genai.Text(adaptPrompt(text, language, level)))
+	if err != nil {
+		return "", fmt.Errorf("failed to adapt text: %w", err)
+	}
+	if len(res.Candidates) == 0 || res.Candidates[0].Content == nil {
+		return "", fmt.Errorf("no content generated")
+	}
+
+	var b strings.Builder
+	for _, part := range res.Candidates[0].Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			b.WriteString(string(text))
+		}
+	}
+	adapted := strings.TrimSpace(b.String())
+	if adapted == "" {
+		return "", fmt.Errorf("no content generated")
+	}
+	return adapted, nil
+}
+
+func adaptPrompt(text, language, level string) string {
+	promptParts := []string{
+		fmt.Sprintf("Rewrite the following %s text in %s for a learner at %s.", language, language, level),
+		"Keep the meaning, the order of ideas and the paragraphs. Use vocabulary and grammar a learner at that level knows, shorten long sentences and explain rare terms in simple words.",
+		"Reply with the rewritten text only, without a title or comments.",
+		"text:",
+		text,
+	}
+	return strings.Join(promptParts, "\n")
+}
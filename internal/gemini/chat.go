@@ -4,37 +4,34 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/yomek33/talki/internal/models"
 )
 
+// materialExcerptLength bounds how much of the material a system instruction quotes
+const materialExcerptLength = 4000
+
+// ChatSetting is what a chat is about: the scenario it plays out, in the
+// language of its material
+type ChatSetting struct {
+	Scenario models.Scenario
+	// Language is the English name of the language the chat is held in
+	Language string
+	// Level describes the learner's level, such as "CEFR level B1"; empty
+	// when it is not known
+	Level           string
+	MaterialTitle   string
+	MaterialContent string
+}
+
 // SendMessageToGemini sends a message to the Gemini model and returns the response
-func (c *Client) SendMessageToGemini(ctx context.Context, chat *models.Chat, content string) (string, error) {
+func (c *Client) SendMessageToGemini(ctx context.Context, chat *models.Chat, setting ChatSetting, content string) (string, error) {
 	geminiModel := c.client.GenerativeModel("gemini-1.5-flash")
+	geminiModel.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(chatInstruction(setting))}}
 	cs := geminiModel.StartChat()
-
-	// Convert chat messages to Gemini API format
-	for _, msg := range chat.Messages {
-		role := "user"
-		if msg.SenderType == "bot" {
-			role = "model"
-		}
-		cs.History = append(cs.History, &genai.Content{
-			Parts: []genai.Part{
-				genai.Text(msg.Content),
-			},
-			Role: role,
-		})
-	}
-
-	// Append the current user message
-	cs.History = append(cs.History, &genai.Content{
-		Parts: []genai.Part{
-			genai.Text(content),
-		},
-		Role: "user",
-	})
+	cs.History = chatHistory(chat.Messages)
 
 	// Send the message to Gemini
 	resp, err := cs.SendMessage(ctx, genai.Text(content))
@@ -64,6 +61,62 @@ func (c *Client) SendMessageToGemini(ctx context.Context, chat *models.Chat, con
 	return string(part), nil
 }
 
+// chatHistory converts chat messages to the Gemini API format; only bot
+// messages are the model's turns
+func chatHistory(messages []models.Message) []*genai.Content {
+	history := make([]*genai.Content, 0, len(messages))
+	for _, msg := range messages {
+		role := "user"
+		if msg.SenderType == "bot" {
+			role = "model"
+		}
+		history = append(history, &genai.Content{
+			Parts: []genai.Part{
+				genai.Text(msg.Content),
+			},
+			Role: role,
+		})
+	}
+	return history
+}
+
+// chatInstruction turns a chat setting into the system instruction the bot
+// plays its part by
+func chatInstruction(setting ChatSetting) string {
+	scenario := setting.Scenario
+	promptParts := []string{
+		fmt.Sprintf("You are a conversation partner for a learner of %s. Always reply in %s, keep your replies short and end them so that the learner has something to answer.", setting.Language, setting.Language),
+		fmt.Sprintf("Scenario: %s", scenario.Title),
+	}
+	if setting.Level != "" {
+		promptParts = append(promptParts, fmt.Sprintf("Use words and grammar a learner at %s understands.", setting.Level))
+	}
+	if scenario.Description != "" {
+		promptParts = append(promptParts, scenario.Description)
+	}
+	promptParts = append(promptParts, fmt.Sprintf("Your role: %s", scenario.Persona))
+	if scenario.Goals != "" {
+		promptParts = append(promptParts, fmt.Sprintf("The learner's goals: %s", scenario.Goals))
+	}
+	if scenario.SuccessCriteria != "" {
+		promptParts = append(promptParts, fmt.Sprintf("The learner succeeds when: %s. When they have, tell them so and wrap up the scenario.", scenario.SuccessCriteria))
+	}
+
+	if setting.MaterialTitle != "" {
+		if scenario.UsesMaterial {
+			promptParts = append(promptParts, fmt.Sprintf("Base the conversation on the material \"%s\" the learner has read:", setting.MaterialTitle))
+		} else {
+			promptParts = append(promptParts, fmt.Sprintf("The learner has been reading \"%s\"; use it for topics when it fits:", setting.MaterialTitle))
+		}
+		excerpt := []rune(setting.MaterialContent)
+		if len(excerpt) > materialExcerptLength {
+			excerpt = excerpt[:materialExcerptLength]
+		}
+		promptParts = append(promptParts, string(excerpt))
+	}
+	return strings.Join(promptParts, "\n")
+}
+
 func printResponse(resp *genai.GenerateContentResponse) {
 	for _, cand := range resp.Candidates {
 		if cand.Content != nil {
@@ -0,0 +1,49 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+const (
+	embeddingModel = "text-embedding-004"
+	// maxEmbeddingBatch is the request limit of BatchEmbedContents
+	maxEmbeddingBatch = 100
+)
+
+// Embed returns one embedding vector per text, in the same order
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	em := c.client.EmbeddingModel(embeddingModel)
+	em.TaskType = genai.TaskTypeSemanticSimilarity
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingBatch {
+		end := start + maxEmbeddingBatch
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch := em.NewBatch()
+		for _, text := range texts[start:end] {
+			batch.AddContent(genai.Text(text))
+		}
+		res, err := em.BatchEmbedContents(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed contents: %w", err)
+		}
+		if len(res.Embeddings) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(res.Embeddings))
+		}
+		for _, embedding := range res.Embeddings {
+			vectors = append(vectors, embedding.Values)
+		}
+	}
+	return vectors, nil
+}
+
+// EmbeddingModelName identifies the vectors produced by Embed
+func (c *Client) EmbeddingModelName() string {
+	return embeddingModel
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
+	"github.com/yomek33/talki/internal/models"
 )
 
 // func TestSendMessageToGemini(t *testing.T) {
@@ -65,13 +66,92 @@ func TestGenerateWords(t *testing.T) {
 	}
 	defer client.Close()
 
-	inWords, err := client.GenerateIntermediateWords(ctx, "A large language model (LLM) is a computational model notable for its ability to achieve general-purpose language generation and other natural language processing tasks such as classification. Based on language models, LLMs acquire these abilities by learning statistical relationships from vast amounts of text during a computationally intensive self-supervised and semi-supervised training process.[1] LLMs can be used for text generation, a form of generative AI, by taking an input text and repeatedly predicting the next token or word.[2]")
+	inWords, err := client.GenerateIntermediateWords(ctx, "A large language model (LLM) is a computational model notable for its ability to achieve general-purpose language generation and other natural language processing tasks such as classification. Based on language models, LLMs acquire these abilities by learning statistical relationships from vast amounts of text during a computationally intensive self-supervised and semi-supervised training process.[1] LLMs can be used for text generation, a form of generative AI, by taking an input text and repeatedly predicting the next token or word.[2]", "English")
 	fmt.Println(inWords)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, inWords)
 
-	adWords, err := client.GenerateAdvancedWords(ctx, "A large language model (LLM) is a computational model notable for its ability to achieve general-purpose language generation and other natural language processing tasks such as classification. Based on language models, LLMs acquire these abilities by learning statistical relationships from vast amounts of text during a computationally intensive self-supervised and semi-supervised training process.[1] LLMs can be used for text generation, a form of generative AI, by taking an input text and repeatedly predicting the next token or word.[2]")
+	adWords, err := client.GenerateAdvancedWords(ctx, "A large language model (LLM) is a computational model notable for its ability to achieve general-purpose language generation and other natural language processing tasks such as classification. Based on language models, LLMs acquire these abilities by learning statistical relationships from vast amounts of text during a computationally intensive self-supervised and semi-supervised training process.[1] LLMs can be used for text generation, a form of generative AI, by taking an input text and repeatedly predicting the next token or word.[2]", "English")
 	fmt.Println(adWords)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, adWords)
-}
\ No newline at end of file
+}
+func TestGeneratePhrasesPrompt(t *testing.T) {
+	prompt := generatePhrasesPrompt("remote work", PhraseOptions{})
+	assert.Contains(t, prompt, "Generate 10 useful English phrases")
+	assert.Contains(t, prompt, "topic: remote work")
+
+	prompt = generatePhrasesPrompt("remote work", PhraseOptions{Count: 5, Level: "advanced", Focus: "business"})
+	assert.Contains(t, prompt, "Generate 5 useful English phrases")
+	assert.Contains(t, prompt, "advanced level")
+	assert.Contains(t, prompt, "business and professional communication")
+}
+
+func TestAdaptPrompt(t *testing.T) {
+	prompt := adaptPrompt("The committee deliberated at length.", "English", "CEFR level A2")
+	assert.Contains(t, prompt, "English text in English for a learner at CEFR level A2")
+	assert.Contains(t, prompt, "The committee deliberated at length.")
+}
+
+func TestTranslatePrompt(t *testing.T) {
+	prompt, err := translatePrompt([]string{"break the ice", `say "hi"`}, "English", "Japanese")
+	assert.NoError(t, err)
+	assert.Contains(t, prompt, "each English text of the following JSON array into natural Japanese")
+	assert.Contains(t, prompt, `["break the ice","say \"hi\""]`)
+}
+
+func TestChatInstruction(t *testing.T) {
+	instruction := chatInstruction(ChatSetting{
+		Scenario: models.Scenario{
+			Title:           "Restaurant",
+			Persona:         "a waiter at a busy bistro",
+			SuccessCriteria: "the learner has ordered a meal",
+		},
+		Language:        "French",
+		MaterialTitle:   "Paris",
+		MaterialContent: "Paris is the capital of France.",
+	})
+	assert.Contains(t, instruction, "learner of French. Always reply in French")
+	assert.Contains(t, instruction, "Your role: a waiter at a busy bistro")
+	assert.Contains(t, instruction, "The learner succeeds when: the learner has ordered a meal")
+	assert.Contains(t, instruction, `use it for topics when it fits`)
+	assert.NotContains(t, instruction, "goals")
+
+	instruction = chatInstruction(ChatSetting{
+		Scenario:        models.Scenario{Title: "Socratic Q&A", Persona: "a tutor", UsesMaterial: true},
+		Language:        "English",
+		MaterialTitle:   "Paris",
+		MaterialContent: "Paris is the capital of France.",
+	})
+	assert.Contains(t, instruction, `Base the conversation on the material "Paris"`)
+	assert.Contains(t, instruction, "Paris is the capital of France.")
+}
+
+func TestAssessPrompt(t *testing.T) {
+	prompt := assessPrompt([]models.Message{
+		{SenderType: "system", Content: "Hello"},
+		{SenderType: "bot", Content: "What would you like to order?"},
+		{SenderType: "user", Content: "I would like a coffee, please."},
+	}, "English", []string{"I would like"})
+	assert.Contains(t, prompt, "You are a teacher of English")
+	assert.Contains(t, prompt, "practicing these phrases: I would like")
+	assert.Contains(t, prompt, "Partner: What would you like to order?\nLearner: I would like a coffee, please.")
+	assert.NotContains(t, prompt, "Hello")
+}
+
+func TestSuggestInstruction(t *testing.T) {
+	setting := ChatSetting{
+		Scenario: models.Scenario{Title: "Restaurant", Persona: "a waiter"},
+		Language: "English",
+		Level:    "CEFR level A2",
+	}
+	instruction := suggestInstruction(setting, []string{"I would like"}, false)
+	assert.Contains(t, instruction, "short replies in English")
+	assert.Contains(t, instruction, "a learner at CEFR level A2")
+	assert.Contains(t, instruction, "practicing: I would like")
+
+	setting.Level = ""
+	instruction = suggestInstruction(setting, nil, true)
+	assert.Contains(t, instruction, "sentence starters in English that a beginner can finish")
+	assert.NotContains(t, instruction, "practicing")
+}
@@ -43,9 +43,26 @@ func (c *Client) GenerateJsonContent(ctx context.Context, prompt string) ([]stri
 	return output, nil
 }
 
+// PhraseOptions tunes phrase generation. Zero values fall back to the defaults.
+type PhraseOptions struct {
+	Count int
+	// Level is a learner level such as "beginner", "intermediate" or "advanced"
+	Level string
+	// Focus narrows the kind of phrases, e.g. "idioms", "collocations" or "business"
+	Focus string
+	// Language is the English name of the language of the phrases; empty means English
+	Language string
+}
+
+const DefaultPhraseCount = 10
+
 func (c *Client) GeneratePhrases(ctx context.Context, topic string) ([]string, error) {
+	return c.GeneratePhrasesWithOptions(ctx, topic, PhraseOptions{})
+}
+
+func (c *Client) GeneratePhrasesWithOptions(ctx context.Context, topic string, opts PhraseOptions) ([]string, error) {
 	log.Print("Generating phrases")
-	prompt := generatePhrasesPrompt(topic)
+	prompt := generatePhrasesPrompt(topic, opts)
 	output, err := c.GenerateJsonContent(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate phrases: %w", err)
@@ -54,9 +71,34 @@ func (c *Client) GeneratePhrases(ctx context.Context, topic string) ([]string, e
 	return output, nil
 }
 
-func generatePhrasesPrompt(topic string) string {
+func generatePhrasesPrompt(topic string, opts PhraseOptions) string {
+	count := opts.Count
+	if count <= 0 {
+		count = DefaultPhraseCount
+	}
+	lang := opts.Language
+	if lang == "" {
+		lang = "English"
+	}
+	instruction := fmt.Sprintf("Generate %d useful %s phrases related to {topic}, focusing on {action verb} (e.g., describing, discussing). Include synonyms and related terms for {topic}.", count, lang)
+	if opts.Level != "" {
+		instruction += fmt.Sprintf(" The phrases should suit a learner at the %s level.", opts.Level)
+	}
+	switch opts.Focus {
+	case "idioms":
+		instruction += " Prefer idiomatic expressions a native speaker would use."
+	case "collocations":
+		instruction += " Prefer natural collocations, i.e. words that commonly go together."
+	case "business":
+		instruction += " Prefer phrases suited to business and professional communication."
+	}
+
+	if lang != "English" {
+		instruction += fmt.Sprintf(" Write the phrases in %s, even though the example below is in English.", lang)
+	}
+
 	promptParts := []string{
-		"Generate 10 useful English phrases related to {topic}, focusing on {action verb} (e.g., describing, discussing). Include synonyms and related terms for {topic}.",
+		instruction,
 		"topic: climate change",
 		"output: [ \"The planet is experiencing an unprecedented rise in global temperatures.\", \"Human activities are the primary drivers of climate change.\", \"Rising sea levels threaten coastal communities around the world.\", \"Extreme weather events, such as hurricanes and heatwaves, are becoming more frequent and intense.\", \"Greenhouse gases, such as carbon dioxide and methane, trap heat in the atmosphere.\", \"Climate change poses a significant threat to biodiversity and ecosystems.\", \"Renewable energy sources, such as solar and wind power, are essential for mitigating climate change.\", \"Carbon emissions must be drastically reduced to limit global warming.\", \"Climate change is a complex and urgent issue that requires global cooperation.\", \"Sustainable practices, such as reducing consumption and improving energy efficiency, are crucial for addressing climate change.\" ]",
 		fmt.Sprintf("topic: %s", topic),
@@ -66,9 +108,9 @@ func generatePhrasesPrompt(topic string) string {
 	return strings.Join(promptParts, "\n")
 }
 
-func (c *Client) GenerateIntermediateWords(ctx context.Context, topic string) ([]string, error) {
+func (c *Client) GenerateIntermediateWords(ctx context.Context, topic, language string) ([]string, error) {
 	log.Print("Generating words")
-	prompt := generateIntermediateWordsPrompt(topic)
+	prompt := generateIntermediateWordsPrompt(topic, language)
 	output, err := c.GenerateJsonContent(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate words: %w", err)
@@ -76,9 +118,9 @@ func (c *Client) GenerateIntermediateWords(ctx context.Context, topic string) ([
 	return output, nil
 }
 
-func generateIntermediateWordsPrompt(topic string) string {
+func generateIntermediateWordsPrompt(topic, language string) string {
 	promptParts := []string{
-		"Generate 20 useful English words related to {topic} at a intermediate level.",
+		fmt.Sprintf("Generate 20 useful %s words related to {topic} at a intermediate level.", language),
 		fmt.Sprintf("topic: %s", topic),
 		"output: ",
 	}
@@ -86,9 +128,9 @@ func generateIntermediateWordsPrompt(topic string) string {
 	return strings.Join(promptParts, "\n")
 }
 
-func (c *Client) GenerateAdvancedWords(ctx context.Context, topic string) ([]string, error) {
+func (c *Client) GenerateAdvancedWords(ctx context.Context, topic, language string) ([]string, error) {
 	log.Print("Generating advanced words")
-	prompt := generateAdvancedWordsPrompt(topic)
+	prompt := generateAdvancedWordsPrompt(topic, language)
 	output, err := c.GenerateJsonContent(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate advanced words: %w", err)
@@ -97,9 +139,9 @@ func (c *Client) GenerateAdvancedWords(ctx context.Context, topic string) ([]str
 	return output, nil
 }
 
-func generateAdvancedWordsPrompt(topic string) string {
+func generateAdvancedWordsPrompt(topic, language string) string {
 	promptParts := []string{
-		"Generate 20 useful English words related to {topic} at an advanced level.",
+		fmt.Sprintf("Generate 20 useful %s words related to {topic} at an advanced level.", language),
 		fmt.Sprintf("topic: %s", topic),
 		"output: ",
 	}
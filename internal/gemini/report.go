@@ -0,0 +1,124 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/yomek33/talki/internal/models"
+)
+
+// ConversationAssessment is the model's view of a learner's part in a chat.
+// Scores run from 0 to 100.
+type ConversationAssessment struct {
+	Fluency         int    `json:"fluency"`
+	Accuracy        int    `json:"accuracy"`
+	VocabularyRange int    `json:"vocabulary_range"`
+	Summary         string `json:"summary"`
+	Mistakes        []struct {
+		Original    string `json:"original"`
+		Correction  string `json:"correction"`
+		Explanation string `json:"explanation"`
+		Count       int    `json:"count"`
+	} `json:"mistakes"`
+	Suggestions []struct {
+		Phrase string `json:"phrase"`
+		Reason string `json:"reason"`
+	} `json:"suggestions"`
+}
+
+var assessmentSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"fluency":          {Type: genai.TypeInteger},
+		"accuracy":         {Type: genai.TypeInteger},
+		"vocabulary_range": {Type: genai.TypeInteger},
+		"summary":          {Type: genai.TypeString},
+		"mistakes": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"original":    {Type: genai.TypeString},
+					"correction":  {Type: genai.TypeString},
+					"explanation": {Type: genai.TypeString},
+					"count":       {Type: genai.TypeInteger},
+				},
+				Required: []string{"original", "correction", "explanation", "count"},
+			},
+		},
+		"suggestions": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"phrase": {Type: genai.TypeString},
+					"reason": {Type: genai.TypeString},
+				},
+				Required: []string{"phrase", "reason"},
+			},
+		},
+	},
+	Required: []string{"fluency", "accuracy", "vocabulary_range", "summary", "mistakes", "suggestions"},
+}
+
+// AssessConversation scores the learner's messages of a chat held in language,
+// given by its English name. Phrases are the ones the learner was meant to
+// practice.
+func (c *Client) AssessConversation(ctx context.Context, messages []models.Message, language string, phrases []string) (*ConversationAssessment, error) {
+	log.Printf("Assessing a conversation of %d messages", len(messages))
+	model := c.client.GenerativeModel("gemini-1.5-flash")
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = assessmentSchema
+
+	res, err := model.GenerateContent(ctx, genai.Text(assessPrompt(messages, language, phrases)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to assess conversation: %w", err)
+	}
+	if len(res.Candidates) == 0 || res.Candidates[0].Content == nil || len(res.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content generated")
+	}
+	part, ok := res.Candidates[0].Content.Parts[0].(genai.Text)
+	if !ok {
+		return nil, fmt.Errorf("part is not a string")
+	}
+
+	var assessment ConversationAssessment
+	if err := json.Unmarshal([]byte(part), &assessment); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	assessment.Fluency = clampScore(assessment.Fluency)
+	assessment.Accuracy = clampScore(assessment.Accuracy)
+	assessment.VocabularyRange = clampScore(assessment.VocabularyRange)
+	return &assessment, nil
+}
+
+func assessPrompt(messages []models.Message, language string, phrases []string) string {
+	promptParts := []string{
+		fmt.Sprintf("You are a teacher of %s reviewing a conversation between a learner and their conversation partner.", language),
+		"Score only the learner's messages from 0 to 100 on fluency (natural, connected expression), accuracy (grammar and word choice) and vocabulary_range (variety and level of the words used).",
+		"List the learner's recurring mistakes with the original wording, a correction, a short explanation and how often it occurred, most frequent first.",
+		"Suggest up to five phrases to practice next that would have helped in this conversation, each with the reason.",
+		"Write a two-sentence summary addressed to the learner.",
+	}
+	if len(phrases) > 0 {
+		promptParts = append(promptParts, "The learner was practicing these phrases: "+strings.Join(phrases, "; "))
+	}
+	promptParts = append(promptParts, "conversation:")
+	for _, msg := range messages {
+		switch msg.SenderType {
+		case "user":
+			promptParts = append(promptParts, "Learner: "+msg.Content)
+		case "bot":
+			promptParts = append(promptParts, "Partner: "+msg.Content)
+		}
+	}
+	return strings.Join(promptParts, "\n")
+}
+
+func clampScore(score int) int {
+	return max(0, min(score, 100))
+}
@@ -0,0 +1,76 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/yomek33/talki/internal/models"
+)
+
+// MaxSuggestions is the most replies SuggestReplies returns
+const MaxSuggestions = 3
+
+// suggestRequest is the learner's last turn that asks for the suggestions
+const suggestRequest = "What could I say next?"
+
+// SuggestReplies proposes what the learner could say next in a chat, using
+// phrases they are practicing where they fit. With hint, the suggestions are
+// sentence starters for the learner to finish instead of whole replies.
+func (c *Client) SuggestReplies(ctx context.Context, chat *models.Chat, setting ChatSetting, phrases []string, hint bool) ([]string, error) {
+	geminiModel := c.client.GenerativeModel("gemini-1.5-flash")
+	geminiModel.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(suggestInstruction(setting, phrases, hint))}}
+	geminiModel.ResponseMIMEType = "application/json"
+	geminiModel.ResponseSchema = &genai.Schema{
+		Type:  genai.TypeArray,
+		Items: &genai.Schema{Type: genai.TypeString},
+	}
+	cs := geminiModel.StartChat()
+	cs.History = chatHistory(chat.Messages)
+
+	resp, err := cs.SendMessage(ctx, genai.Text(suggestRequest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest replies: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content generated")
+	}
+	part, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
+	if !ok {
+		return nil, fmt.Errorf("part is not a string")
+	}
+
+	var suggestions []string
+	if err := json.Unmarshal([]byte(part), &suggestions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	if len(suggestions) > MaxSuggestions {
+		suggestions = suggestions[:MaxSuggestions]
+	}
+	return suggestions, nil
+}
+
+func suggestInstruction(setting ChatSetting, phrases []string, hint bool) string {
+	promptParts := []string{
+		fmt.Sprintf("You help a learner of %s who is stuck in a conversation. The model turns are their conversation partner's, the user turns are the learner's.", setting.Language),
+		fmt.Sprintf("Scenario: %s. The partner plays %s.", setting.Scenario.Title, setting.Scenario.Persona),
+	}
+	if setting.Scenario.Goals != "" {
+		promptParts = append(promptParts, fmt.Sprintf("The learner's goals: %s", setting.Scenario.Goals))
+	}
+	level := "a beginner"
+	if setting.Level != "" {
+		level = "a learner at " + setting.Level
+	}
+	if hint {
+		promptParts = append(promptParts, fmt.Sprintf("When the learner asks what to say next, reply with a JSON array of 2 to %d different sentence starters in %s that %s can finish in their own words, each ending with \"...\".", MaxSuggestions, setting.Language, level))
+	} else {
+		promptParts = append(promptParts, fmt.Sprintf("When the learner asks what to say next, reply with a JSON array of 2 to %d different short replies in %s to the partner's last turn, written as %s would say them.", MaxSuggestions, setting.Language, level))
+	}
+	if len(phrases) > 0 {
+		promptParts = append(promptParts, "Where they fit naturally, use these phrases the learner is practicing: "+strings.Join(phrases, "; "))
+	}
+	return strings.Join(promptParts, "\n")
+}
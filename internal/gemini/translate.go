@@ -0,0 +1,43 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// TranslateTexts translates each of texts from one language into another, both
+// given by their English name such as "Japanese", returning the translations in
+// the same order
+func (c *Client) TranslateTexts(ctx context.Context, texts []string, from, language string) ([]string, error) {
+	log.Printf("Translating %d texts from %s into %s", len(texts), from, language)
+	prompt, err := translatePrompt(texts, from, language)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := c.GenerateJsonContent(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate: %w", err)
+	}
+	if len(translations) != len(texts) {
+		return nil, fmt.Errorf("expected %d translations, got %d", len(texts), len(translations))
+	}
+	return translations, nil
+}
+
+func translatePrompt(texts []string, from, language string) (string, error) {
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return "", err
+	}
+	promptParts := []string{
+		fmt.Sprintf("Translate each %s text of the following JSON array into natural %s for a language learner.", from, language),
+		"Translate idioms and phrasal verbs by their meaning, not word by word.",
+		"Reply with a JSON array of the translations only, in the same order and with exactly one translation per text.",
+		"texts:",
+		string(input),
+	}
+	return strings.Join(promptParts, "\n"), nil
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+)
+
+// POST /api/materials/:id/adapt?level=B1 rewrites the material at a level of the
+// scale of its language: CEFR, JLPT (N5 to N1) for Japanese or HSK (1 to 6) for Chinese.
+// The rewrite runs in the background; poll GET /api/jobs/:id, whose result_id is
+// the new child material.
+func (h *materialHandler) AdaptMaterial(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	job, err := h.jobService.StartAdaptation(materialID, UserUID, c.QueryParam("level"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMaterialNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		case errors.Is(err, services.ErrInvalidAdaptLevel), errors.Is(err, services.ErrEmptyContent):
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to start adaptation: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedAdaptMaterial)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+}
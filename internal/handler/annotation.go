@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+)
+
+type AnnotationHandler interface {
+	GetAnnotations(c echo.Context) error
+}
+
+type annotationHandler struct {
+	annotationService services.AnnotationService
+}
+
+func NewAnnotationHandler(as services.AnnotationService) AnnotationHandler {
+	return &annotationHandler{annotationService: as}
+}
+
+// GET /api/materials/:id/annotations returns the material split into
+// paragraphs, sentences and tokens with the offsets of its phrases and words
+func (h *annotationHandler) GetAnnotations(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	annotation, err := h.annotationService.Annotate(materialID, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to annotate material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedAnnotateMaterial)
+	}
+	return c.JSON(http.StatusOK, annotation)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/yomek33/talki/internal/config"
+	"github.com/yomek33/talki/internal/language"
 	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
 	"gorm.io/gorm"
@@ -47,8 +48,10 @@ func (h *userHandler) GetGoogleLoginSignin(c echo.Context) error {
 			return respondWithError(c, http.StatusBadRequest, "Invalid token claims")
 		}
 		user = &models.User{
-			UserUID: token.UID,
-			Name:    name,
+			UserUID:        token.UID,
+			Name:           name,
+			NativeLanguage: models.DefaultNativeLanguage,
+			TargetLanguage: language.Default,
 		}
 		if err := h.UserService.CreateUser(user); err != nil {
 			logger.Errorf("Error creating user: %v", err)
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
 	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
 )
 
 // ChatHandler defines the interface for chat-related operations
@@ -16,6 +18,7 @@ type ChatHandler interface {
 	GetChatByMaterialID(c echo.Context) error
 	GetChatByChatID(c echo.Context) error
 	ChatWithGemini(c echo.Context) error
+	GetSuggestions(c echo.Context) error
 }
 
 // chatHandler implements the ChatHandler interface
@@ -48,8 +51,18 @@ func (h *chatHandler) CreateChat(c echo.Context) error {
 
 	chat.UserUID = userUID
 	chat.CreatedAt = time.Now()
+	// the branch and the reply state are the server's to keep
+	chat.ActiveLeafID = nil
+	chat.PendingMessage = 0
 	createdChat, err := h.chatService.CreateChat(&chat)
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidChatMode),
+			errors.Is(err, services.ErrScenarioRequired):
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrScenarioNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrScenarioNotFound)
+		}
 		logger.Errorf("Error creating chat: %v", err)
 		return respondWithError(c, http.StatusInternalServerError, "Could not create chat")
 	}
@@ -72,7 +85,7 @@ func (h *chatHandler) CreateChat(c echo.Context) error {
 	return c.JSON(http.StatusCreated, createdChat)
 }
 
-//　もしChatが存在しない場合、createChatを呼び出す
+// 　もしChatが存在しない場合、createChatを呼び出す
 func (h *chatHandler) GetChatByMaterialID(c echo.Context) error {
 	logger.Infof("Retrieving chat by material ID")
 	materialID, err := parseUintParam(c, "id")
@@ -85,8 +98,16 @@ func (h *chatHandler) GetChatByMaterialID(c echo.Context) error {
 		return respondWithError(c, http.StatusUnauthorized, "Invalid user token")
 	}
 
-	chat, err := h.chatService.GetChatsByMaterialID(materialID, userUID)
+	page, err := parsePageQuery(c)
 	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	chat, err := h.chatService.GetChatsByMaterialID(materialID, userUID, page)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
 		logger.Errorf("Chat room not found: %v", err)
 		return respondWithError(c, http.StatusNotFound, "Chat room not found")
 	}
@@ -138,10 +159,49 @@ func (h *chatHandler) ChatWithGemini(c echo.Context) error {
 
 	logger.Info("Sending message to Gemini API")
 	response, err := h.messageService.SendMessageToGemini(chatID, request.Content, userUID)
+	if errors.Is(err, services.ErrChatNotFound) {
+		return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+	}
+	if errors.Is(err, services.ErrReplyPending) {
+		return respondWithError(c, http.StatusConflict, err.Error())
+	}
 	if err != nil {
 		logger.Errorf("Error communicating with Gemini API: %v", err)
 		return respondWithError(c, http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{"response":  "received", "message": response})
+	return c.JSON(http.StatusOK, echo.Map{"response": "received", "message": response})
+}
+
+// GET /api/chat/:chatId/suggestions?mode=replies|hint suggests what the
+// learner could say next; hint mode gives sentence starters to finish
+func (h *chatHandler) GetSuggestions(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+
+	userUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var hint bool
+	switch c.QueryParam("mode") {
+	case "", "replies":
+	case "hint":
+		hint = true
+	default:
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidSuggestionMode)
+	}
+
+	suggestions, err := h.messageService.SuggestReplies(chatID, userUID, hint)
+	if err != nil {
+		if errors.Is(err, services.ErrChatNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+		}
+		logger.Errorf("Failed to suggest replies: %v, ChatID: %v, UserUID: %v", err, chatID, userUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedSuggestReplies)
+	}
+	return c.JSON(http.StatusOK, suggestions)
 }
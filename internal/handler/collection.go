@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+type CollectionHandler interface {
+	GetCollections(c echo.Context) error
+	CreateCollection(c echo.Context) error
+	UpdateCollection(c echo.Context) error
+	DeleteCollection(c echo.Context) error
+	AddMaterialsToCollection(c echo.Context) error
+	RemoveMaterialFromCollection(c echo.Context) error
+}
+
+type collectionHandler struct {
+	collectionService services.CollectionService
+}
+
+type collectionMaterialsRequest struct {
+	MaterialIDs []uint `json:"material_ids" validate:"required,min=1"`
+}
+
+func NewCollectionHandler(cs services.CollectionService) CollectionHandler {
+	return &collectionHandler{collectionService: cs}
+}
+
+// GET /api/collections returns the collections as a tree
+func (h *collectionHandler) GetCollections(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	collections, err := h.collectionService.GetCollectionTree(UserUID)
+	if err != nil {
+		logger.Errorf("Failed to retrieve collections: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveCollections)
+	}
+	if collections == nil {
+		collections = []models.Collection{}
+	}
+	return c.JSON(http.StatusOK, collections)
+}
+
+// POST /api/collections
+func (h *collectionHandler) CreateCollection(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var collection models.Collection
+	if err := c.Bind(&collection); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidCollectionData)
+	}
+	collection.ID = 0
+	collection.UserUID = UserUID
+
+	if err := h.collectionService.CreateCollection(&collection); err != nil {
+		return respondWithCollectionError(c, err, UserUID)
+	}
+
+	logger.Infof("Created collection, CollectionID: %v, UserUID: %v", collection.ID, UserUID)
+	return c.JSON(http.StatusCreated, collection)
+}
+
+// PUT /api/collections/:id renames or moves a collection
+func (h *collectionHandler) UpdateCollection(c echo.Context) error {
+	collectionID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var collection models.Collection
+	if err := c.Bind(&collection); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidCollectionData)
+	}
+	collection.ID = collectionID
+	collection.UserUID = UserUID
+
+	if err := h.collectionService.UpdateCollection(&collection); err != nil {
+		return respondWithCollectionError(c, err, UserUID)
+	}
+
+	logger.Infof("Updated collection, CollectionID: %v, UserUID: %v", collectionID, UserUID)
+	return c.JSON(http.StatusOK, collection)
+}
+
+// DELETE /api/collections/:id
+func (h *collectionHandler) DeleteCollection(c echo.Context) error {
+	collectionID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	if err := h.collectionService.DeleteCollection(collectionID, UserUID); err != nil {
+		return respondWithCollectionError(c, err, UserUID)
+	}
+
+	logger.Infof("Deleted collection, CollectionID: %v, UserUID: %v", collectionID, UserUID)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /api/collections/:id/materials
+func (h *collectionHandler) AddMaterialsToCollection(c echo.Context) error {
+	collectionID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req collectionMaterialsRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidCollectionData)
+	}
+	if err := c.Validate(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.collectionService.AddMaterials(collectionID, req.MaterialIDs, UserUID); err != nil {
+		return respondWithCollectionError(c, err, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DELETE /api/collections/:id/materials/:materialId
+func (h *collectionHandler) RemoveMaterialFromCollection(c echo.Context) error {
+	collectionID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+	materialID, err := parseUintParam(c, "materialId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	if err := h.collectionService.RemoveMaterials(collectionID, []uint{materialID}, UserUID); err != nil {
+		return respondWithCollectionError(c, err, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func respondWithCollectionError(c echo.Context, err error, UserUID string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, services.ErrCollectionNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrCollectionNotFound)
+	case errors.Is(err, services.ErrCollectionNameRequired),
+		errors.Is(err, services.ErrCollectionCycle),
+		errors.Is(err, services.ErrEmptyMaterialIDs),
+		errors.Is(err, services.ErrTooManyMaterials),
+		errors.Is(err, stores.ErrUnknownMaterialsOrTags):
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	logger.Errorf("Collection operation failed: %v, UserUID: %v", err, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateCollection)
+}
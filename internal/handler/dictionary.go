@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/services"
+)
+
+type DictionaryHandler interface {
+	LookupWord(c echo.Context) error
+}
+
+type dictionaryHandler struct {
+	dictionaryService services.DictionaryService
+}
+
+func NewDictionaryHandler(ds services.DictionaryService) DictionaryHandler {
+	return &dictionaryHandler{dictionaryService: ds}
+}
+
+// GET /api/dictionary/:word returns the lemma, senses, examples and
+// pronunciations of a word from the offline dictionary
+func (h *dictionaryHandler) LookupWord(c echo.Context) error {
+	if _, err := getUserUIDFromContext(c); err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	entry, err := h.dictionaryService.Lookup(c.Param("word"))
+	if err != nil {
+		if errors.Is(err, services.ErrEmptyText) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidDictionaryWord)
+		}
+		return respondWithError(c, http.StatusNotFound, ErrDictionaryNotFound)
+	}
+	return c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/embedding"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRelatedLimit = 5
+	embeddingTimeout    = 30 * time.Second
+)
+
+type EmbeddingHandler interface {
+	GetRelatedMaterials(c echo.Context) error
+	SemanticSearch(c echo.Context) error
+}
+
+type embeddingHandler struct {
+	embeddingService services.EmbeddingService
+}
+
+func NewEmbeddingHandler(es services.EmbeddingService) EmbeddingHandler {
+	return &embeddingHandler{embeddingService: es}
+}
+
+// GET /api/materials/:id/related?limit=
+func (h *embeddingHandler) GetRelatedMaterials(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	limit, err := parseLimitParam(c, defaultRelatedLimit)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), embeddingTimeout)
+	defer cancel()
+
+	related, err := h.embeddingService.RelatedMaterials(ctx, materialID, UserUID, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to find related materials: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRelatedMaterials)
+	}
+
+	return c.JSON(http.StatusOK, related)
+}
+
+// GET /api/search/semantic?q=&types=material,phrase&limit=
+func (h *embeddingHandler) SemanticSearch(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return respondWithError(c, http.StatusBadRequest, ErrEmptySearchQuery)
+	}
+
+	var kinds []string
+	if types := c.QueryParam("types"); types != "" {
+		for _, kind := range strings.Split(types, ",") {
+			kind = strings.TrimSpace(kind)
+			if kind != embedding.KindMaterial && kind != embedding.KindPhrase {
+				return respondWithError(c, http.StatusBadRequest, ErrInvalidSearchType)
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	limit, err := parseLimitParam(c, defaultPageLimit)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), embeddingTimeout)
+	defer cancel()
+
+	results, err := h.embeddingService.SemanticSearch(ctx, UserUID, query, kinds, limit)
+	if err != nil {
+		logger.Errorf("Failed semantic search: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedSearch)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"query": query, "results": results})
+}
+
+func parseLimitParam(c echo.Context, fallback int) (int, error) {
+	param := c.QueryParam("limit")
+	if param == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(param)
+	if err != nil || limit <= 0 || limit > maxPageLimit {
+		return 0, errors.New(ErrInvalidPageParams)
+	}
+	return limit, nil
+}
@@ -1,27 +1,114 @@
 package handler
 
 const (
-	ErrInvalidUserData     = "invalid user data"
-	ErrCouldNotCreateUser  = "could not create user"
-	ErrInvalidUserToken    = "invalid user token"
-	ErrUserNotFound        = "user not found"
-	ErrInvalidUserUID      = "invalid user ID"
-	ErrCouldNotUpdateUser  = "could not update user"
-	ErrCouldNotDeleteUser  = "could not delete user"
-	ErrInvalidCredentials  = "invalid credentials"
-	TokenExpirationMinutes = 60
+	ErrInvalidUserData       = "invalid user data"
+	ErrCouldNotCreateUser    = "could not create user"
+	ErrInvalidUserToken      = "invalid user token"
+	ErrUserNotFound          = "user not found"
+	ErrInvalidUserUID        = "invalid user ID"
+	ErrCouldNotUpdateUser    = "could not update user"
+	ErrCouldNotDeleteUser    = "could not delete user"
+	ErrFailedRetrieveUser    = "failed to retrieve user"
+	ErrInvalidNativeLanguage = "unsupported native language"
+	ErrInvalidLanguage       = "unsupported language"
+	ErrInvalidCredentials    = "invalid credentials"
+	TokenExpirationMinutes   = 60
 
 	ErrInvalidMaterialID       = "invalid material ID format"
 	ErrInvalidMaterialData     = "invalid material data"
 	ErrForbiddenModify         = "forbidden to modify this material"
 	ErrFailedUpdateMaterial    = "failed to update material"
 	ErrInvalidID               = "invalid ID"
+	ErrInvalidPageParams       = "invalid pagination parameters"
+	ErrInvalidSort             = "invalid sort parameter"
+	ErrInvalidLevelFilter      = "level must be one of A1, A2, B1, B2, C1, C2"
+	ErrInvalidGradeFilter      = "max_grade must be a number"
 	ErrFailedDeleteMaterial    = "failed to delete material"
 	ErrFailedRetrieveMaterials = "failed to retrieve materials"
 	ErrFailedCreateMaterial    = "failed to create material"
 	ErrMaterialNotFound        = "material not found"
+	ErrInvalidClippingsFile    = "invalid clippings file"
+	ErrFailedImportClippings   = "failed to import clippings"
+	ErrClippingsFileTooLarge   = "clippings file is too large"
+	ErrInvalidRevisionNumber   = "invalid revision number"
+	ErrRevisionNotFound        = "revision not found"
+	ErrFailedRetrieveRevisions = "failed to retrieve revisions"
+	ErrFailedRestoreRevision   = "failed to restore revision"
+	ErrInvalidPhraseData       = "invalid phrase data"
+	ErrInvalidWordData         = "invalid word data"
+	ErrFailedRetrieveWords     = "failed to retrieve words"
+	ErrFailedUpdateItems       = "failed to update phrases or words"
+	ErrFailedRetrieveReview    = "failed to retrieve review queue"
+	ErrJobNotFound             = "job not found"
+	ErrFailedRetrieveJob       = "failed to retrieve job"
+	ErrFailedRetrieveTrash     = "failed to retrieve trash"
+	ErrFailedRestoreMaterial   = "failed to restore material"
+	ErrFailedPurgeMaterial     = "failed to purge material"
 
 	ErrFailedCreateChat = "failed to create chat"
 	ErrInvalidChatID    = "invalid chat ID"
+	ErrChatNotFound     = "chat not found"
+	ErrInvalidMessageID = "invalid message ID"
+	ErrMessageNotFound  = "message not found"
+	ErrNotBotMessage    = "only bot messages can be translated"
+	ErrFailedTranslate  = "failed to translate"
 	ErrGeminiAPI        = "error communicating with Gemini API"
+
+	ErrEmptySearchQuery  = "search query cannot be empty"
+	ErrInvalidSearchType = "invalid search type"
+	ErrFailedSearch      = "failed to search"
+
+	ErrFailedRelatedMaterials = "failed to find related materials"
+
+	ErrInvalidTagData     = "invalid tag data"
+	ErrTagNotFound        = "tag not found"
+	ErrDuplicateTag       = "a tag with this name already exists"
+	ErrFailedRetrieveTags = "failed to retrieve tags"
+	ErrFailedUpdateTags   = "failed to update tags"
+
+	ErrInvalidCollectionData     = "invalid collection data"
+	ErrCollectionNotFound        = "collection not found"
+	ErrFailedRetrieveCollections = "failed to retrieve collections"
+	ErrFailedUpdateCollection    = "failed to update collection"
+
+	ErrInvalidShareData    = "invalid share data"
+	ErrFailedShareMaterial = "failed to update material sharing"
+	ErrFailedForkMaterial  = "failed to fork material"
+	ErrFailedAdaptMaterial = "failed to adapt material"
+
+	ErrFailedAnnotateMaterial = "failed to annotate material"
+
+	ErrInvalidScenarioData     = "invalid scenario data"
+	ErrScenarioNotFound        = "scenario not found"
+	ErrFailedRetrieveScenarios = "failed to retrieve scenarios"
+	ErrFailedUpdateScenario    = "failed to update scenario"
+
+	ErrFailedUpdateConversation = "failed to update conversation"
+	ErrFailedRetrieveUsage      = "failed to retrieve phrase usage"
+	ErrInvalidSuggestionMode    = "mode must be replies or hint"
+	ErrFailedSuggestReplies     = "failed to suggest replies"
+
+	ErrInvalidReportID      = "invalid report ID"
+	ErrReportNotFound       = "report not found"
+	ErrFailedCreateReport   = "failed to create report"
+	ErrFailedRetrieveReport = "failed to retrieve reports"
+
+	ErrInvalidDictionaryWord = "word cannot be empty"
+	ErrDictionaryNotFound    = "word not found in dictionary"
+
+	ErrInvalidVocabularyID      = "invalid vocabulary entry ID"
+	ErrInvalidVocabularyData    = "invalid vocabulary entry data"
+	ErrVocabularyNotFound       = "vocabulary entry not found"
+	ErrInvalidExportFormat      = "format must be json or csv"
+	ErrFailedRetrieveVocabulary = "failed to retrieve vocabulary"
+	ErrFailedUpdateVocabulary   = "failed to update vocabulary entry"
+
+	ErrInvalidKnownWordData     = "words must contain at least one word"
+	ErrFailedRetrieveKnownWords = "failed to retrieve known words"
+	ErrFailedUpdateKnownWords   = "failed to update known words"
+	ErrInvalidReviewData        = "invalid review results"
+	ErrFailedRecordReview       = "failed to record review results"
+
+	defaultPageLimit = 20
+	maxPageLimit     = 100
 )
@@ -19,19 +19,49 @@ type Handlers struct {
 	PhraseHandler
 	ChatHandler
 	MessageHandler
+	SearchHandler
+	EmbeddingHandler
+	TagHandler
+	CollectionHandler
+	SharingHandler
+	TrashHandler
+	WordHandler
+	ReviewHandler
+	JobHandler
+	VocabularyHandler
+	KnownWordHandler
+	AnnotationHandler
+	DictionaryHandler
+	ScenarioHandler
+	ReportHandler
 	jwtSecretKey string
 	Firebase     *Firebase
 }
 
 func NewHandler(s *services.Services, jwtSecretKey string, firebase *Firebase) *Handlers {
 	return &Handlers{
-		UserHandler:     &userHandler{UserService: s.UserService, jwtSecretKey: jwtSecretKey, Firebase: firebase},
-		MaterialHandler: &materialHandler{MaterialService: s.MaterialService, PhraseService: s.PhraseService},
-		PhraseHandler:   &phraseHandler{PhraseService: s.PhraseService},
-		ChatHandler:     &chatHandler{chatService: s.ChatService, messageService: s.MessageService, materialService: s.MaterialService},
-		MessageHandler:  &messageHandler{messageService: s.MessageService},
-		jwtSecretKey:    jwtSecretKey,
-		Firebase:        firebase,
+		UserHandler:       &userHandler{UserService: s.UserService, jwtSecretKey: jwtSecretKey, Firebase: firebase},
+		MaterialHandler:   &materialHandler{MaterialService: s.MaterialService, PhraseService: s.PhraseService, importService: s.ImportService, embeddingService: s.EmbeddingService, collectionService: s.CollectionService, sharingService: s.SharingService, knownWordService: s.KnownWordService, jobService: s.JobService},
+		PhraseHandler:     &phraseHandler{PhraseService: s.PhraseService, jobService: s.JobService, translationService: s.TranslationService},
+		ChatHandler:       &chatHandler{chatService: s.ChatService, messageService: s.MessageService, materialService: s.MaterialService},
+		MessageHandler:    &messageHandler{messageService: s.MessageService, translationService: s.TranslationService, usageService: s.UsageService},
+		SearchHandler:     &searchHandler{searchService: s.SearchService},
+		EmbeddingHandler:  &embeddingHandler{embeddingService: s.EmbeddingService},
+		TagHandler:        &tagHandler{tagService: s.TagService},
+		CollectionHandler: &collectionHandler{collectionService: s.CollectionService},
+		SharingHandler:    &sharingHandler{sharingService: s.SharingService, embeddingService: s.EmbeddingService},
+		TrashHandler:      &trashHandler{trashService: s.TrashService, embeddingService: s.EmbeddingService},
+		WordHandler:       &wordHandler{wordService: s.WordService, translationService: s.TranslationService},
+		ReviewHandler:     &reviewHandler{reviewService: s.ReviewService},
+		JobHandler:        &jobHandler{jobService: s.JobService},
+		VocabularyHandler: &vocabularyHandler{vocabularyService: s.VocabularyService},
+		KnownWordHandler:  &knownWordHandler{knownWordService: s.KnownWordService},
+		AnnotationHandler: &annotationHandler{annotationService: s.AnnotationService},
+		DictionaryHandler: &dictionaryHandler{dictionaryService: s.DictionaryService},
+		ScenarioHandler:   &scenarioHandler{scenarioService: s.ScenarioService},
+		ReportHandler:     &reportHandler{reportService: s.ReportService},
+		jwtSecretKey:      jwtSecretKey,
+		Firebase:          firebase,
 	}
 }
 
@@ -48,15 +78,91 @@ func (h *Handlers) SetAPIRoutes(e *echo.Echo) {
 	api.OPTIONS("/auth", handleOptions)
 	api.POST("/auth", h.GetGoogleLoginSignin)
 
+	api.GET("/users/me", h.GetCurrentUser)
+	api.PUT("/users/me", h.UpdateCurrentUser)
+	api.GET("/languages", h.GetLanguages)
+
 	materialRoutes := api.Group("/materials")
 	materialRoutes.POST("", h.CreateMaterial)
 	materialRoutes.GET("", h.GetAllMaterials)
+	materialRoutes.POST("/import/kindle", h.ImportKindleClippings)
 	materialRoutes.GET("/:id", h.GetMaterialByID)
 	materialRoutes.PUT("/:id", h.UpdateMaterial)
 	materialRoutes.DELETE("/:id", h.DeleteMaterial)
 	materialRoutes.GET("/:id/status", h.CheckMaterialStatus)
+	materialRoutes.GET("/:id/difficulty", h.GetMaterialDifficulty)
+	materialRoutes.GET("/:id/annotations", h.GetAnnotations)
 	materialRoutes.GET("/:id/phrases", h.GetProcessedPhrases)
+	materialRoutes.POST("/:id/phrases", h.CreatePhrase)
+	materialRoutes.POST("/:id/phrases/generate", h.GeneratePhrases)
+	materialRoutes.PUT("/:id/phrases/order", h.ReorderPhrases)
+	materialRoutes.PUT("/:id/phrases/:phraseId", h.UpdatePhrase)
+	materialRoutes.DELETE("/:id/phrases/:phraseId", h.DeletePhrase)
+	materialRoutes.GET("/:id/words", h.GetWords)
+	materialRoutes.POST("/:id/words", h.CreateWord)
+	materialRoutes.PUT("/:id/words/order", h.ReorderWords)
+	materialRoutes.PUT("/:id/words/:wordId", h.UpdateWord)
+	materialRoutes.DELETE("/:id/words/:wordId", h.DeleteWord)
 	materialRoutes.GET("/:id/chats", h.GetChatByMaterialID)
+	materialRoutes.GET("/:id/related", h.GetRelatedMaterials)
+	materialRoutes.GET("/:id/revisions", h.GetRevisions)
+	materialRoutes.GET("/:id/revisions/:number", h.GetRevision)
+	materialRoutes.POST("/:id/revisions/:number/restore", h.RestoreRevision)
+	materialRoutes.POST("/:id/adapt", h.AdaptMaterial)
+	materialRoutes.PUT("/:id/share", h.ShareMaterial)
+	materialRoutes.POST("/:id/fork", h.ForkMaterial)
+
+	api.GET("/library", h.GetPublicMaterials)
+	api.GET("/shared/:token", h.GetSharedMaterial)
+	api.POST("/shared/:token/fork", h.ForkSharedMaterial)
+
+	api.GET("/search", h.Search)
+	api.GET("/search/semantic", h.SemanticSearch)
+
+	api.GET("/review/queue", h.GetReviewQueue)
+	api.POST("/review/results", h.RecordReviewResults)
+	api.GET("/jobs/:id", h.GetJob)
+
+	api.GET("/vocabulary", h.GetVocabulary)
+	api.PUT("/vocabulary/:id", h.UpdateVocabularyEntry)
+
+	api.GET("/known-words", h.GetKnownWords)
+	api.POST("/known-words", h.AddKnownWords)
+	api.DELETE("/known-words/:word", h.RemoveKnownWord)
+
+	api.GET("/dictionary/:word", h.LookupWord)
+
+	trashRoutes := api.Group("/trash")
+	trashRoutes.GET("", h.GetTrash)
+	trashRoutes.POST("/:id/restore", h.RestoreMaterial)
+	trashRoutes.DELETE("/:id", h.PurgeMaterial)
+
+	tagRoutes := api.Group("/tags")
+	tagRoutes.GET("", h.GetTags)
+	tagRoutes.POST("", h.CreateTag)
+	tagRoutes.POST("/assign", h.AssignTags)
+	tagRoutes.POST("/unassign", h.UnassignTags)
+	tagRoutes.PUT("/:id", h.UpdateTag)
+	tagRoutes.DELETE("/:id", h.DeleteTag)
+
+	collectionRoutes := api.Group("/collections")
+	collectionRoutes.GET("", h.GetCollections)
+	collectionRoutes.POST("", h.CreateCollection)
+	collectionRoutes.PUT("/:id", h.UpdateCollection)
+	collectionRoutes.DELETE("/:id", h.DeleteCollection)
+	collectionRoutes.POST("/:id/materials", h.AddMaterialsToCollection)
+	collectionRoutes.DELETE("/:id/materials/:materialId", h.RemoveMaterialFromCollection)
+
+	api.GET("/reports", h.GetReports)
+	api.GET("/reports/trends", h.GetReportTrends)
+	api.GET("/reports/:id", h.GetReport)
+
+	scenarioRoutes := api.Group("/scenarios")
+	scenarioRoutes.GET("", h.GetScenarios)
+	scenarioRoutes.POST("", h.CreateScenario)
+	scenarioRoutes.GET("/:id", h.GetScenario)
+	scenarioRoutes.PUT("/:id", h.UpdateScenario)
+	scenarioRoutes.DELETE("/:id", h.DeleteScenario)
 
 	chatRoutes := api.Group("/chat")
 	chatRoutes.POST("", h.CreateChat)
@@ -64,6 +170,13 @@ func (h *Handlers) SetAPIRoutes(e *echo.Echo) {
 	chatRoutes.POST("/:chatId/chat", h.ChatWithGemini)
 	chatRoutes.POST("/:chatId/message", h.CreateMessage)
 	chatRoutes.GET("/:chatId/messages", h.GetMessages)
+	chatRoutes.PUT("/:chatId/messages/:id", h.EditMessage)
+	chatRoutes.POST("/:chatId/messages/:id/regenerate", h.RegenerateMessage)
+	chatRoutes.POST("/:chatId/messages/:id/activate", h.ActivateBranch)
+	chatRoutes.POST("/:chatId/messages/:id/translate", h.TranslateMessage)
+	chatRoutes.GET("/:chatId/usage", h.GetUsage)
+	chatRoutes.GET("/:chatId/suggestions", h.GetSuggestions)
+	chatRoutes.POST("/:chatId/report", h.CreateReport)
 }
 
 func handleOptions(c echo.Context) error {
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
 	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
 )
 
 func respondWithError(c echo.Context, code int, message string) error {
@@ -60,3 +62,44 @@ func parseUintParam(c echo.Context, paramName string) (uint, error) {
 	}
 	return uint(value), err
 }
+
+func parsePageQuery(c echo.Context) (stores.PageQuery, error) {
+	page := stores.PageQuery{Cursor: c.QueryParam("cursor")}
+	if limit := c.QueryParam("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 0 {
+			logger.Errorf("Error parsing limit: %v", limit)
+			return page, errors.New(ErrInvalidPageParams)
+		}
+		page.Limit = value
+	}
+	return page, nil
+}
+
+// parseDateQuery accepts either RFC3339 timestamps or plain dates (2006-01-02)
+func parseDateQuery(c echo.Context, paramName string) (*time.Time, error) {
+	param := c.QueryParam(paramName)
+	if param == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, param); err == nil {
+			return &t, nil
+		}
+	}
+	logger.Errorf("Error parsing date param %s: %v", paramName, param)
+	return nil, fmt.Errorf("invalid %s", paramName)
+}
+
+func parseOptionalUintQuery(c echo.Context, paramName string) (uint, bool, error) {
+	param := c.QueryParam(paramName)
+	if param == "" {
+		return 0, false, nil
+	}
+	value, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		logger.Errorf("Error parsing uint query param %s: %v", paramName, err)
+		return 0, false, fmt.Errorf("invalid %s", paramName)
+	}
+	return uint(value), true, nil
+}
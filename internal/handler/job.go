@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+)
+
+type JobHandler interface {
+	GetJob(c echo.Context) error
+}
+
+type jobHandler struct {
+	jobService services.JobService
+}
+
+func NewJobHandler(js services.JobService) JobHandler {
+	return &jobHandler{jobService: js}
+}
+
+// GET /api/jobs/:id
+func (h *jobHandler) GetJob(c echo.Context) error {
+	jobID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	job, err := h.jobService.GetJob(jobID, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrJobNotFound)
+		}
+		logger.Errorf("Failed to retrieve job: %v, JobID: %v, UserUID: %v", err, jobID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveJob)
+	}
+	return c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type KnownWordHandler interface {
+	GetKnownWords(c echo.Context) error
+	AddKnownWords(c echo.Context) error
+	RemoveKnownWord(c echo.Context) error
+}
+
+type knownWordHandler struct {
+	knownWordService services.KnownWordService
+}
+
+func NewKnownWordHandler(ks services.KnownWordService) KnownWordHandler {
+	return &knownWordHandler{knownWordService: ks}
+}
+
+// GET /api/known-words?language=&cursor=&limit=
+// language defaults to the one the user is learning
+func (h *knownWordHandler) GetKnownWords(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	words, err := h.knownWordService.ListKnownWords(UserUID, c.QueryParam("language"), page)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, services.ErrUnsupportedTargetLanguage) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidLanguage)
+		}
+		logger.Errorf("Failed to retrieve known words: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveKnownWords)
+	}
+	return c.JSON(http.StatusOK, words)
+}
+
+// POST /api/known-words marks words of a language as known; they are stored by lemma
+func (h *knownWordHandler) AddKnownWords(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req struct {
+		Language string   `json:"language"`
+		Words    []string `json:"words"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidKnownWordData)
+	}
+
+	if err := h.knownWordService.MarkKnown(UserUID, req.Language, req.Words, models.KnownSourceManual); err != nil {
+		if errors.Is(err, services.ErrNoWords) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidKnownWordData)
+		}
+		if errors.Is(err, services.ErrUnsupportedTargetLanguage) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidLanguage)
+		}
+		logger.Errorf("Failed to add known words: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateKnownWords)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DELETE /api/known-words/:word?language=
+func (h *knownWordHandler) RemoveKnownWord(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	if err := h.knownWordService.MarkUnknown(UserUID, c.QueryParam("language"), []string{c.Param("word")}); err != nil {
+		if errors.Is(err, services.ErrNoWords) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidKnownWordData)
+		}
+		if errors.Is(err, services.ErrUnsupportedTargetLanguage) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidLanguage)
+		}
+		logger.Errorf("Failed to remove known word: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateKnownWords)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -2,15 +2,25 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/levels"
 	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
 	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
 )
 
+// maxClippingsSize bounds a clippings upload; years of highlights fit in a few
+// megabytes
+const maxClippingsSize = 10 << 20
+
 type MaterialHandler interface {
 	CreateMaterial(c echo.Context) error
 	GetMaterialByID(c echo.Context) error
@@ -18,17 +28,30 @@ type MaterialHandler interface {
 	DeleteMaterial(c echo.Context) error
 	GetAllMaterials(c echo.Context) error
 	CheckMaterialStatus(c echo.Context) error
+	GetMaterialDifficulty(c echo.Context) error
+	AdaptMaterial(c echo.Context) error
+	ImportKindleClippings(c echo.Context) error
+	GetRevisions(c echo.Context) error
+	GetRevision(c echo.Context) error
+	RestoreRevision(c echo.Context) error
 }
 
 type materialHandler struct {
 	services.MaterialService
 	services.PhraseService
+	importService     services.ImportService
+	embeddingService  services.EmbeddingService
+	collectionService services.CollectionService
+	sharingService    services.SharingService
+	knownWordService  services.KnownWordService
+	jobService        services.JobService
 }
 
-func NewMaterialHandler(materialService services.MaterialService, phraseService services.PhraseService) MaterialHandler {
+func NewMaterialHandler(materialService services.MaterialService, phraseService services.PhraseService, importService services.ImportService) MaterialHandler {
 	return &materialHandler{
 		MaterialService: materialService,
 		PhraseService:   phraseService,
+		importService:   importService,
 	}
 }
 
@@ -45,12 +68,20 @@ func (h *materialHandler) CreateMaterial(c echo.Context) error {
 
 	material.UserUID = UserUID
 	material.Status = "processing"
+	material.Visibility = models.VisibilityPrivate
+	material.ShareToken = nil
+	material.ForkedFromID = nil
+	material.ParentID = nil
+	material.AdaptedLevel = ""
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
 	defer cancel()
 
 	id, err := h.MaterialService.CreateMaterial(&material)
 	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedTargetLanguage) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidLanguage)
+		}
 		logger.Errorf("Error creating material: %v, UserUID: %v", err, UserUID)
 		return respondWithError(c, http.StatusInternalServerError, ErrFailedCreateMaterial)
 	}
@@ -76,15 +107,43 @@ func (h *materialHandler) GetMaterialByID(c echo.Context) error {
 		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
 	}
 
-	material, err := h.MaterialService.GetMaterialByID(id, UserUID)
+	// public materials of other users are readable too
+	material, err := h.sharingService.GetReadableMaterial(id, UserUID)
 	if err != nil {
 		return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
 	}
+	if err := h.knownWordService.AttachCoverage(material, UserUID); err != nil {
+		logger.Errorf("Failed to compute coverage: %v, MaterialID: %v, UserUID: %v", err, id, UserUID)
+	}
 
 	logger.Infof("Retrieved material MaterialID;%v", id)
 	return c.JSON(http.StatusOK, material)
 }
 
+// GET /api/materials/:id/difficulty estimates the CEFR level of the vocabulary
+// of an English material
+func (h *materialHandler) GetMaterialDifficulty(c echo.Context) error {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	material, err := h.sharingService.GetReadableMaterial(id, UserUID)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+	}
+	estimate, err := h.MaterialService.EstimateLevel(material)
+	if err != nil {
+		return respondWithError(c, http.StatusUnprocessableEntity, err.Error())
+	}
+	return c.JSON(http.StatusOK, estimate)
+}
+
 func (h *materialHandler) UpdateMaterial(c echo.Context) error {
 	UserUID, err := getUserUIDFromContext(c)
 	if err != nil {
@@ -101,19 +160,46 @@ func (h *materialHandler) UpdateMaterial(c echo.Context) error {
 		return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
 	}
 
+	previousContent := material.Content
+	// sharing is changed through PUT /materials/:id/share only, and lineage never
+	visibility, shareToken, forkedFromID := material.Visibility, material.ShareToken, material.ForkedFromID
+	parentID, adaptedLevel, status := material.ParentID, material.AdaptedLevel, material.Status
 	if err := bindAndValidateMaterial(c, material); err != nil {
 		return respondWithError(c, http.StatusBadRequest, err.Error())
 	}
+	material.Visibility, material.ShareToken, material.ForkedFromID = visibility, shareToken, forkedFromID
+	material.ParentID, material.AdaptedLevel = parentID, adaptedLevel
 
 	if material.UserUID != UserUID {
 		return respondWithError(c, http.StatusForbidden, ErrForbiddenModify)
 	}
 
+	// phrases generated from the old content are stale now, so they are
+	// generated again, which waits for any other work on them to finish
+	reprocess := material.Content != previousContent
+	if reprocess {
+		if err := h.MaterialService.ClaimProcessing(materialID, UserUID); err != nil {
+			return respondWithClaimError(c, err, materialID, UserUID)
+		}
+	}
+
 	if err := h.MaterialService.UpdateMaterial(materialID, material); err != nil {
+		if reprocess {
+			h.MaterialService.UpdateMaterialStatus(materialID, status)
+		}
+		if errors.Is(err, services.ErrUnsupportedTargetLanguage) {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidLanguage)
+		}
 		logger.Errorf("Failed to update material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
 		return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateMaterial)
 	}
 
+	material.Status = status
+	if reprocess {
+		material.Status = models.StatusProcessing
+		go h.processMaterialAsync(context.Background(), materialID, UserUID)
+	}
+
 	logger.Infof("Updated material, MaterialID: %v, UserUID: %v", materialID, UserUID)
 	return c.JSON(http.StatusOK, material)
 }
@@ -130,6 +216,9 @@ func (h *materialHandler) DeleteMaterial(c echo.Context) error {
 	}
 
 	if err := h.MaterialService.DeleteMaterial(materialID, UserUID); err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
 		logger.Errorf("Failed to delete material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
 		return respondWithError(c, http.StatusInternalServerError, ErrFailedDeleteMaterial)
 	}
@@ -138,24 +227,102 @@ func (h *materialHandler) DeleteMaterial(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// GetAllMaterials lists materials with cursor pagination.
+// Query params: search, status, tag_id, collection_id, created_from, created_to,
+// language, min_level, max_level (CEFR), max_grade,
+// sort (created|updated|title|coverage|level|readability|length), order (asc|desc), cursor, limit
 func (h *materialHandler) GetAllMaterials(c echo.Context) error {
-	searchQuery := c.QueryParam("search")
-
 	UserUID, err := getUserUIDFromContext(c)
 	if err != nil {
 		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
 	}
 
-	materials, err := h.MaterialService.GetAllMaterials(searchQuery, UserUID)
+	query, err := parseMaterialQuery(c)
 	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	if collectionID, ok, err := parseOptionalUintQuery(c, "collection_id"); err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	} else if ok {
+		// a collection also lists the materials of its sub-collections
+		if query.CollectionIDs, err = h.collectionService.DescendantIDs(collectionID, UserUID); err != nil {
+			if errors.Is(err, services.ErrCollectionNotFound) {
+				return respondWithError(c, http.StatusNotFound, ErrCollectionNotFound)
+			}
+			logger.Errorf("Failed to resolve collection: %v, CollectionID: %v, UserUID: %v", err, collectionID, UserUID)
+			return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveMaterials)
+		}
+	}
+
+	materials, err := h.MaterialService.GetAllMaterials(query, UserUID)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
 		logger.Errorf("Failed to retrieve materials: %v, UserUID: %v", err, UserUID)
 		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveMaterials)
 	}
 
-	logger.Infof("Retrieved materials, MaterialCount: %v, UserUID: %v", len(materials), UserUID)
+	logger.Infof("Retrieved materials, MaterialCount: %v, Total: %v, UserUID: %v", len(materials.Items), materials.Total, UserUID)
 	return c.JSON(http.StatusOK, materials)
 }
 
+func parseMaterialQuery(c echo.Context) (stores.MaterialQuery, error) {
+	var query stores.MaterialQuery
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return query, err
+	}
+	query.PageQuery = page
+	query.Search = c.QueryParam("search")
+	query.Status = c.QueryParam("status")
+
+	switch sort := c.QueryParam("sort"); sort {
+	case "", stores.SortCreated, stores.SortUpdated, stores.SortTitle, stores.SortCoverage,
+		stores.SortLevel, stores.SortReadability, stores.SortLength:
+		query.Sort = sort
+	default:
+		return query, errors.New(ErrInvalidSort)
+	}
+	switch order := c.QueryParam("order"); order {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return query, errors.New(ErrInvalidSort)
+	}
+
+	if tagID, ok, err := parseOptionalUintQuery(c, "tag_id"); err != nil {
+		return query, err
+	} else if ok {
+		query.TagID = tagID
+	}
+	if query.Language = c.QueryParam("language"); query.Language != "" {
+		if _, ok := language.Get(query.Language); !ok {
+			return query, errors.New(ErrInvalidLanguage)
+		}
+	}
+	query.MinLevel = strings.ToUpper(c.QueryParam("min_level"))
+	query.MaxLevel = strings.ToUpper(c.QueryParam("max_level"))
+	if (query.MinLevel != "" && !levels.IsLevel(query.MinLevel)) || (query.MaxLevel != "" && !levels.IsLevel(query.MaxLevel)) {
+		return query, errors.New(ErrInvalidLevelFilter)
+	}
+	if grade := c.QueryParam("max_grade"); grade != "" {
+		maxGrade, err := strconv.ParseFloat(grade, 64)
+		if err != nil {
+			return query, errors.New(ErrInvalidGradeFilter)
+		}
+		query.MaxGrade = &maxGrade
+	}
+	if query.CreatedAfter, err = parseDateQuery(c, "created_from"); err != nil {
+		return query, err
+	}
+	if query.CreatedBefore, err = parseDateQuery(c, "created_to"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
 func (h *materialHandler) CheckMaterialStatus(c echo.Context) error {
 	materialID, err := parseUintParam(c, "id")
 	if err != nil {
@@ -172,9 +339,71 @@ func (h *materialHandler) CheckMaterialStatus(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"status": status})
 }
 
-func (h *materialHandler) processMaterialAsync(ctx context.Context, materialID uint, userUID string) {
-	h.MaterialService.UpdateMaterialStatus(materialID, "processing")
+// ImportKindleClippings imports a Kindle "My Clippings.txt" upload, one material per book.
+// Set the "as_phrases" form value to store highlights as phrases instead of generating them.
+func (h *materialHandler) ImportKindleClippings(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxClippingsSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return respondWithError(c, http.StatusRequestEntityTooLarge, ErrClippingsFileTooLarge)
+		}
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidClippingsFile)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		logger.Errorf("Error opening clippings file: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidClippingsFile)
+	}
+	defer file.Close()
+
+	asPhrases, _ := strconv.ParseBool(c.FormValue("as_phrases"))
+
+	result, err := h.importService.ImportKindleClippings(file, UserUID, asPhrases)
+	if err != nil {
+		if errors.Is(err, services.ErrNoHighlights) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, services.ErrMaterialBusy) {
+			return respondWithError(c, http.StatusConflict, err.Error())
+		}
+		logger.Errorf("Failed to import clippings: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedImportClippings)
+	}
 
+	for _, book := range result.Books {
+		if book.NeedsProcessing {
+			go h.processMaterialAsync(context.Background(), book.MaterialID, UserUID)
+		} else if book.Added > 0 {
+			go h.embedMaterialAsync(book.MaterialID, UserUID)
+		}
+	}
+
+	logger.Infof("Imported clippings, BookCount: %v, UserUID: %v", len(result.Books), UserUID)
+	return c.JSON(http.StatusCreated, result)
+}
+
+// respondWithClaimError answers a failed ClaimProcessing
+func respondWithClaimError(c echo.Context, err error, materialID uint, UserUID string) error {
+	switch {
+	case errors.Is(err, services.ErrMaterialNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+	case errors.Is(err, services.ErrMaterialBusy):
+		return respondWithError(c, http.StatusConflict, err.Error())
+	}
+	logger.Errorf("Failed to claim material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateMaterial)
+}
+
+// processMaterialAsync generates the phrases of a material the caller has
+// marked as processing, by creating it so or through ClaimProcessing
+func (h *materialHandler) processMaterialAsync(ctx context.Context, materialID uint, userUID string) {
 	phrases, err := h.PhraseService.GeneratePhrases(ctx, materialID, userUID)
 	if err != nil {
 		logger.Errorf("Failed to generate phrases: %v, MaterialID: %v, UserUID: %v", err, materialID, userUID)
@@ -182,7 +411,7 @@ func (h *materialHandler) processMaterialAsync(ctx context.Context, materialID u
 		return
 	}
 
-	if err = h.PhraseService.StorePhrases(materialID, phrases); err != nil {
+	if err = h.PhraseService.SyncPhrases(materialID, userUID, phrases); err != nil {
 		logger.Errorf("Failed to store phrases: %v, MaterialID: %v, UserUID: %v", err, materialID, userUID)
 		h.MaterialService.UpdateMaterialStatus(materialID, "failed")
 		return
@@ -190,4 +419,23 @@ func (h *materialHandler) processMaterialAsync(ctx context.Context, materialID u
 
 	logger.Infof("Phrases generated and stored successfully, MaterialID: %v, UserUID: %v", materialID, userUID)
 	h.MaterialService.UpdateMaterialStatus(materialID, "completed")
+
+	h.embedMaterialAsync(materialID, userUID)
+}
+
+// embedMaterialAsync refreshes the vectors used for related materials and semantic search.
+// Failures are only logged: the material is usable without embeddings.
+func (h *materialHandler) embedMaterialAsync(materialID uint, userUID string) {
+	embedMaterial(h.embeddingService, materialID, userUID)
+}
+
+func embedMaterial(embeddingService services.EmbeddingService, materialID uint, userUID string) {
+	if embeddingService == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), embeddingTimeout)
+	defer cancel()
+	if err := embeddingService.EmbedMaterial(ctx, materialID, userUID); err != nil {
+		logger.Errorf("Failed to embed material: %v, MaterialID: %v, UserUID: %v", err, materialID, userUID)
+	}
 }
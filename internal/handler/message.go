@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
 	"github.com/yomek33/talki/internal/services"
 )
@@ -11,10 +13,17 @@ import (
 type MessageHandler interface {
 	CreateMessage(c echo.Context) error
 	GetMessages(c echo.Context) error
+	EditMessage(c echo.Context) error
+	RegenerateMessage(c echo.Context) error
+	ActivateBranch(c echo.Context) error
+	TranslateMessage(c echo.Context) error
+	GetUsage(c echo.Context) error
 }
 
 type messageHandler struct {
-	messageService services.MessageService
+	messageService     services.MessageService
+	translationService services.TranslationService
+	usageService       services.UsageService
 }
 
 func NewMessageHandler(ms services.MessageService) MessageHandler {
@@ -30,12 +39,21 @@ func (h *messageHandler) CreateMessage(c echo.Context) error {
 		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
 	}
 
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
 	var message models.Message
 	if err := c.Bind(&message); err != nil {
 		return respondWithError(c, http.StatusBadRequest, "Invalid message data")
 	}
+	message.UserUID = UserUID
 
 	createdMessage, err := h.messageService.CreateMessage(chatID, &message)
+	if errors.Is(err, services.ErrChatNotFound) {
+		return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+	}
 	if err != nil {
 		return respondWithError(c, http.StatusInternalServerError, "Failed to create message")
 	}
@@ -43,17 +61,176 @@ func (h *messageHandler) CreateMessage(c echo.Context) error {
 	return c.JSON(http.StatusCreated, createdMessage)
 }
 
-// GET /chats/:chatId/messages
+// GET /chats/:chatId/messages returns the active branch of the chat
 func (h *messageHandler) GetMessages(c echo.Context) error {
 	chatID, err := parseUintParam(c, "chatId")
 	if err != nil {
 		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
 	}
 
-	messages, err := h.messageService.GetMessages(chatID)
+	UserUID, err := getUserUIDFromContext(c)
 	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	messages, err := h.messageService.GetMessages(chatID, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrChatNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+		}
 		return respondWithError(c, http.StatusInternalServerError, "Failed to retrieve messages")
 	}
 
 	return c.JSON(http.StatusOK, messages)
 }
+
+// PUT /api/chat/:chatId/messages/:id edits the learner's last message on a
+// new branch and returns it with the reply to it
+func (h *messageHandler) EditMessage(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMessageID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var request struct {
+		Content string `json:"content"`
+	}
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid message data")
+	}
+
+	edited, err := h.messageService.EditMessage(chatID, id, request.Content, UserUID)
+	if err != nil {
+		return respondWithBranchError(c, err, chatID, UserUID)
+	}
+	return c.JSON(http.StatusOK, edited)
+}
+
+// POST /api/chat/:chatId/messages/:id/regenerate replaces the latest reply
+// with a new one on a new branch
+func (h *messageHandler) RegenerateMessage(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMessageID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	reply, err := h.messageService.RegenerateMessage(chatID, id, UserUID)
+	if err != nil {
+		return respondWithBranchError(c, err, chatID, UserUID)
+	}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// POST /api/chat/:chatId/messages/:id/activate switches the chat to the
+// branch through the message and returns it
+func (h *messageHandler) ActivateBranch(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMessageID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	messages, err := h.messageService.ActivateBranch(chatID, id, UserUID)
+	if err != nil {
+		return respondWithBranchError(c, err, chatID, UserUID)
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+func respondWithBranchError(c echo.Context, err error, chatID uint, UserUID string) error {
+	switch {
+	case errors.Is(err, services.ErrChatNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+	case errors.Is(err, services.ErrMessageNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrMessageNotFound)
+	case errors.Is(err, services.ErrNotLastUserMessage),
+		errors.Is(err, services.ErrNotLastBotMessage),
+		errors.Is(err, services.ErrReplyPending):
+		return respondWithError(c, http.StatusConflict, err.Error())
+	}
+	logger.Errorf("Failed to update conversation: %v, ChatID: %v, UserUID: %v", err, chatID, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateConversation)
+}
+
+// POST /api/chat/:chatId/messages/:id/translate translates a bot message
+// into the user's native language
+func (h *messageHandler) TranslateMessage(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMessageID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	translation, err := h.translationService.TranslateMessage(chatID, id, UserUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrChatNotFound), errors.Is(err, services.ErrMaterialNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+		case errors.Is(err, services.ErrMessageNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrMessageNotFound)
+		case errors.Is(err, services.ErrNotBotMessage):
+			return respondWithError(c, http.StatusBadRequest, ErrNotBotMessage)
+		}
+		logger.Errorf("Failed to translate message: %v, MessageID: %v, UserUID: %v", err, id, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedTranslate)
+	}
+	return c.JSON(http.StatusOK, translation)
+}
+
+// GET /api/chat/:chatId/usage lists the material's phrases and words the
+// learner used in the chat
+func (h *messageHandler) GetUsage(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	events, err := h.usageService.GetUsage(chatID, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrChatNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+		}
+		logger.Errorf("Failed to retrieve usage: %v, ChatID: %v, UserUID: %v", err, chatID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveUsage)
+	}
+	return c.JSON(http.StatusOK, events)
+}
@@ -1,14 +1,14 @@
 package handler
 
 import (
-	"context"
-	"log"
+	"errors"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
 	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
 )
 
 const (
@@ -18,37 +18,65 @@ const (
 type PhraseHandler interface {
 	GeneratePhrases(c echo.Context) error
 	GetProcessedPhrases(c echo.Context) error
+	CreatePhrase(c echo.Context) error
+	UpdatePhrase(c echo.Context) error
+	DeletePhrase(c echo.Context) error
+	ReorderPhrases(c echo.Context) error
+}
+
+type phraseRequest struct {
+	Text       string `json:"text"`
+	Importance string `json:"importance"`
+	Starred    bool   `json:"starred"`
+}
+
+type reorderRequest struct {
+	IDs []uint `json:"ids"`
 }
 
 type phraseHandler struct {
 	services.PhraseService
+	jobService         services.JobService
+	translationService services.TranslationService
 }
 
+// POST /api/materials/:id/phrases/generate with optional count, level, focus and mode.
+// Generation runs in the background; poll GET /api/jobs/:id with the returned job ID.
 func (h *phraseHandler) GeneratePhrases(c echo.Context) error {
-	log.Printf("Generating phrases for material")
-	// Parse materialID and UserUID from the request
-	UserUID, err := getUserUIDFromContext(c)
+	materialID, err := parseUintParam(c, "id")
 	if err != nil {
-		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
 	}
 
-	materialID, err := strconv.Atoi(c.Param("materialID"))
+	UserUID, err := getUserUIDFromContext(c)
 	if err != nil {
-		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	var req services.PhraseGenerationRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidPhraseData)
+	}
 
-	// Call the service to generate phrases
-	phrases, err := h.PhraseService.GeneratePhrases(ctx, uint(materialID), UserUID)
+	job, err := h.jobService.StartPhraseGeneration(materialID, UserUID, req)
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMaterialNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		case errors.Is(err, services.ErrInvalidCount), errors.Is(err, services.ErrInvalidLevel),
+			errors.Is(err, services.ErrInvalidFocus), errors.Is(err, services.ErrInvalidGenerateMode):
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrMaterialBusy):
+			return respondWithError(c, http.StatusConflict, err.Error())
+		}
+		logger.Errorf("Failed to start phrase generation: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
 		return respondWithError(c, http.StatusInternalServerError, ErrFailedGeneratePhrases)
 	}
 
-	// Return the phrases as JSON response
-	return c.JSON(http.StatusOK, phrases)
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
 }
 
 func (h *phraseHandler) GetProcessedPhrases(c echo.Context) error {
@@ -57,10 +85,137 @@ func (h *phraseHandler) GetProcessedPhrases(c echo.Context) error {
 		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
 	}
 
-	phrases, err := h.PhraseService.GetPhrasesByMaterialID(materialID)
+	UserUID, err := getUserUIDFromContext(c)
 	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	phrases, err := h.PhraseService.ListPhrases(materialID, UserUID, page)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
 		return respondWithError(c, http.StatusInternalServerError, err.Error())
 	}
+	if err := h.translationService.AttachPhraseTranslations(phrases.Items, UserUID); err != nil {
+		logger.Errorf("Failed to translate phrases: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+	}
 
 	return c.JSON(http.StatusOK, phrases)
 }
+
+// POST /api/materials/:id/phrases
+func (h *phraseHandler) CreatePhrase(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req phraseRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidPhraseData)
+	}
+	phrase := models.Phrase{Text: req.Text, Importance: req.Importance, Starred: req.Starred}
+
+	if err := h.PhraseService.CreatePhrase(materialID, UserUID, &phrase); err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.JSON(http.StatusCreated, phrase)
+}
+
+// PUT /api/materials/:id/phrases/:phraseId
+func (h *phraseHandler) UpdatePhrase(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+	phraseID, err := parseUintParam(c, "phraseId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	var update services.PhraseUpdate
+	if err := c.Bind(&update); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidPhraseData)
+	}
+
+	phrase, err := h.PhraseService.UpdatePhrase(materialID, phraseID, UserUID, update)
+	if err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.JSON(http.StatusOK, phrase)
+}
+
+// DELETE /api/materials/:id/phrases/:phraseId
+func (h *phraseHandler) DeletePhrase(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+	phraseID, err := parseUintParam(c, "phraseId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	if err := h.PhraseService.DeletePhrase(materialID, phraseID, UserUID); err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PUT /api/materials/:id/phrases/order with {"ids": [...]} listing every phrase in its new order
+func (h *phraseHandler) ReorderPhrases(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req reorderRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidPhraseData)
+	}
+
+	if err := h.PhraseService.ReorderPhrases(materialID, UserUID, req.IDs); err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func respondWithCurationError(c echo.Context, err error, materialID uint, UserUID string) error {
+	switch {
+	case errors.Is(err, services.ErrMaterialNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+	case errors.Is(err, services.ErrPhraseNotFound), errors.Is(err, services.ErrWordNotFound):
+		return respondWithError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrEmptyText), errors.Is(err, services.ErrInvalidImportance),
+		errors.Is(err, stores.ErrInvalidOrder):
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	logger.Errorf("Failed to update material items: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateItems)
+}
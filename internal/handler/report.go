@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type ReportHandler interface {
+	CreateReport(c echo.Context) error
+	GetReports(c echo.Context) error
+	GetReport(c echo.Context) error
+	GetReportTrends(c echo.Context) error
+}
+
+type reportHandler struct {
+	reportService services.ReportService
+}
+
+func NewReportHandler(rs services.ReportService) ReportHandler {
+	return &reportHandler{reportService: rs}
+}
+
+// POST /api/chat/:chatId/report scores the learner's part in a chat
+func (h *reportHandler) CreateReport(c echo.Context) error {
+	chatID, err := parseUintParam(c, "chatId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidChatID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	report, err := h.reportService.CreateReport(chatID, UserUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrChatNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrChatNotFound)
+		case errors.Is(err, services.ErrEmptyTranscript):
+			return respondWithError(c, http.StatusUnprocessableEntity, err.Error())
+		}
+		logger.Errorf("Failed to create report: %v, ChatID: %v, UserUID: %v", err, chatID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedCreateReport)
+	}
+
+	logger.Infof("Created report, ReportID: %v, ChatID: %v, UserUID: %v", report.ID, chatID, UserUID)
+	return c.JSON(http.StatusCreated, report)
+}
+
+// GET /api/reports?chat_id=&cursor=&limit= lists the user's reports, newest last
+func (h *reportHandler) GetReports(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	chatID, _, err := parseOptionalUintQuery(c, "chat_id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	reports, err := h.reportService.ListReports(UserUID, chatID, page)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to retrieve reports: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveReport)
+	}
+	return c.JSON(http.StatusOK, reports)
+}
+
+// GET /api/reports/:id with its mistakes and suggestions
+func (h *reportHandler) GetReport(c echo.Context) error {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidReportID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	report, err := h.reportService.GetReport(id, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrReportNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrReportNotFound)
+		}
+		logger.Errorf("Failed to retrieve report: %v, ReportID: %v, UserUID: %v", err, id, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveReport)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GET /api/reports/trends?interval=day|week|month&since= averages the scores
+// of the user's reports per period
+func (h *reportHandler) GetReportTrends(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	since, err := parseDateQuery(c, "since")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	trends, err := h.reportService.GetTrends(UserUID, c.QueryParam("interval"), since)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInterval) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to retrieve report trends: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveReport)
+	}
+	return c.JSON(http.StatusOK, trends)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+)
+
+type ReviewHandler interface {
+	GetReviewQueue(c echo.Context) error
+	RecordReviewResults(c echo.Context) error
+}
+
+type reviewHandler struct {
+	reviewService services.ReviewService
+}
+
+func NewReviewHandler(rs services.ReviewService) ReviewHandler {
+	return &reviewHandler{reviewService: rs}
+}
+
+// GET /api/review/queue?limit=
+func (h *reviewHandler) GetReviewQueue(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	limit, err := parseLimitParam(c, defaultPageLimit)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	queue, err := h.reviewService.GetQueue(UserUID, limit)
+	if err != nil {
+		logger.Errorf("Failed to retrieve review queue: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveReview)
+	}
+	return c.JSON(http.StatusOK, queue)
+}
+
+// POST /api/review/results feeds reviewed words into the known-word set
+func (h *reviewHandler) RecordReviewResults(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req struct {
+		Results []services.ReviewResult `json:"results"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidReviewData)
+	}
+
+	if err := h.reviewService.RecordResults(UserUID, req.Results); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoWords):
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidReviewData)
+		case errors.Is(err, services.ErrWordNotFound):
+			return respondWithError(c, http.StatusNotFound, err.Error())
+		}
+		logger.Errorf("Failed to record review results: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRecordReview)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+// GET /api/materials/:id/revisions?cursor=&limit=
+func (h *materialHandler) GetRevisions(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	revisions, err := h.MaterialService.ListRevisions(materialID, UserUID, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMaterialNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		case errors.Is(err, stores.ErrInvalidCursor):
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to retrieve revisions: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveRevisions)
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
+
+// GET /api/materials/:id/revisions/:number
+func (h *materialHandler) GetRevision(c echo.Context) error {
+	materialID, number, err := parseRevisionParams(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	revision, err := h.MaterialService.GetRevision(materialID, number, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrRevisionNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrRevisionNotFound)
+		}
+		logger.Errorf("Failed to retrieve revision: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveRevisions)
+	}
+	return c.JSON(http.StatusOK, revision)
+}
+
+// POST /api/materials/:id/revisions/:number/restore
+func (h *materialHandler) RestoreRevision(c echo.Context) error {
+	materialID, number, err := parseRevisionParams(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	current, err := h.MaterialService.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+	}
+	revision, err := h.MaterialService.GetRevision(materialID, number, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrRevisionNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrRevisionNotFound)
+		}
+		logger.Errorf("Failed to restore revision: %v, MaterialID: %v, Revision: %v, UserUID: %v", err, materialID, number, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRestoreRevision)
+	}
+	// restored content needs its phrases generated again, like an edit
+	reprocess := revision.Content != current.Content
+	if reprocess {
+		if err := h.MaterialService.ClaimProcessing(materialID, UserUID); err != nil {
+			return respondWithClaimError(c, err, materialID, UserUID)
+		}
+	}
+
+	material, err := h.MaterialService.RestoreRevision(materialID, number, UserUID)
+	if err != nil {
+		if reprocess {
+			h.MaterialService.UpdateMaterialStatus(materialID, current.Status)
+		}
+		switch {
+		case errors.Is(err, services.ErrMaterialNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		case errors.Is(err, services.ErrRevisionNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrRevisionNotFound)
+		}
+		logger.Errorf("Failed to restore revision: %v, MaterialID: %v, Revision: %v, UserUID: %v", err, materialID, number, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRestoreRevision)
+	}
+
+	if reprocess {
+		material.Status = models.StatusProcessing
+		go h.processMaterialAsync(context.Background(), materialID, UserUID)
+	}
+
+	logger.Infof("Restored revision, MaterialID: %v, Revision: %v, UserUID: %v", materialID, number, UserUID)
+	return c.JSON(http.StatusOK, material)
+}
+
+func parseRevisionParams(c echo.Context) (uint, int, error) {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return 0, 0, errors.New(ErrInvalidMaterialID)
+	}
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		return 0, 0, errors.New(ErrInvalidRevisionNumber)
+	}
+	return materialID, number, nil
+}
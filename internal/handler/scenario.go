@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+)
+
+type ScenarioHandler interface {
+	GetScenarios(c echo.Context) error
+	GetScenario(c echo.Context) error
+	CreateScenario(c echo.Context) error
+	UpdateScenario(c echo.Context) error
+	DeleteScenario(c echo.Context) error
+}
+
+type scenarioHandler struct {
+	scenarioService services.ScenarioService
+}
+
+func NewScenarioHandler(ss services.ScenarioService) ScenarioHandler {
+	return &scenarioHandler{scenarioService: ss}
+}
+
+// GET /api/scenarios lists the built-in scenarios and the user's own
+func (h *scenarioHandler) GetScenarios(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	scenarios, err := h.scenarioService.ListScenarios(UserUID)
+	if err != nil {
+		logger.Errorf("Failed to retrieve scenarios: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveScenarios)
+	}
+	return c.JSON(http.StatusOK, scenarios)
+}
+
+// GET /api/scenarios/:id
+func (h *scenarioHandler) GetScenario(c echo.Context) error {
+	scenarioID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	scenario, err := h.scenarioService.GetScenario(scenarioID, UserUID)
+	if err != nil {
+		return respondWithScenarioError(c, err, UserUID)
+	}
+	return c.JSON(http.StatusOK, scenario)
+}
+
+// POST /api/scenarios
+func (h *scenarioHandler) CreateScenario(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var scenario models.Scenario
+	if err := c.Bind(&scenario); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidScenarioData)
+	}
+	scenario.ID = 0
+	scenario.UserUID = UserUID
+
+	if err := h.scenarioService.CreateScenario(&scenario); err != nil {
+		return respondWithScenarioError(c, err, UserUID)
+	}
+
+	logger.Infof("Created scenario, ScenarioID: %v, UserUID: %v", scenario.ID, UserUID)
+	return c.JSON(http.StatusCreated, scenario)
+}
+
+// PUT /api/scenarios/:id
+func (h *scenarioHandler) UpdateScenario(c echo.Context) error {
+	scenarioID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var scenario models.Scenario
+	if err := c.Bind(&scenario); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidScenarioData)
+	}
+	scenario.ID = scenarioID
+	scenario.UserUID = UserUID
+
+	if err := h.scenarioService.UpdateScenario(&scenario); err != nil {
+		return respondWithScenarioError(c, err, UserUID)
+	}
+
+	logger.Infof("Updated scenario, ScenarioID: %v, UserUID: %v", scenarioID, UserUID)
+	return c.JSON(http.StatusOK, scenario)
+}
+
+// DELETE /api/scenarios/:id; chats already following the scenario keep it
+func (h *scenarioHandler) DeleteScenario(c echo.Context) error {
+	scenarioID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	if err := h.scenarioService.DeleteScenario(scenarioID, UserUID); err != nil {
+		return respondWithScenarioError(c, err, UserUID)
+	}
+
+	logger.Infof("Deleted scenario, ScenarioID: %v, UserUID: %v", scenarioID, UserUID)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func respondWithScenarioError(c echo.Context, err error, UserUID string) error {
+	switch {
+	case errors.Is(err, services.ErrScenarioNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrScenarioNotFound)
+	case errors.Is(err, services.ErrScenarioTitleRequired),
+		errors.Is(err, services.ErrScenarioPersonaRequired):
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	logger.Errorf("Scenario operation failed: %v, UserUID: %v", err, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateScenario)
+}
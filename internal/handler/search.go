@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/search"
+	"github.com/yomek33/talki/internal/services"
+)
+
+type SearchHandler interface {
+	Search(c echo.Context) error
+}
+
+type searchHandler struct {
+	searchService services.SearchService
+}
+
+func NewSearchHandler(ss services.SearchService) SearchHandler {
+	return &searchHandler{searchService: ss}
+}
+
+// GET /api/search?q=&types=material,phrase,word,message&limit=
+func (h *searchHandler) Search(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	query := search.Query{Text: strings.TrimSpace(c.QueryParam("q"))}
+	if query.Text == "" {
+		return respondWithError(c, http.StatusBadRequest, ErrEmptySearchQuery)
+	}
+	if types := c.QueryParam("types"); types != "" {
+		for _, kind := range strings.Split(types, ",") {
+			kind = strings.TrimSpace(kind)
+			if !isSearchKind(kind) {
+				return respondWithError(c, http.StatusBadRequest, ErrInvalidSearchType)
+			}
+			query.Kinds = append(query.Kinds, kind)
+		}
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidPageParams)
+		}
+	}
+
+	results, err := h.searchService.Search(UserUID, query)
+	if err != nil {
+		logger.Errorf("Failed to search: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedSearch)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"query": query.Text, "results": results})
+}
+
+func isSearchKind(kind string) bool {
+	for _, k := range search.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type SharingHandler interface {
+	ShareMaterial(c echo.Context) error
+	GetSharedMaterial(c echo.Context) error
+	GetPublicMaterials(c echo.Context) error
+	ForkMaterial(c echo.Context) error
+	ForkSharedMaterial(c echo.Context) error
+}
+
+type sharingHandler struct {
+	sharingService   services.SharingService
+	embeddingService services.EmbeddingService
+}
+
+type shareRequest struct {
+	Visibility string `json:"visibility"`
+	// RotateToken replaces the current share token, invalidating old links
+	RotateToken bool `json:"rotate_token"`
+}
+
+func NewSharingHandler(ss services.SharingService, es services.EmbeddingService) SharingHandler {
+	return &sharingHandler{sharingService: ss, embeddingService: es}
+}
+
+// PUT /api/materials/:id/share
+func (h *sharingHandler) ShareMaterial(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req shareRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidShareData)
+	}
+
+	material, err := h.sharingService.ShareMaterial(materialID, UserUID, req.Visibility, req.RotateToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVisibility):
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrMaterialNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to share material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedShareMaterial)
+	}
+	return c.JSON(http.StatusOK, material)
+}
+
+// GET /api/shared/:token returns a link-shared material with its phrases and words
+func (h *sharingHandler) GetSharedMaterial(c echo.Context) error {
+	material, err := h.sharingService.GetSharedMaterial(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to retrieve shared material: %v", err)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveMaterials)
+	}
+	return c.JSON(http.StatusOK, material)
+}
+
+// GET /api/library lists public materials. It accepts the same search, status,
+// date, sort and page parameters as GET /api/materials.
+func (h *sharingHandler) GetPublicMaterials(c echo.Context) error {
+	query, err := parseMaterialQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	materials, err := h.sharingService.GetPublicMaterials(query)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to retrieve public materials: %v", err)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveMaterials)
+	}
+	return c.JSON(http.StatusOK, materials)
+}
+
+// POST /api/materials/:id/fork copies a public material into the user's library
+func (h *sharingHandler) ForkMaterial(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	fork, err := h.sharingService.ForkMaterial(materialID, UserUID)
+	return h.respondWithFork(c, fork, err, UserUID)
+}
+
+// POST /api/shared/:token/fork copies a link-shared material into the user's library
+func (h *sharingHandler) ForkSharedMaterial(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	fork, err := h.sharingService.ForkSharedMaterial(c.Param("token"), UserUID)
+	return h.respondWithFork(c, fork, err, UserUID)
+}
+
+func (h *sharingHandler) respondWithFork(c echo.Context, fork *models.Material, err error, UserUID string) error {
+	if err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to fork material: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedForkMaterial)
+	}
+
+	go embedMaterial(h.embeddingService, fork.ID, UserUID)
+	return c.JSON(http.StatusCreated, fork)
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+type TagHandler interface {
+	GetTags(c echo.Context) error
+	CreateTag(c echo.Context) error
+	UpdateTag(c echo.Context) error
+	DeleteTag(c echo.Context) error
+	AssignTags(c echo.Context) error
+	UnassignTags(c echo.Context) error
+}
+
+type tagHandler struct {
+	tagService services.TagService
+}
+
+type tagAssignmentRequest struct {
+	MaterialIDs []uint `json:"material_ids" validate:"required,min=1"`
+	TagIDs      []uint `json:"tag_ids" validate:"required,min=1"`
+}
+
+func NewTagHandler(ts services.TagService) TagHandler {
+	return &tagHandler{tagService: ts}
+}
+
+// GET /api/tags
+func (h *tagHandler) GetTags(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	tags, err := h.tagService.GetTags(UserUID)
+	if err != nil {
+		logger.Errorf("Failed to retrieve tags: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveTags)
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// POST /api/tags
+func (h *tagHandler) CreateTag(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var tag models.Tag
+	if err := c.Bind(&tag); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidTagData)
+	}
+	tag.ID = 0
+	tag.UserUID = UserUID
+
+	if err := h.tagService.CreateTag(&tag); err != nil {
+		return respondWithTagError(c, err, UserUID)
+	}
+
+	logger.Infof("Created tag, TagID: %v, UserUID: %v", tag.ID, UserUID)
+	return c.JSON(http.StatusCreated, tag)
+}
+
+// PUT /api/tags/:id
+func (h *tagHandler) UpdateTag(c echo.Context) error {
+	tagID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var tag models.Tag
+	if err := c.Bind(&tag); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidTagData)
+	}
+	tag.ID = tagID
+	tag.UserUID = UserUID
+
+	if err := h.tagService.UpdateTag(&tag); err != nil {
+		return respondWithTagError(c, err, UserUID)
+	}
+
+	logger.Infof("Updated tag, TagID: %v, UserUID: %v", tagID, UserUID)
+	return c.JSON(http.StatusOK, tag)
+}
+
+// DELETE /api/tags/:id
+func (h *tagHandler) DeleteTag(c echo.Context) error {
+	tagID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	if err := h.tagService.DeleteTag(tagID, UserUID); err != nil {
+		return respondWithTagError(c, err, UserUID)
+	}
+
+	logger.Infof("Deleted tag, TagID: %v, UserUID: %v", tagID, UserUID)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /api/tags/assign
+func (h *tagHandler) AssignTags(c echo.Context) error {
+	return h.changeAssignment(c, h.tagService.AssignTags)
+}
+
+// POST /api/tags/unassign
+func (h *tagHandler) UnassignTags(c echo.Context) error {
+	return h.changeAssignment(c, h.tagService.UnassignTags)
+}
+
+func (h *tagHandler) changeAssignment(c echo.Context, apply func(materialIDs, tagIDs []uint, UserUID string) error) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req tagAssignmentRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidTagData)
+	}
+	if err := c.Validate(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err := apply(req.MaterialIDs, req.TagIDs, UserUID); err != nil {
+		return respondWithTagError(c, err, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func respondWithTagError(c echo.Context, err error, UserUID string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return respondWithError(c, http.StatusNotFound, ErrTagNotFound)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return respondWithError(c, http.StatusConflict, ErrDuplicateTag)
+	case errors.Is(err, services.ErrTagNameRequired),
+		errors.Is(err, services.ErrEmptyAssignment),
+		errors.Is(err, services.ErrTooManyMaterials),
+		errors.Is(err, stores.ErrUnknownMaterialsOrTags):
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	logger.Errorf("Tag operation failed: %v, UserUID: %v", err, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateTags)
+}
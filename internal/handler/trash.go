@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type TrashHandler interface {
+	GetTrash(c echo.Context) error
+	RestoreMaterial(c echo.Context) error
+	PurgeMaterial(c echo.Context) error
+}
+
+type trashHandler struct {
+	trashService     services.TrashService
+	embeddingService services.EmbeddingService
+}
+
+func NewTrashHandler(ts services.TrashService) TrashHandler {
+	return &trashHandler{trashService: ts}
+}
+
+// GET /api/trash?cursor=&limit=
+func (h *trashHandler) GetTrash(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	materials, err := h.trashService.ListTrash(UserUID, page)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to retrieve trash: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveTrash)
+	}
+	return c.JSON(http.StatusOK, materials)
+}
+
+// POST /api/trash/:id/restore
+func (h *trashHandler) RestoreMaterial(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	material, err := h.trashService.RestoreMaterial(materialID, UserUID)
+	if err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to restore material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRestoreMaterial)
+	}
+	// vectors are dropped when a material is trashed
+	go embedMaterial(h.embeddingService, materialID, UserUID)
+	return c.JSON(http.StatusOK, material)
+}
+
+// DELETE /api/trash/:id permanently deletes a trashed material
+func (h *trashHandler) PurgeMaterial(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	if err := h.trashService.PurgeMaterial(materialID, UserUID); err != nil {
+		if errors.Is(err, services.ErrMaterialNotFound) {
+			return respondWithError(c, http.StatusNotFound, ErrMaterialNotFound)
+		}
+		logger.Errorf("Failed to purge material: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedPurgeMaterial)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/services"
 )
 
@@ -11,6 +16,9 @@ type UserHandler interface {
 	// UpdateUser(c echo.Context) error
 	// DeleteUser(c echo.Context) error
 	GetGoogleLoginSignin(c echo.Context) error
+	GetCurrentUser(c echo.Context) error
+	UpdateCurrentUser(c echo.Context) error
+	GetLanguages(c echo.Context) error
 }
 
 type userHandler struct {
@@ -19,6 +27,58 @@ type userHandler struct {
 	Firebase     *Firebase
 }
 
+// GET /api/users/me
+func (h *userHandler) GetCurrentUser(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	user, err := h.UserService.GetUserByUserUID(UserUID)
+	if err != nil {
+		logger.Errorf("Error getting user by UID: %v", err)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveUser)
+	}
+	if user == nil {
+		return respondWithError(c, http.StatusNotFound, ErrUserNotFound)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// PUT /api/users/me with the target_language being learned and the
+// native_language phrases and words are translated into
+func (h *userHandler) UpdateCurrentUser(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var settings services.UserSettings
+	if err := c.Bind(&settings); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidUserData)
+	}
+
+	user, err := h.UserService.UpdateSettings(UserUID, settings)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnsupportedLanguage):
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidNativeLanguage)
+		case errors.Is(err, services.ErrUnsupportedTargetLanguage):
+			return respondWithError(c, http.StatusBadRequest, ErrInvalidLanguage)
+		case errors.Is(err, services.ErrUserNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrUserNotFound)
+		}
+		logger.Errorf("Error updating user: %v, UserUID: %v", err, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrCouldNotUpdateUser)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// GET /api/languages lists the languages that can be learned with their level scales
+func (h *userHandler) GetLanguages(c echo.Context) error {
+	return c.JSON(http.StatusOK, language.All)
+}
+
 // Handlers
 // func (h *userHandler) CreateUser(c echo.Context) error {
 // 	var user models.User
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type VocabularyHandler interface {
+	GetVocabulary(c echo.Context) error
+	UpdateVocabularyEntry(c echo.Context) error
+}
+
+type vocabularyHandler struct {
+	vocabularyService services.VocabularyService
+}
+
+func NewVocabularyHandler(vs services.VocabularyService) VocabularyHandler {
+	return &vocabularyHandler{vocabularyService: vs}
+}
+
+// GET /api/vocabulary?kind=&status=&search=&material_id=&cursor=&limit=&format=
+// format=csv exports every matching entry instead of one page
+func (h *vocabularyHandler) GetVocabulary(c echo.Context) error {
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	query, err := parseVocabularyQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	switch c.QueryParam("format") {
+	case "", "json":
+	case "csv":
+		entries, err := h.vocabularyService.ExportEntries(query, UserUID)
+		if err != nil {
+			return h.respondWithVocabularyError(c, err, UserUID)
+		}
+		return writeVocabularyCSV(c, entries)
+	default:
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidExportFormat)
+	}
+
+	entries, err := h.vocabularyService.ListEntries(query, UserUID)
+	if err != nil {
+		return h.respondWithVocabularyError(c, err, UserUID)
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// PUT /api/vocabulary/:id
+func (h *vocabularyHandler) UpdateVocabularyEntry(c echo.Context) error {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidVocabularyID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var update services.VocabularyUpdate
+	if err := c.Bind(&update); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidVocabularyData)
+	}
+
+	entry, err := h.vocabularyService.UpdateEntry(id, UserUID, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVocabularyNotFound):
+			return respondWithError(c, http.StatusNotFound, ErrVocabularyNotFound)
+		case errors.Is(err, services.ErrInvalidVocabStatus):
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to update vocabulary entry: %v, ID: %v, UserUID: %v", err, id, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedUpdateVocabulary)
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+func (h *vocabularyHandler) respondWithVocabularyError(c echo.Context, err error, UserUID string) error {
+	switch {
+	case errors.Is(err, stores.ErrInvalidCursor),
+		errors.Is(err, services.ErrInvalidVocabKind),
+		errors.Is(err, services.ErrInvalidVocabStatus):
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+	logger.Errorf("Failed to retrieve vocabulary: %v, UserUID: %v", err, UserUID)
+	return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveVocabulary)
+}
+
+func parseVocabularyQuery(c echo.Context) (stores.VocabularyQuery, error) {
+	var query stores.VocabularyQuery
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return query, err
+	}
+	query.PageQuery = page
+	query.Kind = c.QueryParam("kind")
+	query.Status = c.QueryParam("status")
+	query.Search = strings.TrimSpace(c.QueryParam("search"))
+
+	if materialID, ok, err := parseOptionalUintQuery(c, "material_id"); err != nil {
+		return query, err
+	} else if ok {
+		query.MaterialID = materialID
+	}
+	return query, nil
+}
+
+// writeVocabularyCSV writes entries as a CSV attachment, one row per entry with
+// its source material titles joined by "; "
+func writeVocabularyCSV(c echo.Context, entries []models.VocabularyEntry) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="vocabulary.csv"`)
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	if err := w.Write([]string{"id", "kind", "text", "status", "note", "materials"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		titles := make([]string, len(entry.Sources))
+		for i, source := range entry.Sources {
+			titles[i] = source.MaterialTitle
+		}
+		row := []string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.Kind,
+			entry.Text,
+			entry.Status,
+			entry.Note,
+			strings.Join(titles, "; "),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/services"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type WordHandler interface {
+	GetWords(c echo.Context) error
+	CreateWord(c echo.Context) error
+	UpdateWord(c echo.Context) error
+	DeleteWord(c echo.Context) error
+	ReorderWords(c echo.Context) error
+}
+
+type wordHandler struct {
+	wordService        services.WordService
+	translationService services.TranslationService
+}
+
+type wordRequest struct {
+	Text       string `json:"text"`
+	Importance string `json:"importance"`
+	Level      string `json:"level"`
+	Starred    bool   `json:"starred"`
+}
+
+func NewWordHandler(ws services.WordService) WordHandler {
+	return &wordHandler{wordService: ws}
+}
+
+// GET /api/materials/:id/words?cursor=&limit=
+func (h *wordHandler) GetWords(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	page, err := parsePageQuery(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error())
+	}
+
+	words, err := h.wordService.ListWords(materialID, UserUID, page)
+	if err != nil {
+		if errors.Is(err, stores.ErrInvalidCursor) {
+			return respondWithError(c, http.StatusBadRequest, err.Error())
+		}
+		logger.Errorf("Failed to retrieve words: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+		return respondWithError(c, http.StatusInternalServerError, ErrFailedRetrieveWords)
+	}
+	if err := h.translationService.AttachWordTranslations(words.Items, UserUID); err != nil {
+		logger.Errorf("Failed to translate words: %v, MaterialID: %v, UserUID: %v", err, materialID, UserUID)
+	}
+	return c.JSON(http.StatusOK, words)
+}
+
+// POST /api/materials/:id/words
+func (h *wordHandler) CreateWord(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req wordRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidWordData)
+	}
+	word := models.Word{Text: req.Text, Importance: req.Importance, Level: req.Level, Starred: req.Starred}
+
+	if err := h.wordService.CreateWord(materialID, UserUID, &word); err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.JSON(http.StatusCreated, word)
+}
+
+// PUT /api/materials/:id/words/:wordId
+func (h *wordHandler) UpdateWord(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	wordID, err := parseUintParam(c, "wordId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	var update services.WordUpdate
+	if err := c.Bind(&update); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidWordData)
+	}
+
+	word, err := h.wordService.UpdateWord(materialID, wordID, UserUID, update)
+	if err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.JSON(http.StatusOK, word)
+}
+
+// DELETE /api/materials/:id/words/:wordId
+func (h *wordHandler) DeleteWord(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	wordID, err := parseUintParam(c, "wordId")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidID)
+	}
+
+	if err := h.wordService.DeleteWord(materialID, wordID, UserUID); err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PUT /api/materials/:id/words/order with {"ids": [...]} listing every word in its new order
+func (h *wordHandler) ReorderWords(c echo.Context) error {
+	materialID, err := parseUintParam(c, "id")
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidMaterialID)
+	}
+
+	UserUID, err := getUserUIDFromContext(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, ErrInvalidUserToken)
+	}
+
+	var req reorderRequest
+	if err := c.Bind(&req); err != nil {
+		return respondWithError(c, http.StatusBadRequest, ErrInvalidWordData)
+	}
+
+	if err := h.wordService.ReorderWords(materialID, UserUID, req.IDs); err != nil {
+		return respondWithCurationError(c, err, materialID, UserUID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package kindle
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ClippingHighlight = "highlight"
+	ClippingNote      = "note"
+	ClippingBookmark  = "bookmark"
+
+	separator = "=========="
+)
+
+// Clipping is a single entry of a Kindle "My Clippings.txt" file
+type Clipping struct {
+	Title    string
+	Author   string
+	Type     string
+	Page     string
+	Location string
+	AddedAt  time.Time
+	Text     string
+}
+
+// Book groups the highlights of one title
+type Book struct {
+	Title      string
+	Author     string
+	Highlights []Clipping
+}
+
+var (
+	authorPattern   = regexp.MustCompile(`^(.*)\(([^()]*)\)\s*$`)
+	typePattern     = regexp.MustCompile(`(?i)your\s+(highlight|note|bookmark)`)
+	pagePattern     = regexp.MustCompile(`(?i)page\s+([0-9ivxlc-]+)`)
+	locationPattern = regexp.MustCompile(`(?i)location\s+([0-9-]+)`)
+	addedPattern    = regexp.MustCompile(`(?i)added on\s+(.+)$`)
+)
+
+var addedLayouts = []string{
+	"Monday, January 2, 2006 3:04:05 PM",
+	"Monday, 2 January 2006 15:04:05",
+	"Monday, January 2, 2006, 3:04 PM",
+	"Monday, 2 January 2006 3:04:05 PM",
+}
+
+// Parse reads a "My Clippings.txt" stream and returns every clipping in file order
+func Parse(r io.Reader) ([]Clipping, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var clippings []Clipping
+	var block []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == separator {
+			if clipping, ok := parseBlock(block); ok {
+				clippings = append(clippings, clipping)
+			}
+			block = block[:0]
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if clipping, ok := parseBlock(block); ok {
+		clippings = append(clippings, clipping)
+	}
+	return clippings, nil
+}
+
+// GroupByBook collects highlights per title, dropping notes, bookmarks and
+// the duplicates Kindle writes when a highlight is edited
+func GroupByBook(clippings []Clipping) []Book {
+	var books []Book
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+
+	for _, clipping := range clippings {
+		if clipping.Type != ClippingHighlight || clipping.Text == "" {
+			continue
+		}
+		key := clipping.Title + "\x00" + clipping.Author
+		dedupKey := key + "\x00" + Hash(clipping.Text)
+		if seen[dedupKey] {
+			continue
+		}
+		seen[dedupKey] = true
+
+		i, ok := index[key]
+		if !ok {
+			books = append(books, Book{Title: clipping.Title, Author: clipping.Author})
+			i = len(books) - 1
+			index[key] = i
+		}
+		books[i].Highlights = append(books[i].Highlights, clipping)
+	}
+	return books
+}
+
+// Hash returns a stable identifier for a highlight text, ignoring case and spacing
+func Hash(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	sum := sha1.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseBlock(lines []string) (Clipping, bool) {
+	// drop leading blank lines left over from the previous separator
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) < 2 {
+		return Clipping{}, false
+	}
+
+	var clipping Clipping
+	clipping.Title, clipping.Author = parseTitleLine(lines[0])
+	parseMetaLine(lines[1], &clipping)
+	if clipping.Type == "" {
+		return Clipping{}, false
+	}
+
+	clipping.Text = strings.TrimSpace(strings.Join(lines[2:], "\n"))
+	return clipping, true
+}
+
+func parseTitleLine(line string) (string, string) {
+	line = strings.TrimPrefix(line, "\ufeff")
+	line = strings.TrimSpace(line)
+	if m := authorPattern.FindStringSubmatch(line); m != nil && strings.TrimSpace(m[1]) != "" {
+		return strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+	}
+	return line, ""
+}
+
+func parseMetaLine(line string, clipping *Clipping) {
+	if m := typePattern.FindStringSubmatch(line); m != nil {
+		clipping.Type = strings.ToLower(m[1])
+	}
+	if m := pagePattern.FindStringSubmatch(line); m != nil {
+		clipping.Page = m[1]
+	}
+	if m := locationPattern.FindStringSubmatch(line); m != nil {
+		clipping.Location = m[1]
+	}
+	if m := addedPattern.FindStringSubmatch(line); m != nil {
+		added := strings.TrimSpace(m[1])
+		for _, layout := range addedLayouts {
+			if t, err := time.Parse(layout, added); err == nil {
+				clipping.AddedAt = t
+				break
+			}
+		}
+	}
+}
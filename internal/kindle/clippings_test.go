@@ -0,0 +1,67 @@
+package kindle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleClippings = "\ufeffThe Pragmatic Programmer (Hunt, Andrew)\r\n" +
+	"- Your Highlight on page 12 | Location 150-152 | Added on Monday, March 4, 2019 10:00:00 PM\r\n" +
+	"\r\n" +
+	"Care about your craft.\r\n" +
+	"==========\r\n" +
+	"The Pragmatic Programmer (Hunt, Andrew)\r\n" +
+	"- Your Note on page 12 | Location 152 | Added on Monday, March 4, 2019 10:01:00 PM\r\n" +
+	"\r\n" +
+	"remember this\r\n" +
+	"==========\r\n" +
+	"The Pragmatic Programmer (Hunt, Andrew)\r\n" +
+	"- Your Highlight on page 12 | Location 150-152 | Added on Monday, March 4, 2019 10:02:00 PM\r\n" +
+	"\r\n" +
+	"Care about  your craft.\r\n" +
+	"==========\r\n" +
+	"Atomic Habits\r\n" +
+	"- Your Highlight at location 300-301 | Added on Tuesday, 5 March 2019 08:15:00\r\n" +
+	"\r\n" +
+	"You do not rise to the level of your goals.\r\n" +
+	"==========\r\n" +
+	"Atomic Habits\r\n" +
+	"- Your Bookmark at location 310 | Added on Tuesday, 5 March 2019 08:16:00\r\n" +
+	"\r\n" +
+	"\r\n" +
+	"==========\r\n"
+
+func TestParse(t *testing.T) {
+	clippings, err := Parse(strings.NewReader(sampleClippings))
+	assert.NoError(t, err)
+	assert.Len(t, clippings, 5)
+
+	first := clippings[0]
+	assert.Equal(t, "The Pragmatic Programmer", first.Title)
+	assert.Equal(t, "Hunt, Andrew", first.Author)
+	assert.Equal(t, ClippingHighlight, first.Type)
+	assert.Equal(t, "12", first.Page)
+	assert.Equal(t, "150-152", first.Location)
+	assert.Equal(t, 2019, first.AddedAt.Year())
+	assert.Equal(t, "Care about your craft.", first.Text)
+
+	assert.Equal(t, ClippingNote, clippings[1].Type)
+	assert.Equal(t, "Atomic Habits", clippings[3].Title)
+	assert.Equal(t, "", clippings[3].Author)
+	assert.Equal(t, 8, clippings[3].AddedAt.Hour())
+	assert.Equal(t, ClippingBookmark, clippings[4].Type)
+}
+
+func TestGroupByBook(t *testing.T) {
+	clippings, err := Parse(strings.NewReader(sampleClippings))
+	assert.NoError(t, err)
+
+	books := GroupByBook(clippings)
+	assert.Len(t, books, 2)
+	assert.Equal(t, "The Pragmatic Programmer", books[0].Title)
+	assert.Len(t, books[0].Highlights, 1)
+	assert.Equal(t, "Atomic Habits", books[1].Title)
+	assert.Len(t, books[1].Highlights, 1)
+}
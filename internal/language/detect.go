@@ -0,0 +1,70 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent function words that tell Latin-script languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "was", "for", "with", "you", "this", "are", "have", "not", "be", "on", "they", "but"},
+	"es": {"el", "la", "de", "que", "y", "los", "las", "en", "un", "una", "es", "por", "con", "para", "del", "se", "no", "lo", "como", "pero"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "du", "que", "qui", "dans", "pour", "pas", "sur", "au", "il", "elle", "avec"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "von", "mit", "sich", "des", "auf", "für", "im", "dem", "auch", "es"},
+	"it": {"il", "la", "di", "che", "e", "è", "un", "una", "per", "non", "gli", "le", "del", "della", "con", "sono", "si", "nel", "anche", "ma"},
+	"pt": {"o", "a", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "os", "as", "no", "na", "se", "por", "mais"},
+}
+
+// Detect guesses the language of text: CJK languages from their scripts,
+// Latin-script ones from their most frequent words. Text it cannot tell
+// apart is taken to be in the default language.
+func Detect(text string) string {
+	var kana, han, hangul, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch cjk := kana + han + hangul; {
+	case cjk == 0 || cjk < latin:
+		return detectLatin(text)
+	case hangul >= kana+han:
+		return "ko"
+	case kana > 0:
+		// Japanese mixes kana into kanji text; Chinese has none
+		return "ja"
+	default:
+		return "zh"
+	}
+}
+
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[word]++
+	}
+
+	best, bestScore := Default, 0
+	// ranging over All keeps ties deterministic, in favour of earlier languages
+	for _, l := range All {
+		score := 0
+		for _, word := range stopwords[l.Code] {
+			score += counts[word]
+		}
+		if score > bestScore {
+			best, bestScore = l.Code, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,120 @@
+// Package language describes the languages materials can be written in and
+// the proficiency scale learners of each are levelled on, and detects the
+// language of a text.
+package language
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default is the language of materials and learners that do not say otherwise
+const Default = "en"
+
+const (
+	ScaleCEFR = "CEFR"
+	ScaleJLPT = "JLPT"
+	ScaleHSK  = "HSK"
+)
+
+// Language is a language that can be learned
+type Language struct {
+	// Code is the ISO 639-1 code
+	Code string `json:"code"`
+	// Name is the English name, as used in prompts
+	Name  string `json:"name"`
+	Scale string `json:"scale"`
+	// Levels are the levels of Scale from easiest to hardest
+	Levels []string `json:"levels"`
+}
+
+var (
+	cefrLevels = []string{"A1", "A2", "B1", "B2", "C1", "C2"}
+	jlptLevels = []string{"N5", "N4", "N3", "N2", "N1"}
+	hskLevels  = []string{"HSK1", "HSK2", "HSK3", "HSK4", "HSK5", "HSK6"}
+)
+
+// All are the supported languages
+var All = []Language{
+	{Code: "en", Name: "English", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "es", Name: "Spanish", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "fr", Name: "French", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "de", Name: "German", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "it", Name: "Italian", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "pt", Name: "Portuguese", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "ko", Name: "Korean", Scale: ScaleCEFR, Levels: cefrLevels},
+	{Code: "ja", Name: "Japanese", Scale: ScaleJLPT, Levels: jlptLevels},
+	{Code: "zh", Name: "Chinese", Scale: ScaleHSK, Levels: hskLevels},
+}
+
+// Get returns the supported language of code
+func Get(code string) (Language, bool) {
+	for _, l := range All {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// Of returns the language of code, falling back to the default language for
+// codes that are empty or not supported
+func Of(code string) Language {
+	if l, ok := Get(code); ok {
+		return l
+	}
+	l, _ := Get(Default)
+	return l
+}
+
+// IsEnglish reports whether code is English, the only language the offline
+// lemmatizer, word list and readability formulas handle
+func IsEnglish(code string) bool {
+	return code == "" || code == "en"
+}
+
+// NormalizeLevel puts level in the form used by the language's scale, so that
+// "n3" becomes "N3" and "3" becomes "HSK3" for Chinese
+func (l Language) NormalizeLevel(level string) string {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if l.Scale == ScaleHSK && !strings.HasPrefix(level, ScaleHSK) {
+		level = ScaleHSK + level
+	}
+	return level
+}
+
+// IsLevel reports whether level is on the language's scale
+func (l Language) IsLevel(level string) bool {
+	return l.index(level) >= 0
+}
+
+func (l Language) index(level string) int {
+	for i, lv := range l.Levels {
+		if lv == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Describe names level for a prompt, e.g. "CEFR level B1" or "JLPT N3"
+func (l Language) Describe(level string) string {
+	switch l.Scale {
+	case ScaleJLPT:
+		return "JLPT " + level
+	case ScaleHSK:
+		return "HSK level " + strings.TrimPrefix(level, ScaleHSK)
+	}
+	return fmt.Sprintf("%s level %s", l.Scale, level)
+}
+
+// CEFR returns the CEFR level roughly equivalent to level, so that materials
+// in all languages can be filtered on one scale. JLPT N5 to N1 map to A1 to
+// C1 and HSK 1 to 6 to A1 to C2.
+func (l Language) CEFR(level string) string {
+	i := l.index(level)
+	if i < 0 {
+		return ""
+	}
+	return cefrLevels[i]
+}
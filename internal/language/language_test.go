@@ -0,0 +1,43 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	cases := map[string]string{
+		"The committee met on Tuesday and it was decided that the plan should go ahead.": "en",
+		"El comité se reunió el martes y se decidió que el plan sigue adelante.":         "es",
+		"Le comité s'est réuni mardi et il a été décidé que le plan continue.":           "fr",
+		"Der Ausschuss hat sich am Dienstag getroffen und die Pläne sind nicht neu.":     "de",
+		"Il comitato si è riunito martedì e ha deciso che il piano non cambia.":          "it",
+		"O comitê se reuniu na terça e decidiu que o plano não muda.":                    "pt",
+		"委員会は火曜日に開かれ、計画を進めることが決まった。":                                                     "ja",
+		"委员会星期二开会，决定继续推进这个计划。":                                                           "zh",
+		"위원회는 화요일에 모여 계획을 진행하기로 했다.":                                                     "ko",
+		"":     Default,
+		"1234": Default,
+	}
+	for text, want := range cases {
+		assert.Equal(t, want, Detect(text), text)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	ja := Of("ja")
+	assert.Equal(t, "N3", ja.NormalizeLevel("n3"))
+	assert.True(t, ja.IsLevel("N3"))
+	assert.False(t, ja.IsLevel("B1"))
+	assert.Equal(t, "JLPT N3", ja.Describe("N3"))
+	assert.Equal(t, "B1", ja.CEFR("N3"))
+
+	zh := Of("zh")
+	assert.Equal(t, "HSK4", zh.NormalizeLevel("4"))
+	assert.Equal(t, "HSK level 4", zh.Describe("HSK4"))
+	assert.Equal(t, "B2", zh.CEFR("HSK4"))
+
+	assert.Equal(t, "CEFR level B1", Of("xx").Describe("B1"))
+	assert.Equal(t, "", Of("en").CEFR("N3"))
+}
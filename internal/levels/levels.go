@@ -0,0 +1,155 @@
+// Package levels assigns CEFR levels and frequency ranks to English words from
+// an embedded reference list, so that levelling works offline and gives the
+// same answer every time.
+package levels
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+
+	"github.com/yomek33/talki/internal/nlp"
+)
+
+//go:embed wordlist.tsv
+var wordlist string
+
+// CEFR levels from easiest to hardest. Words missing from the list are
+// treated as C2.
+var CEFR = []string{"A1", "A2", "B1", "B2", "C1", "C2"}
+
+// Beyond is the level given to words the list does not contain
+const Beyond = "C2"
+
+// coverageThreshold is the share of tokens a reader must know at a level for
+// a text to be estimated at that level
+const coverageThreshold = 0.9
+
+// Entry is one lemma of the reference list
+type Entry struct {
+	Lemma string `json:"lemma"`
+	// Rank is the frequency rank, 1 being the most frequent
+	Rank  int    `json:"rank"`
+	Level string `json:"level"`
+}
+
+var entries = load(wordlist)
+
+func load(list string) map[string]Entry {
+	loaded := make(map[string]Entry)
+	rank := 0
+	for _, line := range strings.Split(list, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			continue
+		}
+		rank++
+		lemma := nlp.Lemma(fields[0])
+		if _, ok := loaded[lemma]; !ok {
+			loaded[lemma] = Entry{Lemma: lemma, Rank: rank, Level: fields[1]}
+		}
+	}
+	return loaded
+}
+
+// Lookup returns the list entry of word, lemmatizing it first
+func Lookup(word string) (Entry, bool) {
+	entry, ok := entries[nlp.Lemma(word)]
+	return entry, ok
+}
+
+// IsLevel reports whether level is a CEFR level
+func IsLevel(level string) bool {
+	return index(level) >= 0
+}
+
+func index(level string) int {
+	for i, l := range CEFR {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Of returns the level of text: that of its hardest word
+func Of(text string) string {
+	hardest := -1
+	for _, token := range nlp.Tokenize(text) {
+		level := Beyond
+		if entry, ok := Lookup(token); ok {
+			level = entry.Level
+		}
+		if i := index(level); i > hardest {
+			hardest = i
+		}
+	}
+	if hardest < 0 {
+		return ""
+	}
+	return CEFR[hardest]
+}
+
+// Resolve cross-checks a level provided for word, by a model or a learner,
+// against the list. Listed words always get their reference level; for other
+// words the provided level is kept.
+func Resolve(word, provided string) string {
+	if len(nlp.Tokenize(word)) == 1 {
+		if entry, ok := Lookup(word); ok {
+			return entry.Level
+		}
+	}
+	return provided
+}
+
+// Importance rates how useful a phrase is to learn: phrases made of everyday
+// words are worth more than ones hinging on a rare word
+func Importance(text string) string {
+	switch Of(text) {
+	case "A1", "A2":
+		return "high"
+	case "B1", "B2":
+		return "medium"
+	}
+	return "low"
+}
+
+// Estimate is the vocabulary difficulty of a text
+type Estimate struct {
+	// Level is the lowest level whose words cover 90% of the text
+	Level string `json:"level"`
+	// Profile is the percentage of tokens at each level
+	Profile map[string]float64 `json:"profile"`
+}
+
+// EstimateLevel estimates the level of a text from its lemma counts, as
+// returned by nlp.LemmaCounts
+func EstimateLevel(counts map[string]int) Estimate {
+	perLevel := make([]int, len(CEFR))
+	total := 0
+	for lemma, count := range counts {
+		level := len(CEFR) - 1
+		if entry, ok := entries[lemma]; ok {
+			level = index(entry.Level)
+		}
+		perLevel[level] += count
+		total += count
+	}
+
+	estimate := Estimate{Profile: make(map[string]float64, len(CEFR))}
+	if total == 0 {
+		return estimate
+	}
+	covered := 0
+	for i, level := range CEFR {
+		estimate.Profile[level] = math.Round(float64(perLevel[i])*1000/float64(total)) / 10
+		covered += perLevel[i]
+		if estimate.Level == "" && float64(covered) >= coverageThreshold*float64(total) {
+			estimate.Level = level
+		}
+	}
+	return estimate
+}
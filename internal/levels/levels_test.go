@@ -0,0 +1,45 @@
+package levels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomek33/talki/internal/nlp"
+)
+
+func TestLookup(t *testing.T) {
+	entry, ok := Lookup("studying")
+	assert.True(t, ok)
+	assert.Equal(t, "study", entry.Lemma)
+	assert.Equal(t, "A1", entry.Level)
+
+	_, ok = Lookup("zyzzyva")
+	assert.False(t, ok)
+}
+
+func TestOf(t *testing.T) {
+	assert.Equal(t, "A1", Of("I like tea"))
+	assert.Equal(t, "C1", Of("a meticulous plan"))
+	assert.Equal(t, Beyond, Of("the zyzzyva"))
+	assert.Equal(t, "", Of("42"))
+}
+
+func TestResolve(t *testing.T) {
+	assert.Equal(t, "B2", Resolve("ambiguity", "B2"))
+	assert.Equal(t, "C1", Resolve("meticulous", "A1"))
+	assert.Equal(t, "B1", Resolve("a meticulous plan", "B1"))
+}
+
+func TestImportance(t *testing.T) {
+	assert.Equal(t, "high", Importance("break the ice"))
+	assert.Equal(t, "medium", Importance("reach a compromise"))
+	assert.Equal(t, "low", Importance("an egregious error"))
+}
+
+func TestEstimateLevel(t *testing.T) {
+	estimate := EstimateLevel(nlp.LemmaCounts("The cat likes milk. The dog likes water. My friend has a meticulous plan."))
+	assert.Equal(t, "A2", estimate.Level)
+	assert.Equal(t, 7.1, estimate.Profile["C1"])
+
+	assert.Equal(t, "", EstimateLevel(nil).Level)
+}
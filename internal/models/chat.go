@@ -4,11 +4,22 @@ import "gorm.io/gorm"
 
 type Chat struct {
 	gorm.Model
-	Detail         string    `gorm:"type:text" json:"detail"`
-	MaterialID     uint      `gorm:"index" json:"material_id" validate:"required"`
-	UserUID        string    `gorm:"index" json:"user_uid" validate:"required"`
-	Messages       []Message `gorm:"foreignKey:ChatID;references:ID"`
-	PendingMessage uint      `gorm: json:"pending_message"`
+	Detail     string    `gorm:"type:text" json:"detail"`
+	MaterialID uint      `gorm:"index" json:"material_id" validate:"required"`
+	UserUID    string    `gorm:"index" json:"user_uid" validate:"required"`
+	Messages   []Message `gorm:"foreignKey:ChatID;references:ID"`
+	// PendingMessage is 1 while a reply in the chat is being generated, so
+	// that the chat takes one message at a time
+	PendingMessage uint `json:"pending_message"`
+	// ReplyToken identifies the request holding the pending reply, so that
+	// only it releases the claim
+	ReplyToken string `gorm:"type:varchar(64)" json:"-"`
+	Mode       string `gorm:"type:varchar(32);default:free_talk" json:"mode"`
+	// ScenarioID is the user's own scenario of a custom chat
+	ScenarioID *uint `gorm:"index" json:"scenario_id"`
+	// ActiveLeafID is the last message of the branch the chat continues from;
+	// nil for chats without messages
+	ActiveLeafID *uint `json:"active_leaf_id"`
 }
 
 type Message struct {
@@ -17,4 +28,10 @@ type Message struct {
 	Content    string `gorm:"type:text" json:"content"`
 	UserUID    string `gorm:"index" json:"user_uid"`
 	SenderType string `gorm:"type:varchar(255)" json:"sender_type"` // user or bot
+	// ParentID is the message this one follows. Edited and regenerated
+	// messages are siblings of the ones they replace, so a chat is a tree.
+	ParentID *uint `gorm:"index" json:"parent_id"`
+	// SiblingIDs are the alternatives to this message on its branch, itself
+	// included; filled in when the branch has more than one
+	SiblingIDs []uint `gorm:"-" json:"sibling_ids,omitempty"`
 }
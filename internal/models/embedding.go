@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// Embedding is the vector of a material or phrase for one embedding model
+type Embedding struct {
+	gorm.Model
+	Kind       string `gorm:"type:varchar(32);uniqueIndex:idx_embedding_ref" json:"kind"`
+	RefID      uint   `gorm:"uniqueIndex:idx_embedding_ref" json:"ref_id"`
+	ModelName  string `gorm:"type:varchar(64);uniqueIndex:idx_embedding_ref" json:"model_name"`
+	UserUID    string `gorm:"type:varchar(255);index" json:"user_uid"`
+	MaterialID uint   `gorm:"index" json:"material_id"`
+	Vector     []byte `gorm:"type:mediumblob" json:"-"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SourceManual = "manual"
+	SourceKindle = "kindle"
+)
+
+type Highlight struct {
+	gorm.Model
+	MaterialID uint      `gorm:"uniqueIndex:idx_highlight_material_hash;not null" json:"material_id"`
+	UserUID    string    `gorm:"type:varchar(255);index" json:"user_uid"`
+	Hash       string    `gorm:"type:varchar(40);uniqueIndex:idx_highlight_material_hash" json:"-"`
+	Text       string    `gorm:"type:text" json:"text"`
+	Location   string    `gorm:"type:varchar(64)" json:"location"`
+	AddedAt    time.Time `json:"added_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+
+	JobKindGeneratePhrases = "generate_phrases"
+	JobKindAdaptMaterial   = "adapt_material"
+)
+
+// Job tracks a background task started from the API
+type Job struct {
+	gorm.Model
+	UserUID    string `gorm:"type:varchar(255);index" json:"-"`
+	MaterialID uint   `gorm:"index" json:"material_id"`
+	Kind       string `gorm:"type:varchar(32)" json:"kind"`
+	Status     string `gorm:"type:varchar(16)" json:"status"`
+	Error      string `gorm:"type:text" json:"error,omitempty"`
+	// Added and Removed count the rows the job created and deleted
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	// ResultID is the material the job created, if any
+	ResultID   *uint      `json:"result_id,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
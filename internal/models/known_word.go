@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	KnownSourceManual = "manual"
+	KnownSourceReview = "review"
+)
+
+// KnownWord is a lemma of a language the user already understands
+type KnownWord struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserUID   string    `gorm:"type:varchar(255);uniqueIndex:idx_known_user_language_lemma;not null" json:"-"`
+	Language  string    `gorm:"type:varchar(8);uniqueIndex:idx_known_user_language_lemma;default:en;not null" json:"language"`
+	Lemma     string    `gorm:"type:varchar(191);uniqueIndex:idx_known_user_language_lemma;not null" json:"lemma"`
+	Source    string    `gorm:"type:varchar(16)" json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaterialLemma counts how often a lemma occurs in a material's content
+type MaterialLemma struct {
+	MaterialID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Lemma      string `gorm:"type:varchar(191);primaryKey;index"`
+	Count      int
+}
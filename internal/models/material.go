@@ -10,13 +10,53 @@ const (
 	StatusFailed     = "failed"
 )
 
+const (
+	VisibilityPrivate = "private"
+	// VisibilityLink materials can be opened by anyone holding the share token
+	VisibilityLink = "link"
+	// VisibilityPublic materials are listed in the public library
+	VisibilityPublic = "public"
+)
+
 type Material struct {
 	gorm.Model
-	UserUID string   `gorm:"type:varchar(255);index;foreignKey" json:"uid"`
-	Title   string   `gorm:"type:varchar(255)" json:"title" validate:"required"`
-	Content string   `gorm:"type:text" json:"content" `
-	Phrases []Phrase `gorm:"foreignKey:MaterialID;references:ID"`
-	Status  string   `gorm:"type:varchar(255)" json:"status"`
-	Chats   []Chat   `gorm:"foreignKey:MaterialID;references:ID"`
-	Words[] Word `gorm:"foreignKey:MaterialID;references:ID"`
+	UserUID string `gorm:"type:varchar(255);index;foreignKey" json:"uid,omitempty"`
+	Title   string `gorm:"type:varchar(255)" json:"title" validate:"required"`
+	Author  string `gorm:"type:varchar(255)" json:"author"`
+	Source  string `gorm:"type:varchar(32)" json:"source"`
+	Content string `gorm:"type:text" json:"content" `
+	// Language is the ISO 639-1 code of Content, detected when not given
+	Language string   `gorm:"type:varchar(8);default:en;index" json:"language"`
+	Phrases  []Phrase `gorm:"foreignKey:MaterialID;references:ID"`
+	Status   string   `gorm:"type:varchar(255)" json:"status"`
+	Chats    []Chat   `gorm:"foreignKey:MaterialID;references:ID"`
+	Words    []Word   `gorm:"foreignKey:MaterialID;references:ID"`
+	Tags     []Tag    `gorm:"many2many:material_tags;" json:"tags,omitempty"`
+
+	Visibility   string  `gorm:"type:varchar(16);default:private;index" json:"visibility"`
+	ShareToken   *string `gorm:"type:varchar(64);uniqueIndex" json:"share_token,omitempty"`
+	ForkedFromID *uint   `gorm:"index" json:"forked_from_id,omitempty"`
+
+	// ParentID is the material this one is a leveled rewrite of, at AdaptedLevel
+	// on the scale of its language, such as "B1" or "N3"
+	ParentID     *uint  `gorm:"index" json:"parent_id,omitempty"`
+	AdaptedLevel string `gorm:"type:varchar(8)" json:"adapted_level,omitempty"`
+
+	// TokenCount is the number of words in Content, kept with its MaterialLemma rows
+	TokenCount int `gorm:"default:0;index" json:"word_count"`
+
+	// Readability of Content, computed whenever it changes
+	SentenceCount             int     `json:"sentence_count"`
+	LexicalDiversity          float64 `json:"lexical_diversity"`
+	FleschReadingEase         float64 `gorm:"index" json:"flesch_reading_ease"`
+	FleschKincaidGrade        float64 `json:"flesch_kincaid_grade"`
+	AutomatedReadabilityIndex float64 `json:"automated_readability_index"`
+	// Level is the estimated CEFR level of the vocabulary of Content. Only
+	// English is estimated; rewrites in other languages take the CEFR
+	// equivalent of their AdaptedLevel.
+	Level string `gorm:"type:varchar(2);index" json:"level"`
+
+	// Coverage is the percentage of Content the reading user already knows
+	Coverage      *float64 `gorm:"->;-:migration" json:"coverage,omitempty"`
+	UnknownLemmas []string `gorm:"-" json:"unknown_lemmas,omitempty"`
 }
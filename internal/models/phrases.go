@@ -10,4 +10,11 @@ type Phrase struct {
 	MaterialID uint `gorm:"index;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Text       string
 	Importance string
+	// Position orders the phrases of a material; learners can reorder them
+	Position int  `gorm:"index"`
+	Starred  bool `gorm:"index"`
+	// UserEdited phrases were written or changed by the learner and survive regeneration
+	UserEdited bool
+	// Translation is into the reader's native language, filled in on listing
+	Translation string `gorm:"-"`
 }
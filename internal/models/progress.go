@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Progress is the spaced-repetition schedule of one phrase or word for a
+// user. Kind is VocabularyKindPhrase or VocabularyKindWord.
+type Progress struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	UserUID      string    `gorm:"type:varchar(255);uniqueIndex:idx_progress_item;not null" json:"-"`
+	Kind         string    `gorm:"type:varchar(16);uniqueIndex:idx_progress_item;not null" json:"kind"`
+	ItemID       uint      `gorm:"uniqueIndex:idx_progress_item;not null" json:"item_id"`
+	Repetitions  int       `json:"repetitions"`
+	IntervalDays int       `json:"interval_days"`
+	Ease         float64   `json:"ease"`
+	DueAt        time.Time `gorm:"index" json:"due_at"`
+	LastReviewed time.Time `json:"last_reviewed"`
+	// Exposures counts the uses of the item in chat while it was not due,
+	// which leave the schedule as it is
+	Exposures int       `gorm:"default:0" json:"exposures"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UsageEvent records that the learner used a phrase or word of the chat's
+// material in one of their messages
+type UsageEvent struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserUID    string `gorm:"type:varchar(255);index" json:"-"`
+	ChatID     uint   `gorm:"index" json:"chat_id"`
+	MessageID  uint   `gorm:"index" json:"message_id"`
+	MaterialID uint   `json:"material_id"`
+	Kind       string `gorm:"type:varchar(16)" json:"kind"`
+	ItemID     uint   `json:"item_id"`
+	// Text is what the learner wrote, which may be an inflected or misspelled form
+	Text string `gorm:"type:text" json:"text"`
+	// Exact is false when the item only matched through lemmas, gaps or a typo
+	Exact     bool      `json:"exact"`
+	CreatedAt time.Time `json:"created_at"`
+}
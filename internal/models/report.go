@@ -0,0 +1,43 @@
+package models
+
+import "gorm.io/gorm"
+
+// ChatReport is the feedback on one chat session. Scores run from 0 to 100;
+// Overall is their average.
+type ChatReport struct {
+	gorm.Model
+	UserUID         string `gorm:"type:varchar(255);index" json:"-"`
+	ChatID          uint   `gorm:"index" json:"chat_id"`
+	MaterialID      uint   `gorm:"index" json:"material_id"`
+	Mode            string `gorm:"type:varchar(32)" json:"mode"`
+	Fluency         int    `json:"fluency"`
+	Accuracy        int    `json:"accuracy"`
+	VocabularyRange int    `json:"vocabulary_range"`
+	PhraseUsage     int    `json:"phrase_usage"`
+	Overall         int    `json:"overall"`
+	// PhrasesUsed of the material's PhrasesTotal phrases appeared in the
+	// learner's messages
+	PhrasesUsed  int                `json:"phrases_used"`
+	PhrasesTotal int                `json:"phrases_total"`
+	Summary      string             `gorm:"type:text" json:"summary"`
+	Mistakes     []ReportMistake    `gorm:"foreignKey:ReportID" json:"mistakes"`
+	Suggestions  []ReportSuggestion `gorm:"foreignKey:ReportID" json:"suggestions"`
+}
+
+// ReportMistake is a mistake the learner made, Count times in the session
+type ReportMistake struct {
+	ID          uint   `gorm:"primaryKey" json:"-"`
+	ReportID    uint   `gorm:"index;not null" json:"-"`
+	Original    string `gorm:"type:text" json:"original"`
+	Correction  string `gorm:"type:text" json:"correction"`
+	Explanation string `gorm:"type:text" json:"explanation"`
+	Count       int    `json:"count"`
+}
+
+// ReportSuggestion is a phrase to practice in the next session
+type ReportSuggestion struct {
+	ID       uint   `gorm:"primaryKey" json:"-"`
+	ReportID uint   `gorm:"index;not null" json:"-"`
+	Phrase   string `gorm:"type:text" json:"phrase"`
+	Reason   string `gorm:"type:text" json:"reason"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// MaterialRevision is a snapshot of a material taken on every edit.
+// Diff is the unified diff of Content against the previous revision.
+type MaterialRevision struct {
+	gorm.Model
+	MaterialID uint   `gorm:"uniqueIndex:idx_revision_material_number;not null" json:"material_id"`
+	Number     int    `gorm:"uniqueIndex:idx_revision_material_number;not null" json:"number"`
+	UserUID    string `gorm:"type:varchar(255);index" json:"-"`
+	Title      string `gorm:"type:varchar(255)" json:"title"`
+	Content    string `gorm:"type:text" json:"content,omitempty"`
+	Diff       string `gorm:"type:text" json:"diff"`
+	Inserted   int    `json:"inserted"`
+	Deleted    int    `json:"deleted"`
+	// RestoredFrom is the revision number this one restored, if any
+	RestoredFrom *int `json:"restored_from,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "gorm.io/gorm"
+
+// Chat modes. The built-in modes come from the scenario library; custom chats
+// follow one of the user's own scenarios.
+const (
+	ModeFreeTalk     = "free_talk"
+	ModeJobInterview = "job_interview"
+	ModeDebate       = "debate"
+	ModeRestaurant   = "restaurant"
+	ModeSocratic     = "socratic"
+	ModeCustom       = "custom"
+)
+
+// Scenario sets up a chat: the persona the bot plays, what the learner tries to
+// achieve and how they can tell they did. Built-in scenarios are not stored and
+// have no ID.
+type Scenario struct {
+	gorm.Model
+	UserUID         string `gorm:"type:varchar(255);index" json:"user_uid"`
+	Mode            string `gorm:"type:varchar(32)" json:"mode"`
+	Title           string `gorm:"type:varchar(255)" json:"title" validate:"required,max=255"`
+	Description     string `gorm:"type:text" json:"description"`
+	Persona         string `gorm:"type:text" json:"persona" validate:"required"`
+	Goals           string `gorm:"type:text" json:"goals"`
+	SuccessCriteria string `gorm:"type:text" json:"success_criteria"`
+	// UsesMaterial has the bot discuss the chat's material instead of only
+	// taking its topic
+	UsesMaterial bool `json:"uses_material"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+type Tag struct {
+	gorm.Model
+	UserUID   string     `gorm:"type:varchar(255);uniqueIndex:idx_tag_user_name" json:"user_uid"`
+	Name      string     `gorm:"type:varchar(100);uniqueIndex:idx_tag_user_name" json:"name" validate:"required,max=100"`
+	Color     string     `gorm:"type:varchar(16)" json:"color"`
+	Materials []Material `gorm:"many2many:material_tags;" json:"-"`
+}
+
+// Collection is a user-defined folder; ParentID nests it under another collection
+type Collection struct {
+	gorm.Model
+	UserUID   string       `gorm:"type:varchar(255);index" json:"user_uid"`
+	Name      string       `gorm:"type:varchar(255)" json:"name" validate:"required,max=255"`
+	ParentID  *uint        `gorm:"index" json:"parent_id"`
+	Children  []Collection `gorm:"-" json:"children,omitempty"`
+	Materials []Material   `gorm:"many2many:collection_materials;" json:"-"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// DefaultNativeLanguage is the native language of new users; most of them speak Japanese
+const DefaultNativeLanguage = "ja"
+
+// Translation caches the translation of a text from its Source language into
+// a Language, shared by all users. Key is nlp.Key of the text.
+type Translation struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	Source      string    `gorm:"type:varchar(8);default:en;uniqueIndex:idx_translation_source_key" json:"source"`
+	Language    string    `gorm:"type:varchar(16);uniqueIndex:idx_translation_source_key" json:"language"`
+	Key         string    `gorm:"type:char(40);uniqueIndex:idx_translation_source_key" json:"-"`
+	Text        string    `gorm:"type:text" json:"text"`
+	Translation string    `gorm:"type:text" json:"translation"`
+	CreatedAt   time.Time `json:"created_at"`
+}
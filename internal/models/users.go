@@ -1,16 +1,16 @@
 package models
 
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
+import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	Name      string     `gorm:"type:varchar(255)" json:"name" validate:"required"`
-	UserUID   string     `gorm:"type:varchar(255)" json:"user_uid" `
-	Materials []Material `gorm:"foreignKey:UserUID;references:UserUID"`
+	Name    string `gorm:"type:varchar(255)" json:"name" validate:"required"`
+	UserUID string `gorm:"type:varchar(255)" json:"user_uid" `
+	// NativeLanguage is the ISO 639-1 code phrases and words are translated into
+	NativeLanguage string `gorm:"type:varchar(16);default:ja" json:"native_language"`
+	// TargetLanguage is the ISO 639-1 code of the language the user is learning
+	TargetLanguage string     `gorm:"type:varchar(8);default:en" json:"target_language"`
+	Materials      []Material `gorm:"foreignKey:UserUID;references:UserUID"`
 }
 
 // type Dialogue struct {
@@ -21,13 +21,3 @@ type User struct {
 // 	ResponseText string    `validate:"required"`
 // 	CreatedAt    time.Time `validate:"required"`
 // }
-
-type Progress struct {
-	gorm.Model
-	ID           int       `gorm:"primaryKey"`
-	UserUID      string    `gorm:"index" validate:"required"`
-	PhraseID     string    `gorm:"index" validate:"required"`
-	Status       string    `validate:"required"`
-	LastReviewed time.Time `validate:"required"`
-	Phrase       Phrase
-}
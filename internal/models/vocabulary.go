@@ -0,0 +1,37 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	VocabularyNew      = "new"
+	VocabularyLearning = "learning"
+	VocabularyKnown    = "known"
+
+	VocabularyKindPhrase = "phrase"
+	VocabularyKindWord   = "word"
+)
+
+// VocabularyEntry is one phrase or word in a user's notebook, shared by every
+// material in its language it was found in. Key identifies the normalized text.
+type VocabularyEntry struct {
+	gorm.Model
+	UserUID string `gorm:"type:varchar(255);uniqueIndex:idx_vocabulary_user_language_key;not null" json:"-"`
+	Kind    string `gorm:"type:varchar(16);uniqueIndex:idx_vocabulary_user_language_key;not null" json:"kind"`
+	// Language is the ISO 639-1 code of the materials the entry comes from
+	Language string             `gorm:"type:varchar(8);uniqueIndex:idx_vocabulary_user_language_key;default:en;not null" json:"language"`
+	Key      string             `gorm:"type:varchar(40);uniqueIndex:idx_vocabulary_user_language_key;not null" json:"-"`
+	Text     string             `gorm:"type:text" json:"text"`
+	Status   string             `gorm:"type:varchar(16);default:new;index" json:"status"`
+	Note     string             `gorm:"type:text" json:"note"`
+	Sources  []VocabularySource `gorm:"foreignKey:EntryID" json:"sources"`
+}
+
+// VocabularySource links an entry to the phrase or word of a material it came from
+type VocabularySource struct {
+	ID         uint `gorm:"primaryKey" json:"-"`
+	EntryID    uint `gorm:"uniqueIndex:idx_vocabulary_source;not null" json:"-"`
+	ItemID     uint `gorm:"uniqueIndex:idx_vocabulary_source;not null" json:"item_id"`
+	MaterialID uint `gorm:"index" json:"material_id"`
+	// MaterialTitle is read from the joined material when listing
+	MaterialTitle string `gorm:"->;-:migration" json:"material_title"`
+}
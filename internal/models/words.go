@@ -9,5 +9,14 @@ type Word struct {
 	Text       string
 	Importance string
 	Level      string
+	// Position orders the words of a material; learners can reorder them
+	Position int  `gorm:"index"`
+	Starred  bool `gorm:"index"`
+	// UserEdited words were written or changed by the learner and survive regeneration
+	UserEdited bool
+	// Definition and Pronunciation come from the offline dictionary
+	Definition    string `gorm:"type:text"`
+	Pronunciation string
+	// Translation is into the reader's native language, filled in on listing
+	Translation string `gorm:"-"`
 }
-
@@ -0,0 +1,233 @@
+package nlp
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yomek33/talki/internal/language"
+)
+
+// Tokenize splits text into lowercased words. Apostrophes and hyphens inside a
+// word are kept, numbers and punctuation are dropped. Text without spaces
+// between words is split where the script changes, with every Chinese
+// character (kanji) a token of its own.
+func Tokenize(text string) []string {
+	spans := TokenizeSpans(text)
+	tokens := make([]string, len(spans))
+	for i, span := range spans {
+		tokens[i] = span.Text
+	}
+	return tokens
+}
+
+// Span is a token of a text with its offsets in runes, End being exclusive
+type Span struct {
+	Start int
+	End   int
+	// Text is the lowercased token
+	Text string
+}
+
+// TokenizeSpans tokenizes text like Tokenize and records where each token is
+func TokenizeSpans(text string) []Span {
+	var spans []Span
+	var b strings.Builder
+	start, pos := 0, 0
+	current := scriptOther
+	flush := func() {
+		token := b.String()
+		// apostrophes and hyphens only count inside a word
+		trimmed := strings.TrimRight(token, "'-")
+		if trimmed != "" {
+			spans = append(spans, Span{Start: start, End: pos - (len([]rune(token)) - len([]rune(trimmed))), Text: trimmed})
+		}
+		b.Reset()
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			script := scriptOf(r)
+			if b.Len() > 0 && (script != current || script == scriptHan) {
+				flush()
+			}
+			if b.Len() == 0 {
+				start = pos
+			}
+			current = script
+			b.WriteRune(unicode.ToLower(r))
+		case (r == '\'' || r == '’' || r == '-') && b.Len() > 0:
+			if r == '’' {
+				r = '\''
+			}
+			b.WriteRune(r)
+		default:
+			flush()
+		}
+		pos++
+	}
+	flush()
+	return spans
+}
+
+const (
+	scriptOther = iota
+	scriptHan
+	scriptHiragana
+	scriptKatakana
+)
+
+func scriptOf(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return scriptHan
+	case unicode.Is(unicode.Hiragana, r):
+		return scriptHiragana
+	case unicode.Is(unicode.Katakana, r) || r == 'ー':
+		return scriptKatakana
+	}
+	return scriptOther
+}
+
+// irregular maps inflected forms the suffix rules get wrong to their lemma
+var irregular = map[string]string{
+	"am": "be", "is": "be", "are": "be", "was": "be", "were": "be", "been": "be", "being": "be",
+	"has": "have", "had": "have", "having": "have",
+	"does": "do", "did": "do", "done": "do", "doing": "do",
+	"goes": "go", "went": "go", "gone": "go",
+	"made": "make", "said": "say", "says": "say", "got": "get", "gotten": "get",
+	"took": "take", "taken": "take", "came": "come", "saw": "see", "seen": "see",
+	"knew": "know", "known": "know", "thought": "think", "told": "tell",
+	"found": "find", "gave": "give", "given": "give", "felt": "feel", "left": "leave",
+	"kept": "keep", "began": "begin", "begun": "begin", "brought": "bring",
+	"bought": "buy", "wrote": "write", "written": "write", "ran": "run", "sat": "sit",
+	"stood": "stand", "heard": "hear", "meant": "mean", "met": "meet", "paid": "pay",
+	"spoke": "speak", "spoken": "speak", "ate": "eat", "eaten": "eat", "fell": "fall",
+	"fallen": "fall", "held": "hold", "led": "lead", "lost": "lose", "sent": "send",
+	"spent": "spend", "built": "build", "understood": "understand", "won": "win",
+	"children": "child", "men": "man", "women": "woman", "people": "person",
+	"feet": "foot", "teeth": "tooth", "mice": "mouse", "lives": "life", "wives": "wife",
+	"knives": "knife", "better": "good", "best": "good", "worse": "bad", "worst": "bad",
+	"this": "this", "his": "his", "its": "its", "us": "us", "news": "news",
+}
+
+var contractions = map[string]string{
+	"can't": "can", "won't": "will", "shan't": "shall", "i'm": "i",
+}
+
+// Lemma reduces an English word to its dictionary form with a small set of
+// irregular forms and suffix rules. It is deliberately simple: it only has to
+// make "studies", "studied" and "studying" count as the same word.
+func Lemma(word string) string {
+	word = strings.ToLower(strings.ReplaceAll(word, "’", "'"))
+	if lemma, ok := contractions[word]; ok {
+		return lemma
+	}
+	for _, suffix := range []string{"n't", "'s", "'re", "'ve", "'ll", "'d", "'m", "'"} {
+		if strings.HasSuffix(word, suffix) && len(word) > len(suffix) {
+			word = strings.TrimSuffix(word, suffix)
+			break
+		}
+	}
+	if lemma, ok := irregular[word]; ok {
+		return lemma
+	}
+	if strings.Contains(word, "-") || len(word) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ied") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case hasAnySuffix(word, "sses", "ches", "shes", "xes", "zes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		return restoreStem(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		return restoreStem(word[:len(word)-2])
+	case strings.HasSuffix(word, "s") && !hasAnySuffix(word, "ss", "us", "is"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// restoreStem undoes the spelling changes of -ing and -ed: a doubled final
+// consonant is undoubled ("running") and a dropped e is put back ("making")
+func restoreStem(stem string) string {
+	n := len(stem)
+	if n >= 2 && stem[n-1] == stem[n-2] && !isVowel(stem[n-1]) && !strings.ContainsRune("lsz", rune(stem[n-1])) {
+		return stem[:n-1]
+	}
+	if n >= 3 && n <= 4 && !isVowel(stem[n-1]) && !strings.ContainsRune("wxy", rune(stem[n-1])) &&
+		isVowel(stem[n-2]) && !isVowel(stem[n-3]) && (n == 3 || !isVowel(stem[n-4])) {
+		return stem + "e"
+	}
+	if strings.HasSuffix(stem, "v") || strings.HasSuffix(stem, "iz") {
+		return stem + "e"
+	}
+	return stem
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
+
+func hasAnySuffix(word string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// LemmaIn returns the lemma of word in the language of code. Only English
+// words are lemmatized; words of other languages are lowercased as they are.
+func LemmaIn(word, code string) string {
+	if language.IsEnglish(code) {
+		return Lemma(word)
+	}
+	return strings.ToLower(strings.ReplaceAll(word, "’", "'"))
+}
+
+// LemmaCounts tokenizes English text and counts how often each lemma occurs
+func LemmaCounts(text string) map[string]int {
+	return LemmaCountsIn(text, language.Default)
+}
+
+// LemmaCountsIn is LemmaCounts for text in the language of code
+func LemmaCountsIn(text, code string) map[string]int {
+	counts := map[string]int{}
+	for _, token := range Tokenize(text) {
+		counts[LemmaIn(token, code)]++
+	}
+	return counts
+}
+
+// Coverage returns the percentage of tokens whose lemma is known, rounded to
+// one decimal, and the unknown lemmas, most frequent first
+func Coverage(counts map[string]int, known map[string]bool) (float64, []string) {
+	var total, covered int
+	var unknown []string
+	for lemma, count := range counts {
+		total += count
+		if known[lemma] {
+			covered += count
+			continue
+		}
+		unknown = append(unknown, lemma)
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		if counts[unknown[i]] != counts[unknown[j]] {
+			return counts[unknown[i]] > counts[unknown[j]]
+		}
+		return unknown[i] < unknown[j]
+	})
+	if total == 0 {
+		return 0, unknown
+	}
+	return math.Round(float64(covered)*1000/float64(total)) / 10, unknown
+}
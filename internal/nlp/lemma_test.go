@@ -0,0 +1,63 @@
+package nlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"don't", "well-known", "words", "again"}, Tokenize("Don’t — well-known words, 42 again!"))
+	assert.Equal(t, []string{"東", "京", "で", "コーヒー", "を", "飲", "んだ"}, Tokenize("東京でコーヒーを飲んだ。"))
+}
+
+func TestLemmaIn(t *testing.T) {
+	assert.Equal(t, "study", LemmaIn("Studies", "en"))
+	assert.Equal(t, "casas", LemmaIn("Casas", "es"))
+	assert.Equal(t, map[string]int{"las": 1, "casas": 2}, LemmaCountsIn("Las casas, casas.", "es"))
+}
+
+func TestLemma(t *testing.T) {
+	cases := map[string]string{
+		"studies":  "study",
+		"studied":  "study",
+		"studying": "study",
+		"running":  "run",
+		"making":   "make",
+		"writing":  "write",
+		"reading":  "read",
+		"opened":   "open",
+		"called":   "call",
+		"watches":  "watch",
+		"books":    "book",
+		"class":    "class",
+		"went":     "go",
+		"children": "child",
+		"isn't":    "be",
+		"it's":     "it",
+	}
+	for word, lemma := range cases {
+		assert.Equal(t, lemma, Lemma(word), word)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	counts := LemmaCounts("The cats ran. The cat runs and sleeps.")
+	percent, unknown := Coverage(counts, map[string]bool{"the": true, "cat": true, "and": true})
+	assert.Equal(t, 62.5, percent)
+	assert.Equal(t, []string{"run", "sleep"}, unknown)
+
+	percent, unknown = Coverage(map[string]int{}, nil)
+	assert.Equal(t, 0.0, percent)
+	assert.Empty(t, unknown)
+}
+
+func TestTokenizeSpans(t *testing.T) {
+	spans := TokenizeSpans("Café — it's rock-n-roll' ok")
+	assert.Equal(t, []Span{
+		{Start: 0, End: 4, Text: "café"},
+		{Start: 7, End: 11, Text: "it's"},
+		{Start: 12, End: 23, Text: "rock-n-roll"},
+		{Start: 25, End: 27, Text: "ok"},
+	}, spans)
+}
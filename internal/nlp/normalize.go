@@ -0,0 +1,35 @@
+// Package nlp holds the small text-processing helpers used to compare words
+// and phrases across materials.
+package nlp
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases text, collapses whitespace, unifies curly quotes and
+// trims punctuation around it, so that "Break the ice!" and "break the ice"
+// compare equal.
+func Normalize(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch r {
+		case '‘', '’':
+			return '\''
+		case '“', '”':
+			return '"'
+		}
+		return unicode.ToLower(r)
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+}
+
+// Key is a fixed-length key of the normalized text, for unique indexes
+func Key(text string) string {
+	sum := sha1.Sum([]byte(Normalize(text)))
+	return hex.EncodeToString(sum[:])
+}
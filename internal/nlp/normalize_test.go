@@ -0,0 +1,14 @@
+package nlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "break the ice", Normalize("  Break the\n ice! "))
+	assert.Equal(t, "don't", Normalize("“Don’t”"))
+	assert.Equal(t, "", Normalize("..."))
+	assert.Equal(t, Key("Break the ice"), Key("break  the ice."))
+}
@@ -0,0 +1,119 @@
+// Package readability computes classic readability formulas for English text
+// without any external service.
+package readability
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/nlp"
+)
+
+// Metrics describes how hard a text is to read
+type Metrics struct {
+	Words     int `json:"words"`
+	Sentences int `json:"sentences"`
+	Syllables int `json:"syllables"`
+	// LexicalDiversity is the number of distinct lemmas per word (type-token ratio)
+	LexicalDiversity float64 `json:"lexical_diversity"`
+	// FleschReadingEase runs from about 0 (very hard) to 100 (very easy)
+	FleschReadingEase float64 `json:"flesch_reading_ease"`
+	// FleschKincaidGrade and AutomatedReadabilityIndex are US school grades
+	FleschKincaidGrade        float64 `json:"flesch_kincaid_grade"`
+	AutomatedReadabilityIndex float64 `json:"automated_readability_index"`
+}
+
+// Analyze computes the metrics of English text. A text without words has zero metrics.
+func Analyze(text string) Metrics {
+	return AnalyzeIn(text, language.Default)
+}
+
+// AnalyzeIn computes the metrics of text in the language of code. The
+// formulas are calibrated for English, so for other languages only the
+// counts and the lexical diversity are given.
+func AnalyzeIn(text, code string) Metrics {
+	words := nlp.Tokenize(text)
+	if len(words) == 0 {
+		return Metrics{}
+	}
+
+	m := Metrics{Words: len(words), Sentences: CountSentences(text)}
+	lemmas := make(map[string]bool, len(words))
+	for _, word := range words {
+		lemmas[nlp.LemmaIn(word, code)] = true
+	}
+	m.LexicalDiversity = round(float64(len(lemmas))/float64(m.Words), 3)
+	if !language.IsEnglish(code) {
+		return m
+	}
+
+	letters := 0
+	for _, word := range words {
+		m.Syllables += CountSyllables(word)
+		for _, r := range word {
+			if unicode.IsLetter(r) {
+				letters++
+			}
+		}
+	}
+
+	wordsPerSentence := float64(m.Words) / float64(m.Sentences)
+	syllablesPerWord := float64(m.Syllables) / float64(m.Words)
+	lettersPerWord := float64(letters) / float64(m.Words)
+
+	m.FleschReadingEase = round(206.835-1.015*wordsPerSentence-84.6*syllablesPerWord, 1)
+	m.FleschKincaidGrade = round(0.39*wordsPerSentence+11.8*syllablesPerWord-15.59, 1)
+	m.AutomatedReadabilityIndex = round(4.71*lettersPerWord+0.5*wordsPerSentence-21.43, 1)
+	return m
+}
+
+// CountSentences counts the runs of sentence-ending punctuation in text, and
+// a last sentence left without one. It is at least 1 for any text with words.
+func CountSentences(text string) int {
+	count := 0
+	inSentence := false
+	for _, r := range text {
+		switch {
+		case r == '.' || r == '!' || r == '?' || r == '。':
+			if inSentence {
+				count++
+				inSentence = false
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			inSentence = true
+		}
+	}
+	if inSentence || count == 0 {
+		count++
+	}
+	return count
+}
+
+// CountSyllables estimates the syllables of an English word from its vowel
+// groups, not counting a silent final e
+func CountSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,32 @@
+package readability
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountSyllables(t *testing.T) {
+	cases := map[string]int{"cat": 1, "make": 1, "table": 2, "reading": 2, "beautiful": 3, "rhythm": 1}
+	for word, syllables := range cases {
+		assert.Equal(t, syllables, CountSyllables(word), word)
+	}
+}
+
+func TestCountSentences(t *testing.T) {
+	assert.Equal(t, 3, CountSentences("One. Two?! And three"))
+	assert.Equal(t, 1, CountSentences("no punctuation"))
+	assert.Equal(t, 1, CountSentences("..."))
+}
+
+func TestAnalyze(t *testing.T) {
+	m := Analyze("The cat sat on the mat. The cat was happy.")
+	assert.Equal(t, 10, m.Words)
+	assert.Equal(t, 2, m.Sentences)
+	assert.Equal(t, 11, m.Syllables)
+	assert.Equal(t, 0.7, m.LexicalDiversity)
+	assert.Equal(t, 108.7, m.FleschReadingEase)
+	assert.Equal(t, -0.7, m.FleschKincaidGrade)
+
+	assert.Equal(t, Metrics{}, Analyze("  42 "))
+}
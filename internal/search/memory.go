@@ -0,0 +1,189 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type docKey struct {
+	kind string
+	id   uint
+}
+
+type indexedDoc struct {
+	Document
+	length int
+}
+
+// MemoryIndex is an in-process inverted index ranked with BM25.
+// It is meant for development databases without FULLTEXT support and has to be
+// filled on start-up, since nothing is persisted.
+type MemoryIndex struct {
+	mu             sync.RWMutex
+	docs           map[docKey]*indexedDoc
+	postings       map[string]map[docKey]int
+	materialOwners map[uint]string
+	totalLength    int
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:           make(map[docKey]*indexedDoc),
+		postings:       make(map[string]map[docKey]int),
+		materialOwners: make(map[uint]string),
+	}
+}
+
+func (m *MemoryIndex) Index(docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, doc := range docs {
+		key := docKey{doc.Kind, doc.ID}
+		m.removeLocked(key)
+
+		if doc.Kind == KindMaterial {
+			m.materialOwners[doc.ID] = doc.UserUID
+		}
+		terms := Tokenize(doc.Title + " " + doc.Text)
+		for _, term := range terms {
+			if m.postings[term] == nil {
+				m.postings[term] = make(map[docKey]int)
+			}
+			m.postings[term][key]++
+		}
+		m.docs[key] = &indexedDoc{Document: doc, length: len(terms)}
+		m.totalLength += len(terms)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Remove(kind string, ids ...uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		m.removeLocked(docKey{kind, id})
+		if kind == KindMaterial {
+			delete(m.materialOwners, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) RemoveMaterial(id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, doc := range m.docs {
+		if doc.MaterialID == id || (key.kind == KindMaterial && key.id == id) {
+			m.removeLocked(key)
+		}
+	}
+	delete(m.materialOwners, id)
+	return nil
+}
+
+func (m *MemoryIndex) removeLocked(key docKey) {
+	doc, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	for _, term := range Tokenize(doc.Title + " " + doc.Text) {
+		if posting := m.postings[term]; posting != nil {
+			delete(posting, key)
+			if len(posting) == 0 {
+				delete(m.postings, term)
+			}
+		}
+	}
+	m.totalLength -= doc.length
+	delete(m.docs, key)
+}
+
+func (m *MemoryIndex) Search(UserUID string, q Query) ([]Result, error) {
+	terms := uniqueTerms(QueryTerms(q.Text))
+	if len(terms) == 0 {
+		return []Result{}, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := float64(len(m.docs))
+	avgLength := 1.0
+	if len(m.docs) > 0 {
+		avgLength = float64(m.totalLength) / n
+	}
+
+	scores := make(map[docKey]float64)
+	for _, term := range terms {
+		posting := m.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(len(posting))+0.5)/(float64(len(posting))+0.5))
+		for key, tf := range posting {
+			doc := m.docs[key]
+			if !q.WantsKind(doc.Kind) || m.ownerLocked(doc) != UserUID {
+				continue
+			}
+			freq := float64(tf)
+			norm := freq + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLength)
+			scores[key] += idf * freq * (bm25K1 + 1) / norm
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for key, score := range scores {
+		doc := m.docs[key]
+		results = append(results, Result{
+			Kind:       doc.Kind,
+			ID:         doc.ID,
+			MaterialID: doc.MaterialID,
+			ChatID:     doc.ChatID,
+			Title:      doc.Title,
+			Snippet:    Snippet(doc.Text, terms),
+			Score:      score,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit := NormalizeLimit(q.Limit); len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (m *MemoryIndex) ownerLocked(doc *indexedDoc) string {
+	if doc.UserUID != "" {
+		return doc.UserUID
+	}
+	return m.materialOwners[doc.MaterialID]
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
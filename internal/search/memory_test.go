@@ -0,0 +1,78 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryIndexSearch(t *testing.T) {
+	index := NewMemoryIndex()
+	assert.NoError(t, index.Index(
+		Document{Kind: KindMaterial, ID: 1, UserUID: "alice", Title: "Climate", Text: "Rising sea levels threaten coastal cities."},
+		Document{Kind: KindMaterial, ID: 2, UserUID: "bob", Title: "Oceans", Text: "Sea levels are rising."},
+		Document{Kind: KindPhrase, ID: 10, MaterialID: 1, Text: "rising sea levels"},
+		Document{Kind: KindMessage, ID: 20, UserUID: "alice", ChatID: 3, Text: "I live near the sea & love it"},
+	))
+
+	results, err := index.Search("alice", Query{Text: "sea levels"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, KindPhrase, results[0].Kind)
+	assert.Equal(t, "rising <mark>sea</mark> <mark>levels</mark>", results[0].Snippet)
+	for _, r := range results {
+		assert.NotEqual(t, uint(2), r.ID, "other users' documents must not match")
+	}
+
+	results, err = index.Search("alice", Query{Text: "sea", Kinds: []string{KindMessage}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "I live near the <mark>sea</mark> &amp; love it", results[0].Snippet)
+
+	assert.NoError(t, index.Remove(KindPhrase, 10))
+	results, err = index.Search("alice", Query{Text: "levels"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, KindMaterial, results[0].Kind)
+
+	assert.NoError(t, index.Index(
+		Document{Kind: KindPhrase, ID: 11, MaterialID: 1, Text: "coastal cities"},
+		Document{Kind: KindMessage, ID: 21, UserUID: "alice", MaterialID: 1, ChatID: 3, Text: "coastal towns"},
+	))
+	assert.NoError(t, index.RemoveMaterial(1))
+	results, err = index.Search("alice", Query{Text: "coastal sea"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1, "only the message of no material is left")
+	assert.Equal(t, uint(20), results[0].ID)
+}
+
+func TestSnippetWindow(t *testing.T) {
+	text := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. " +
+		"Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor."
+	snippet := Snippet(text, []string{"commodo"})
+	assert.Contains(t, snippet, "<mark>commodo</mark>")
+	assert.True(t, len(snippet) < len(text))
+	assert.Equal(t, "…", snippet[:len("…")])
+}
+
+func TestMemoryIndexSearchUnspaced(t *testing.T) {
+	index := NewMemoryIndex()
+	assert.NoError(t, index.Index(
+		Document{Kind: KindMaterial, ID: 1, UserUID: "alice", Title: "旅行", Text: "東京でコーヒーを飲んだ。"},
+		Document{Kind: KindMaterial, ID: 2, UserUID: "alice", Title: "京都", Text: "京都の東にある寺。"},
+	))
+
+	results, err := index.Search("alice", Query{Text: "東京"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1, "the characters of a word apart must not match it")
+	assert.Equal(t, uint(1), results[0].ID)
+	assert.Equal(t, "<mark>東京</mark>でコーヒーを飲んだ。", results[0].Snippet)
+
+	results, err = index.Search("alice", Query{Text: "コーヒー"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	results, err = index.Search("alice", Query{Text: "東"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+}
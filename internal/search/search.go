@@ -0,0 +1,164 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	KindMaterial = "material"
+	KindPhrase   = "phrase"
+	KindWord     = "word"
+	KindMessage  = "message"
+
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Kinds lists every searchable document kind
+var Kinds = []string{KindMaterial, KindPhrase, KindWord, KindMessage}
+
+// Document is one searchable unit. UserUID may be left empty for documents that
+// belong to a material (phrases, words); the owner is then taken from the material.
+type Document struct {
+	Kind       string
+	ID         uint
+	UserUID    string
+	MaterialID uint
+	ChatID     uint
+	Title      string
+	Text       string
+}
+
+// Result is a ranked search hit with a highlighted snippet
+type Result struct {
+	Kind       string  `json:"kind"`
+	ID         uint    `json:"id"`
+	MaterialID uint    `json:"material_id,omitempty"`
+	ChatID     uint    `json:"chat_id,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Snippet    string  `json:"snippet"`
+	Score      float64 `json:"score"`
+}
+
+type Query struct {
+	Text  string
+	Kinds []string
+	Limit int
+}
+
+// Index is implemented by every search backend. Backends that read straight from
+// the database can treat Index and Remove as no-ops.
+type Index interface {
+	Index(docs ...Document) error
+	Remove(kind string, ids ...uint) error
+	// RemoveMaterial removes a material and every document that belongs to it
+	RemoveMaterial(id uint) error
+	Search(UserUID string, q Query) ([]Result, error)
+}
+
+// Tokenize lowercases text and splits it into letter/digit runs. Chinese,
+// Japanese and Korean are written without spaces between words, so their
+// runs give every character and every pair of neighbouring characters
+// instead, which lets searches match inside a sentence.
+func Tokenize(text string) []string {
+	return tokenTexts(text, tokenSpans(text, false))
+}
+
+// QueryTerms tokenizes a query like Tokenize, except that Chinese, Japanese
+// and Korean runs longer than a character give only their pairs, so that
+// they match where the run appears rather than wherever its characters do
+func QueryTerms(text string) []string {
+	return tokenTexts(text, tokenSpans(text, true))
+}
+
+func tokenTexts(text string, spans [][2]int) []string {
+	tokens := make([]string, len(spans))
+	for i, span := range spans {
+		tokens[i] = strings.ToLower(text[span[0]:span[1]])
+	}
+	return tokens
+}
+
+// tokenSpans returns the byte offsets of the tokens of text, in order of
+// their start
+func tokenSpans(text string, query bool) [][2]int {
+	var spans [][2]int
+	start := -1
+	endWord := func(end int) {
+		if start >= 0 {
+			spans = append(spans, [2]int{start, end})
+			start = -1
+		}
+	}
+	// run holds the offsets of the characters of the current unspaced run
+	var run []int
+	endRun := func(end int) {
+		for i, offset := range run {
+			next, pairEnd := end, -1
+			if i+1 < len(run) {
+				next = run[i+1]
+				pairEnd = end
+				if i+2 < len(run) {
+					pairEnd = run[i+2]
+				}
+			}
+			if !query || len(run) == 1 {
+				spans = append(spans, [2]int{offset, next})
+			}
+			if pairEnd >= 0 {
+				spans = append(spans, [2]int{offset, pairEnd})
+			}
+		}
+		run = run[:0]
+	}
+
+	for i, r := range text {
+		switch {
+		case isUnspaced(r):
+			endWord(i)
+			run = append(run, i)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			endRun(i)
+			if start < 0 {
+				start = i
+			}
+		default:
+			endRun(i)
+			endWord(i)
+		}
+	}
+	endRun(len(text))
+	endWord(len(text))
+	return spans
+}
+
+// isUnspaced reports whether r belongs to a script written without spaces
+// between words
+func isUnspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == 'ー'
+}
+
+// NormalizeLimit clamps a requested result count to the supported range
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// WantsKind reports whether a query restricted to kinds includes kind
+func (q Query) WantsKind(kind string) bool {
+	if len(q.Kinds) == 0 {
+		return true
+	}
+	for _, k := range q.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
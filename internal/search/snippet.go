@@ -0,0 +1,82 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	markOpen     = "<mark>"
+	markClose    = "</mark>"
+	snippetWidth = 160
+)
+
+// Snippet returns a window of text around the first query term with every
+// matching word wrapped in <mark>. The surrounding text is HTML escaped.
+func Snippet(text string, terms []string) string {
+	if text == "" {
+		return ""
+	}
+	matches := matchSpans(text, terms)
+	start, end := 0, len(text)
+	if len(matches) > 0 {
+		start = matches[0][0] - snippetWidth/3
+	}
+	if start < 0 {
+		start = 0
+	}
+	if len(text)-start > snippetWidth {
+		end = start + snippetWidth
+	}
+	start, end = alignRune(text, start), alignRune(text, end)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[0] < start || m[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:m[0]]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[m[0]:m[1]]))
+		b.WriteString(markClose)
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// matchSpans returns the byte offsets of the tokens of text among terms,
+// overlapping ones merged
+func matchSpans(text string, terms []string) [][2]int {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+	var matches [][2]int
+	for _, span := range tokenSpans(text, false) {
+		if !wanted[strings.ToLower(text[span[0]:span[1]])] {
+			continue
+		}
+		if last := len(matches) - 1; last >= 0 && span[0] < matches[last][1] {
+			matches[last][1] = max(matches[last][1], span[1])
+			continue
+		}
+		matches = append(matches, span)
+	}
+	return matches
+}
+
+func alignRune(text string, i int) int {
+	for i > 0 && i < len(text) && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
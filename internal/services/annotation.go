@@ -0,0 +1,72 @@
+package services
+
+import (
+	"github.com/yomek33/talki/internal/annotate"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type AnnotationService interface {
+	// Annotate segments a readable material and locates its phrases and words in it
+	Annotate(materialID uint, UserUID string) (*Annotation, error)
+}
+
+// Annotation is the reading view of a material; offsets count Unicode code
+// points of the material's content
+type Annotation struct {
+	MaterialID  uint                  `json:"material_id"`
+	Paragraphs  []annotate.Paragraph  `json:"paragraphs"`
+	Occurrences []annotate.Occurrence `json:"occurrences"`
+}
+
+type annotationService struct {
+	materialStore stores.MaterialStore
+	phraseStore   stores.PhraseStore
+	wordStore     stores.WordStore
+}
+
+func NewAnnotationService(ms stores.MaterialStore, ps stores.PhraseStore, ws stores.WordStore) AnnotationService {
+	return &annotationService{materialStore: ms, phraseStore: ps, wordStore: ws}
+}
+
+func (s *annotationService) Annotate(materialID uint, UserUID string) (*Annotation, error) {
+	material, err := s.materialStore.GetReadableMaterial(materialID, UserUID)
+	if err != nil {
+		return nil, ErrMaterialNotFound
+	}
+	items, err := materialItems(s.phraseStore, s.wordStore, materialID)
+	if err != nil {
+		return nil, err
+	}
+
+	paragraphs := annotate.Segment(material.Content, material.Language)
+	if paragraphs == nil {
+		paragraphs = []annotate.Paragraph{}
+	}
+	return &Annotation{
+		MaterialID:  material.ID,
+		Paragraphs:  paragraphs,
+		Occurrences: annotate.Match(paragraphs, items, material.Language),
+	}, nil
+}
+
+// materialItems returns the phrases and words of a material to look for in a text
+func materialItems(ps stores.PhraseStore, ws stores.WordStore, materialID uint) ([]annotate.Item, error) {
+	phrases, err := ps.GetPhrasesByMaterialID(materialID)
+	if err != nil {
+		return nil, err
+	}
+	words, err := ws.GetWordsByMaterialID(materialID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]annotate.Item, 0, len(phrases)+len(words))
+	for _, phrase := range phrases {
+		items = append(items, annotate.Item{Kind: models.VocabularyKindPhrase, ID: uint(phrase.ID), Text: phrase.Text})
+	}
+	for _, word := range words {
+		items = append(items, annotate.Item{Kind: models.VocabularyKindWord, ID: word.ID, Text: word.Text})
+	}
+	return items, nil
+}
@@ -2,7 +2,7 @@ package services
 
 import (
 	"errors"
-	"sync"
+	"fmt"
 
 	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
@@ -14,57 +14,63 @@ type ChatService interface {
 	CreateChat(chat *models.Chat) (*models.Chat, error)
 	GetChatByChatID(id uint, userUID string) (*models.Chat, error)
 	UpdateChat(chat *models.Chat) error
-	GetChatsByMaterialID(materialID uint, userUID string) ([]models.Chat, error)
+	GetChatsByMaterialID(materialID uint, userUID string, page stores.PageQuery) (*stores.Page[models.Chat], error)
 }
 
 // chatService implements the ChatService interface
 type chatService struct {
 	chatStore    stores.ChatStore
 	messageStore stores.MessageStore
-	mu           sync.RWMutex
+	scenarios    *scenarioService
+	// firstChat keeps concurrent listings from each creating a material's first chat
+	firstChat keyedMutex
 }
 
 // NewChatService creates a new instance of chatService
-func NewChatService(cs stores.ChatStore, ms stores.MessageStore) ChatService {
+func NewChatService(cs stores.ChatStore, ms stores.MessageStore, ss stores.ScenarioStore) ChatService {
 	return &chatService{
 		chatStore:    cs,
 		messageStore: ms,
+		scenarios:    &scenarioService{store: ss},
 	}
 }
 
-// CreateChat creates a new chat
+// CreateChat creates a new chat in the mode it asks for, free talk by default
 func (s *chatService) CreateChat(chat *models.Chat) (*models.Chat, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	if err := s.scenarios.ValidateMode(chat); err != nil {
+		return nil, err
+	}
 	return s.chatStore.CreateChat(chat)
 }
 
-// GetChatByMaterialID retrieves a chat by its material ID
-func (s *chatService) GetChatsByMaterialID(materialID uint, userUID string) ([]models.Chat, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	chats, err :=s.chatStore.GetChatsByMaterialID(materialID, userUID)
+// GetChatsByMaterialID lists the chats of a material, creating the first one if none exists yet
+func (s *chatService) GetChatsByMaterialID(materialID uint, userUID string, page stores.PageQuery) (*stores.Page[models.Chat], error) {
+	defer s.firstChat.Lock(fmt.Sprintf("%s/%d", userUID, materialID))()
+	chats, err := s.chatStore.ListChatsByMaterialID(materialID, userUID, page)
 	if err != nil {
-		logger.Info("error getting chats by material ID")
-		newChat := models.Chat{
-			MaterialID: materialID,
-			UserUID:    userUID,
-		}
-		_, err := s.chatStore.CreateChat(&newChat)
-		if err != nil {
-			return nil, err
-		}
-		logger.Info("created new chat")
-		chats = append(chats, newChat)
+		return nil, err
+	}
+	if chats.Total > 0 {
 		return chats, nil
 	}
+
+	logger.Info("no chats for material, creating one")
+	newChat := models.Chat{
+		MaterialID: materialID,
+		UserUID:    userUID,
+		Mode:       models.ModeFreeTalk,
+	}
+	if _, err := s.chatStore.CreateChat(&newChat); err != nil {
+		return nil, err
+	}
+	logger.Info("created new chat")
+	chats.Items = append(chats.Items, newChat)
+	chats.Total = 1
 	return chats, nil
 }
 
 // GetChatByChatID retrieves a chat by its ID
 func (s *chatService) GetChatByChatID(id uint, userUID string) (*models.Chat, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
 
 	chat, err := s.chatStore.GetChatByChatID(id, userUID)
 	if err != nil {
@@ -80,7 +86,5 @@ func (s *chatService) GetChatByChatID(id uint, userUID string) (*models.Chat, er
 
 // UpdateChat updates an existing chat
 func (s *chatService) UpdateChat(chat *models.Chat) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	return s.chatStore.UpdateChat(chat)
 }
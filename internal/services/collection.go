@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type CollectionService interface {
+	CreateCollection(collection *models.Collection) error
+	GetCollectionTree(UserUID string) ([]models.Collection, error)
+	UpdateCollection(collection *models.Collection) error
+	DeleteCollection(id uint, UserUID string) error
+	AddMaterials(collectionID uint, materialIDs []uint, UserUID string) error
+	RemoveMaterials(collectionID uint, materialIDs []uint, UserUID string) error
+	// DescendantIDs returns the collection and all collections nested below it
+	DescendantIDs(collectionID uint, UserUID string) ([]uint, error)
+}
+
+type collectionService struct {
+	store stores.CollectionStore
+}
+
+var (
+	ErrCollectionNameRequired = errors.New("collection name cannot be empty")
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrCollectionCycle        = errors.New("a collection cannot be moved inside itself")
+	ErrEmptyMaterialIDs       = errors.New("material_ids cannot be empty")
+)
+
+func NewCollectionService(cs stores.CollectionStore) CollectionService {
+	return &collectionService{store: cs}
+}
+
+func (s *collectionService) CreateCollection(collection *models.Collection) error {
+	if collection == nil {
+		return errors.New("collection cannot be nil")
+	}
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		return ErrCollectionNameRequired
+	}
+	if collection.ParentID != nil {
+		if _, err := s.store.GetCollectionByID(*collection.ParentID, collection.UserUID); err != nil {
+			return ErrCollectionNotFound
+		}
+	}
+	return s.store.CreateCollection(collection)
+}
+
+// GetCollectionTree returns the user's root collections with their children nested
+func (s *collectionService) GetCollectionTree(UserUID string) ([]models.Collection, error) {
+	collections, err := s.store.GetCollections(UserUID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]models.Collection)
+	var roots []models.Collection
+	for _, collection := range collections {
+		if collection.ParentID == nil {
+			roots = append(roots, collection)
+		} else {
+			children[*collection.ParentID] = append(children[*collection.ParentID], collection)
+		}
+	}
+
+	var attach func(nodes []models.Collection) []models.Collection
+	attach = func(nodes []models.Collection) []models.Collection {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots), nil
+}
+
+func (s *collectionService) UpdateCollection(collection *models.Collection) error {
+	if collection == nil {
+		return errors.New("collection cannot be nil")
+	}
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		return ErrCollectionNameRequired
+	}
+	if collection.ParentID != nil {
+		descendants, err := s.DescendantIDs(collection.ID, collection.UserUID)
+		if err != nil {
+			return err
+		}
+		for _, id := range descendants {
+			if id == *collection.ParentID {
+				return ErrCollectionCycle
+			}
+		}
+		if _, err := s.store.GetCollectionByID(*collection.ParentID, collection.UserUID); err != nil {
+			return ErrCollectionNotFound
+		}
+	}
+	return s.store.UpdateCollection(collection)
+}
+
+func (s *collectionService) DeleteCollection(id uint, UserUID string) error {
+	return s.store.DeleteCollection(id, UserUID)
+}
+
+func (s *collectionService) AddMaterials(collectionID uint, materialIDs []uint, UserUID string) error {
+	if len(materialIDs) == 0 {
+		return ErrEmptyMaterialIDs
+	}
+	if len(materialIDs) > maxBulkMaterials {
+		return ErrTooManyMaterials
+	}
+	return s.store.AddMaterials(collectionID, materialIDs, UserUID)
+}
+
+func (s *collectionService) RemoveMaterials(collectionID uint, materialIDs []uint, UserUID string) error {
+	if len(materialIDs) == 0 {
+		return ErrEmptyMaterialIDs
+	}
+	return s.store.RemoveMaterials(collectionID, materialIDs, UserUID)
+}
+
+func (s *collectionService) DescendantIDs(collectionID uint, UserUID string) ([]uint, error) {
+	collections, err := s.store.GetCollections(UserUID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	children := make(map[uint][]uint)
+	for _, collection := range collections {
+		if collection.ID == collectionID {
+			found = true
+		}
+		if collection.ParentID != nil {
+			children[*collection.ParentID] = append(children[*collection.ParentID], collection.ID)
+		}
+	}
+	if !found {
+		return nil, ErrCollectionNotFound
+	}
+
+	ids := []uint{collectionID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/yomek33/talki/internal/dictionary"
+)
+
+type DictionaryService interface {
+	// Lookup returns the dictionary entry of word, or of its lemma for inflected forms
+	Lookup(word string) (*dictionary.Entry, error)
+}
+
+type dictionaryService struct {
+	dictionary *dictionary.Dictionary
+}
+
+var ErrNotInDictionary = errors.New("word not found in dictionary")
+
+func NewDictionaryService(dict *dictionary.Dictionary) DictionaryService {
+	return &dictionaryService{dictionary: dict}
+}
+
+func (s *dictionaryService) Lookup(word string) (*dictionary.Entry, error) {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return nil, ErrEmptyText
+	}
+	entry, ok := s.dictionary.Lookup(word)
+	if !ok {
+		return nil, ErrNotInDictionary
+	}
+	return entry, nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/yomek33/talki/internal/embedding"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+// maxEmbeddingChars keeps material text within the embedding model's input limit
+const maxEmbeddingChars = 8000
+
+type EmbeddingService interface {
+	EmbedMaterial(ctx context.Context, materialID uint, UserUID string) error
+	RelatedMaterials(ctx context.Context, materialID uint, UserUID string, limit int) ([]RelatedMaterial, error)
+	SemanticSearch(ctx context.Context, UserUID, query string, kinds []string, limit int) ([]SemanticResult, error)
+}
+
+type RelatedMaterial struct {
+	Material models.Material `json:"material"`
+	Score    float64         `json:"score"`
+}
+
+type SemanticResult struct {
+	embedding.Match
+	Text string `json:"text"`
+}
+
+type embeddingService struct {
+	provider      embedding.Provider
+	store         embedding.VectorStore
+	materialStore stores.MaterialStore
+	phraseStore   stores.PhraseStore
+}
+
+func NewEmbeddingService(p embedding.Provider, vs embedding.VectorStore, ms stores.MaterialStore, ps stores.PhraseStore) EmbeddingService {
+	return &embeddingService{
+		provider:      p,
+		store:         vs,
+		materialStore: ms,
+		phraseStore:   ps,
+	}
+}
+
+// EmbedMaterial (re)computes the vectors of a material and all of its phrases
+func (s *embeddingService) EmbedMaterial(ctx context.Context, materialID uint, UserUID string) error {
+	material, err := s.materialStore.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch material: %w", err)
+	}
+	phrases, err := s.phraseStore.GetPhrasesByMaterialID(materialID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch phrases: %w", err)
+	}
+
+	texts := []string{materialEmbeddingText(material)}
+	vectors := []embedding.Vector{{Kind: embedding.KindMaterial, ID: material.ID, UserUID: UserUID, MaterialID: material.ID}}
+	for _, phrase := range phrases {
+		texts = append(texts, phrase.Text)
+		vectors = append(vectors, embedding.Vector{Kind: embedding.KindPhrase, ID: uint(phrase.ID), UserUID: UserUID, MaterialID: materialID})
+	}
+
+	values, err := s.provider.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed material: %w", err)
+	}
+	for i := range vectors {
+		vectors[i].Values = values[i]
+	}
+
+	if err := s.store.Upsert(s.provider.EmbeddingModelName(), vectors...); err != nil {
+		return fmt.Errorf("failed to store embeddings: %w", err)
+	}
+	logger.Infof("Embedded material, MaterialID: %v, PhraseCount: %v", materialID, len(phrases))
+	return nil
+}
+
+// RelatedMaterials returns the user's materials closest to the given one.
+// Materials embedded before the pipeline ran are embedded on demand.
+func (s *embeddingService) RelatedMaterials(ctx context.Context, materialID uint, UserUID string, limit int) ([]RelatedMaterial, error) {
+	model := s.provider.EmbeddingModelName()
+	vector, err := s.store.GetVector(model, embedding.KindMaterial, materialID)
+	if errors.Is(err, embedding.ErrVectorNotFound) {
+		if err := s.EmbedMaterial(ctx, materialID, UserUID); err != nil {
+			return nil, err
+		}
+		vector, err = s.store.GetVector(model, embedding.KindMaterial, materialID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if vector.UserUID != UserUID {
+		return nil, ErrMaterialNotFound
+	}
+
+	matches, err := s.store.Nearest(model, UserUID, vector.Values, limit, embedding.Filter{
+		Kinds:             []string{embedding.KindMaterial},
+		ExcludeMaterialID: materialID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.ID)
+	}
+	materials, err := s.materialStore.GetMaterialsByIDs(ids, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Material, len(materials))
+	for _, material := range materials {
+		byID[material.ID] = material
+	}
+
+	related := make([]RelatedMaterial, 0, len(matches))
+	for _, match := range matches {
+		if material, ok := byID[match.ID]; ok {
+			related = append(related, RelatedMaterial{Material: material, Score: match.Score})
+		}
+	}
+	return related, nil
+}
+
+func (s *embeddingService) SemanticSearch(ctx context.Context, UserUID, query string, kinds []string, limit int) ([]SemanticResult, error) {
+	values, err := s.provider.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	matches, err := s.store.Nearest(s.provider.EmbeddingModelName(), UserUID, values[0], limit, embedding.Filter{Kinds: kinds})
+	if err != nil {
+		return nil, err
+	}
+
+	var materialIDs, phraseIDs []uint
+	for _, match := range matches {
+		if match.Kind == embedding.KindPhrase {
+			phraseIDs = append(phraseIDs, match.ID)
+		} else {
+			materialIDs = append(materialIDs, match.ID)
+		}
+	}
+	materials, err := s.materialStore.GetMaterialsByIDs(materialIDs, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	phrases, err := s.phraseStore.GetPhrasesByIDs(phraseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make(map[string]string, len(materials)+len(phrases))
+	for _, material := range materials {
+		texts[fmt.Sprintf("%s:%d", embedding.KindMaterial, material.ID)] = material.Title
+	}
+	for _, phrase := range phrases {
+		texts[fmt.Sprintf("%s:%d", embedding.KindPhrase, phrase.ID)] = phrase.Text
+	}
+
+	results := make([]SemanticResult, 0, len(matches))
+	for _, match := range matches {
+		text, ok := texts[fmt.Sprintf("%s:%d", match.Kind, match.ID)]
+		if !ok {
+			continue
+		}
+		results = append(results, SemanticResult{Match: match, Text: text})
+	}
+	return results, nil
+}
+
+// RemovePhrases drops the vectors of deleted phrases. Failures are logged, as
+// results whose rows are gone are skipped anyway.
+func (s *embeddingService) RemovePhrases(ids []uint) {
+	if s == nil || len(ids) == 0 {
+		return
+	}
+	if err := s.store.Delete(embedding.KindPhrase, ids...); err != nil {
+		logger.Errorf("Failed to delete phrase embeddings: %v, PhraseCount: %v", err, len(ids))
+	}
+}
+
+// RemoveMaterial drops the vectors of a material and its phrases. It looks the
+// phrases up, so call it before they are trashed along with the material.
+func (s *embeddingService) RemoveMaterial(id uint) {
+	if s == nil {
+		return
+	}
+	if err := s.store.Delete(embedding.KindMaterial, id); err != nil {
+		logger.Errorf("Failed to delete material embedding: %v, MaterialID: %v", err, id)
+	}
+	phrases, err := s.phraseStore.GetPhrasesByMaterialID(id)
+	if err != nil {
+		logger.Errorf("Failed to fetch phrases to unembed: %v, MaterialID: %v", err, id)
+		return
+	}
+	ids := make([]uint, 0, len(phrases))
+	for _, phrase := range phrases {
+		ids = append(ids, uint(phrase.ID))
+	}
+	s.RemovePhrases(ids)
+}
+
+func materialEmbeddingText(material *models.Material) string {
+	text := material.Title + "\n" + material.Content
+	if len(text) > maxEmbeddingChars {
+		cut := maxEmbeddingChars
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return text
+}
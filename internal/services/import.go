@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yomek33/talki/internal/kindle"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+type ImportService interface {
+	ImportKindleClippings(r io.Reader, UserUID string, asPhrases bool) (*ImportResult, error)
+}
+
+// ImportResult summarises an import, one entry per book
+type ImportResult struct {
+	Books []ImportedBook `json:"books"`
+}
+
+type ImportedBook struct {
+	MaterialID uint   `json:"material_id"`
+	Title      string `json:"title"`
+	Created    bool   `json:"created"`
+	Added      int    `json:"added"`
+	Skipped    int    `json:"skipped"`
+	// NeedsProcessing is set when new highlights should go through phrase generation
+	NeedsProcessing bool `json:"needs_processing"`
+}
+
+type importService struct {
+	materialStore stores.MaterialStore
+	// materialService records a revision when new highlights change a material
+	materialService *materialService
+	phraseStore     stores.PhraseStore
+	highlightStore  stores.HighlightStore
+	search          *searchService
+}
+
+var ErrNoHighlights = errors.New("no highlights found in clippings file")
+
+func NewImportService(ms stores.MaterialStore, ps stores.PhraseStore, hs stores.HighlightStore) ImportService {
+	return &importService{
+		materialStore:  ms,
+		phraseStore:    ps,
+		highlightStore: hs,
+	}
+}
+
+// ImportKindleClippings turns each book of a "My Clippings.txt" file into a material.
+// Highlights already imported for the same book are skipped. When asPhrases is set the
+// highlights are stored as phrases directly instead of waiting for phrase generation.
+// New highlights for a book whose phrases are being generated fail with ErrMaterialBusy.
+func (s *importService) ImportKindleClippings(r io.Reader, UserUID string, asPhrases bool) (*ImportResult, error) {
+	clippings, err := kindle.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse clippings: %w", err)
+	}
+	books := kindle.GroupByBook(clippings)
+	if len(books) == 0 {
+		return nil, ErrNoHighlights
+	}
+
+	result := &ImportResult{}
+	for _, book := range books {
+		imported, err := s.importBook(book, UserUID, asPhrases)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %q: %w", book.Title, err)
+		}
+		result.Books = append(result.Books, *imported)
+	}
+	return result, nil
+}
+
+func (s *importService) importBook(book kindle.Book, UserUID string, asPhrases bool) (*ImportedBook, error) {
+	imported := &ImportedBook{Title: book.Title}
+
+	material, err := s.materialStore.GetMaterialBySource(book.Title, models.SourceKindle, UserUID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	if material != nil {
+		if existing, err = s.highlightStore.GetHighlightHashes(material.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	var fresh []kindle.Clipping
+	for _, clipping := range book.Highlights {
+		if existing[kindle.Hash(clipping.Text)] {
+			imported.Skipped++
+			continue
+		}
+		fresh = append(fresh, clipping)
+	}
+	imported.Added = len(fresh)
+
+	status := models.StatusProcessing
+	if asPhrases {
+		status = models.StatusCompleted
+	}
+
+	if material == nil {
+		material = &models.Material{
+			UserUID: UserUID,
+			Title:   book.Title,
+			Author:  book.Author,
+			Source:  models.SourceKindle,
+			Content: joinHighlights(fresh),
+			Status:  status,
+		}
+		material.Language = language.Detect(material.Content)
+		if _, err := s.materialStore.CreateMaterial(material); err != nil {
+			return nil, err
+		}
+		s.search.IndexMaterial(material)
+		imported.Created = true
+	} else if len(fresh) > 0 {
+		// new highlights are processed like an edit, which waits for other work
+		// on the phrases; a retry skips the books imported before this one
+		if !asPhrases {
+			if err := s.materialService.ClaimProcessing(material.ID, UserUID); err != nil {
+				return nil, err
+			}
+		}
+		material.Content = strings.TrimSpace(material.Content + "\n\n" + joinHighlights(fresh))
+		if err := s.materialService.UpdateMaterial(material.ID, material); err != nil {
+			if !asPhrases {
+				s.materialStore.UpdateMaterialStatus(material.ID, material.Status)
+			}
+			return nil, err
+		}
+	}
+	imported.MaterialID = material.ID
+
+	if len(fresh) == 0 {
+		return imported, nil
+	}
+
+	highlights := make([]models.Highlight, 0, len(fresh))
+	for _, clipping := range fresh {
+		highlights = append(highlights, models.Highlight{
+			MaterialID: material.ID,
+			UserUID:    UserUID,
+			Hash:       kindle.Hash(clipping.Text),
+			Text:       clipping.Text,
+			Location:   clipping.Location,
+			AddedAt:    clipping.AddedAt,
+		})
+	}
+	if err := s.highlightStore.CreateHighlights(highlights); err != nil {
+		return nil, err
+	}
+
+	if !asPhrases {
+		imported.NeedsProcessing = true
+		return imported, nil
+	}
+
+	phrases := make([]models.Phrase, 0, len(fresh))
+	for _, clipping := range fresh {
+		phrase := models.Phrase{
+			MaterialID: material.ID,
+			Text:       clipping.Text,
+			Importance: determineImportance(clipping.Text, material.Language),
+		}
+		if err := s.phraseStore.CreatePhrase(&phrase); err != nil {
+			return nil, fmt.Errorf("failed to store phrase: %w", err)
+		}
+		phrases = append(phrases, phrase)
+	}
+	s.search.IndexPhrases(phrases)
+	logger.Infof("Imported %d highlights as phrases, MaterialID: %v", len(fresh), material.ID)
+	return imported, nil
+}
+
+func joinHighlights(clippings []kindle.Clipping) string {
+	texts := make([]string, 0, len(clippings))
+	for _, clipping := range clippings {
+		texts = append(texts, clipping.Text)
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yomek33/talki/internal/gemini"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+const (
+	maxGeneratedPhrases     = 50
+	phraseGenerationTimeout = 2 * time.Minute
+	adaptationTimeout       = 3 * time.Minute
+)
+
+var (
+	ErrJobNotFound       = errors.New("job not found")
+	ErrInvalidCount      = errors.New("count must be between 1 and 50")
+	ErrInvalidLevel      = errors.New("level must be beginner, intermediate or advanced")
+	ErrInvalidFocus      = errors.New("focus must be general, idioms, collocations or business")
+	ErrInvalidAdaptLevel = errors.New("level must be on the scale of the material's language, such as B1 or N3")
+	ErrEmptyContent      = errors.New("material has no content to adapt")
+	ErrMaterialBusy      = errors.New("phrases of this material are still being generated")
+	validPhraseLevels    = map[string]bool{"": true, "beginner": true, "intermediate": true, "advanced": true}
+	validPhraseFocuses   = map[string]bool{"": true, "general": true, "idioms": true, "collocations": true, "business": true}
+)
+
+type JobService interface {
+	GetJob(id uint, UserUID string) (*models.Job, error)
+	// StartPhraseGeneration validates req and generates phrases for the material in the background
+	StartPhraseGeneration(materialID uint, UserUID string, req PhraseGenerationRequest) (*models.Job, error)
+	// StartAdaptation rewrites the material at a CEFR level in the background. The
+	// rewrite is stored as a child material, whose ID the job reports as ResultID.
+	StartAdaptation(materialID uint, UserUID, level string) (*models.Job, error)
+}
+
+// PhraseGenerationRequest holds the options of an on-demand phrase generation
+type PhraseGenerationRequest struct {
+	Count int    `json:"count"`
+	Level string `json:"level"`
+	Focus string `json:"focus"`
+	// Mode is GenerateModeAppend (default) or GenerateModeReplace
+	Mode string `json:"mode"`
+}
+
+type jobService struct {
+	store           stores.JobStore
+	materialStore   stores.MaterialStore
+	materialService *materialService
+	phraseService   *phraseService
+	embedding       *embeddingService
+}
+
+func NewJobService(js stores.JobStore, ms stores.MaterialStore) JobService {
+	return &jobService{store: js, materialStore: ms}
+}
+
+func (s *jobService) GetJob(id uint, UserUID string) (*models.Job, error) {
+	job, err := s.store.GetJob(id, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+func (s *jobService) StartPhraseGeneration(materialID uint, UserUID string, req PhraseGenerationRequest) (*models.Job, error) {
+	if req.Count == 0 {
+		req.Count = gemini.DefaultPhraseCount
+	}
+	if req.Count < 1 || req.Count > maxGeneratedPhrases {
+		return nil, ErrInvalidCount
+	}
+	if !validPhraseLevels[req.Level] {
+		return nil, ErrInvalidLevel
+	}
+	if !validPhraseFocuses[req.Focus] {
+		return nil, ErrInvalidFocus
+	}
+	if req.Mode == "" {
+		req.Mode = GenerateModeAppend
+	}
+	if req.Mode != GenerateModeAppend && req.Mode != GenerateModeReplace {
+		return nil, ErrInvalidGenerateMode
+	}
+	material, err := s.materialStore.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return nil, ErrMaterialNotFound
+	}
+	// claimed before the job is queued so that a second request is turned away
+	if err := claimProcessing(s.materialStore, materialID, UserUID); err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		UserUID:    UserUID,
+		MaterialID: materialID,
+		Kind:       models.JobKindGeneratePhrases,
+		Status:     models.JobQueued,
+	}
+	if err := s.store.CreateJob(job); err != nil {
+		s.materialStore.UpdateMaterialStatus(materialID, material.Status)
+		return nil, err
+	}
+
+	queued := *job
+	go s.runPhraseGeneration(&queued, req)
+	return job, nil
+}
+
+func (s *jobService) runPhraseGeneration(job *models.Job, req PhraseGenerationRequest) {
+	started := time.Now()
+	job.Status = models.JobRunning
+	job.StartedAt = &started
+	s.saveJob(job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), phraseGenerationTimeout)
+	defer cancel()
+
+	opts := gemini.PhraseOptions{Count: req.Count, Level: req.Level, Focus: req.Focus}
+	if req.Focus == "general" {
+		opts.Focus = ""
+	}
+	phrases, err := s.phraseService.GeneratePhrasesWithOptions(ctx, job.MaterialID, job.UserUID, opts)
+	if err == nil {
+		job.Added, job.Removed, err = s.phraseService.ApplyGeneratedPhrases(job.MaterialID, job.UserUID, phrases, req.Mode)
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		logger.Errorf("Phrase generation job failed: %v, JobID: %v, MaterialID: %v", err, job.ID, job.MaterialID)
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		s.saveJob(job)
+		s.materialStore.UpdateMaterialStatus(job.MaterialID, models.StatusFailed)
+		return
+	}
+
+	job.Status = models.JobCompleted
+	s.saveJob(job)
+	s.materialStore.UpdateMaterialStatus(job.MaterialID, models.StatusCompleted)
+	logger.Infof("Phrase generation job completed, JobID: %v, MaterialID: %v, Added: %v, Removed: %v", job.ID, job.MaterialID, job.Added, job.Removed)
+
+	if s.embedding != nil && job.Added > 0 {
+		if err := s.embedding.EmbedMaterial(ctx, job.MaterialID, job.UserUID); err != nil {
+			logger.Errorf("Failed to embed material: %v, MaterialID: %v", err, job.MaterialID)
+		}
+	}
+}
+
+func (s *jobService) StartAdaptation(materialID uint, UserUID, level string) (*models.Job, error) {
+	material, err := s.materialStore.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return nil, ErrMaterialNotFound
+	}
+	lang := language.Of(material.Language)
+	level = lang.NormalizeLevel(level)
+	if !lang.IsLevel(level) {
+		return nil, ErrInvalidAdaptLevel
+	}
+	if strings.TrimSpace(material.Content) == "" {
+		return nil, ErrEmptyContent
+	}
+
+	job := &models.Job{
+		UserUID:    UserUID,
+		MaterialID: materialID,
+		Kind:       models.JobKindAdaptMaterial,
+		Status:     models.JobQueued,
+	}
+	if err := s.store.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	queued := *job
+	go s.runAdaptation(&queued, material, level)
+	return job, nil
+}
+
+func (s *jobService) runAdaptation(job *models.Job, parent *models.Material, level string) {
+	started := time.Now()
+	job.Status = models.JobRunning
+	job.StartedAt = &started
+	s.saveJob(job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), adaptationTimeout)
+	defer cancel()
+
+	child, err := s.adapt(ctx, parent, level)
+	if child != nil {
+		job.ResultID = &child.ID
+	}
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		logger.Errorf("Adaptation job failed: %v, JobID: %v, MaterialID: %v", err, job.ID, job.MaterialID)
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		s.saveJob(job)
+		if child != nil {
+			s.materialStore.UpdateMaterialStatus(child.ID, models.StatusFailed)
+		}
+		return
+	}
+
+	job.Status = models.JobCompleted
+	job.Added = 1
+	s.saveJob(job)
+	logger.Infof("Adaptation job completed, JobID: %v, MaterialID: %v, ChildID: %v", job.ID, job.MaterialID, child.ID)
+
+	if s.embedding != nil {
+		if err := s.embedding.EmbedMaterial(ctx, child.ID, job.UserUID); err != nil {
+			logger.Errorf("Failed to embed material: %v, MaterialID: %v", err, child.ID)
+		}
+	}
+}
+
+// adapt stores the rewrite of parent as a new material and extracts its
+// phrases. The child is returned once stored, even if extraction fails.
+func (s *jobService) adapt(ctx context.Context, parent *models.Material, level string) (*models.Material, error) {
+	if s.phraseService.GeminiClient == nil {
+		return nil, errors.New("GeminiClient is nil")
+	}
+	lang := language.Of(parent.Language)
+	content, err := s.phraseService.GeminiClient.AdaptText(ctx, parent.Content, lang.Name, lang.Describe(level))
+	if err != nil {
+		return nil, err
+	}
+
+	parentID := parent.ID
+	child := &models.Material{
+		UserUID:      parent.UserUID,
+		Title:        fmt.Sprintf("%s (%s)", parent.Title, level),
+		Author:       parent.Author,
+		Source:       parent.Source,
+		Content:      content,
+		Language:     lang.Code,
+		Status:       models.StatusProcessing,
+		Visibility:   models.VisibilityPrivate,
+		ParentID:     &parentID,
+		AdaptedLevel: level,
+	}
+	if _, err := s.materialService.CreateMaterial(child); err != nil {
+		return nil, err
+	}
+
+	phrases, err := s.phraseService.GeneratePhrases(ctx, child.ID, child.UserUID)
+	if err != nil {
+		return child, err
+	}
+	if err := s.phraseService.SyncPhrases(child.ID, child.UserUID, phrases); err != nil {
+		return child, err
+	}
+	s.materialStore.UpdateMaterialStatus(child.ID, models.StatusCompleted)
+	return child, nil
+}
+
+func (s *jobService) saveJob(job *models.Job) {
+	if err := s.store.UpdateJob(job); err != nil {
+		logger.Errorf("Failed to update job: %v, JobID: %v", err, job.ID)
+	}
+}
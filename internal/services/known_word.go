@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/nlp"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+// maxUnknownLemmas caps the unknown lemmas returned with a material
+const maxUnknownLemmas = 50
+
+// KnownWordService tracks known words per language. An empty language stands
+// for the language the user is learning.
+type KnownWordService interface {
+	ListKnownWords(UserUID, language string, page stores.PageQuery) (*stores.Page[models.KnownWord], error)
+	// MarkKnown adds the lemmas of words to the user's known words
+	MarkKnown(UserUID, language string, words []string, source string) error
+	// MarkUnknown removes the lemmas of words from the user's known words
+	MarkUnknown(UserUID, language string, words []string) error
+	// AttachCoverage sets the coverage and unknown lemmas of material for the user
+	AttachCoverage(material *models.Material, UserUID string) error
+}
+
+var ErrNoWords = errors.New("no words given")
+
+type knownWordService struct {
+	store     stores.KnownWordStore
+	userStore stores.UserStore
+}
+
+func NewKnownWordService(ks stores.KnownWordStore, us stores.UserStore) KnownWordService {
+	return &knownWordService{store: ks, userStore: us}
+}
+
+func (s *knownWordService) ListKnownWords(UserUID, code string, page stores.PageQuery) (*stores.Page[models.KnownWord], error) {
+	code, err := s.languageOf(UserUID, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListKnownWords(UserUID, code, page)
+}
+
+func (s *knownWordService) MarkKnown(UserUID, code string, words []string, source string) error {
+	code, err := s.languageOf(UserUID, code)
+	if err != nil {
+		return err
+	}
+	lemmas := lemmasOf(words, code)
+	if len(lemmas) == 0 {
+		return ErrNoWords
+	}
+	return s.store.AddKnownWords(UserUID, code, lemmas, source)
+}
+
+func (s *knownWordService) MarkUnknown(UserUID, code string, words []string) error {
+	code, err := s.languageOf(UserUID, code)
+	if err != nil {
+		return err
+	}
+	lemmas := lemmasOf(words, code)
+	if len(lemmas) == 0 {
+		return ErrNoWords
+	}
+	return s.store.RemoveKnownWords(UserUID, code, lemmas)
+}
+
+func (s *knownWordService) AttachCoverage(material *models.Material, UserUID string) error {
+	code := language.Of(material.Language).Code
+	counts := nlp.LemmaCountsIn(material.Content, code)
+	lemmas := make([]string, 0, len(counts))
+	for lemma := range counts {
+		lemmas = append(lemmas, lemma)
+	}
+	known, err := s.store.FilterKnown(UserUID, code, lemmas)
+	if err != nil {
+		return err
+	}
+	coverage, unknown := nlp.Coverage(counts, known)
+	if len(unknown) > maxUnknownLemmas {
+		unknown = unknown[:maxUnknownLemmas]
+	}
+	material.Coverage = &coverage
+	material.UnknownLemmas = unknown
+	return nil
+}
+
+// languageOf checks code, falling back to the language the user is learning
+// when it is empty
+func (s *knownWordService) languageOf(UserUID, code string) (string, error) {
+	if code != "" {
+		if _, ok := language.Get(code); !ok {
+			return "", ErrUnsupportedTargetLanguage
+		}
+		return code, nil
+	}
+	user, err := s.userStore.GetUserByUserUID(UserUID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return language.Default, nil
+	}
+	return language.Of(user.TargetLanguage).Code, nil
+}
+
+// lemmasOf returns the distinct lemmas of the words in texts in the language of code
+func lemmasOf(texts []string, code string) []string {
+	seen := map[string]bool{}
+	var lemmas []string
+	for _, text := range texts {
+		for _, token := range nlp.Tokenize(text) {
+			lemma := nlp.LemmaIn(token, code)
+			if !seen[lemma] {
+				seen[lemma] = true
+				lemmas = append(lemmas, lemma)
+			}
+		}
+	}
+	return lemmas
+}
@@ -0,0 +1,42 @@
+package services
+
+import "sync"
+
+// keyedMutex serializes work on one key, such as one user's chats of a
+// material, while work on different keys runs in parallel. The zero value is
+// ready to use.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu sync.Mutex
+	// refs counts the holders and waiters, so that unused locks are dropped
+	refs int
+}
+
+// Lock locks key and returns the function that unlocks it
+func (m *keyedMutex) Lock(key string) (unlock func()) {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = map[string]*keyedLock{}
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
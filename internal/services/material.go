@@ -3,10 +3,16 @@ package services
 import (
 	"errors"
 	"fmt"
-	"sync"
+	"time"
 
+	"github.com/yomek33/talki/internal/diff"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/levels"
+	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/nlp"
 	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
 )
 
 type MaterialService interface {
@@ -14,26 +20,61 @@ type MaterialService interface {
 	GetMaterialByID(id uint, UserUID string) (*models.Material, error)
 	UpdateMaterial(id uint, material *models.Material) error
 	DeleteMaterial(id uint, UserUID string) error
-	GetAllMaterials(searchQuery string, UserUID string) ([]models.Material, error)
+	GetAllMaterials(query stores.MaterialQuery, UserUID string) (*stores.Page[models.Material], error)
 	UpdateMaterialStatus(id uint, status string) error
 	GetMaterialStatus(id uint) (string, error)
+	// ClaimProcessing marks one of the user's materials as processing before its
+	// phrases are generated again, failing with ErrMaterialBusy while other
+	// work on them runs
+	ClaimProcessing(id uint, UserUID string) error
+	ListRevisions(materialID uint, UserUID string, page stores.PageQuery) (*stores.Page[models.MaterialRevision], error)
+	GetRevision(materialID uint, number int, UserUID string) (*models.MaterialRevision, error)
+	// RestoreRevision puts the title and content of a revision back, recorded as a new revision
+	RestoreRevision(materialID uint, number int, UserUID string) (*models.Material, error)
+	// AnalyzeMissing analyzes materials stored before their lemmas and readability were recorded
+	AnalyzeMissing() (int, error)
+	// EstimateLevel estimates the CEFR level of the vocabulary of an English material
+	EstimateLevel(material *models.Material) (levels.Estimate, error)
 }
 
 type materialService struct {
-	store stores.MaterialStore
-	mu    sync.Mutex
+	store     stores.MaterialStore
+	revisions stores.RevisionStore
+	search    *searchService
+	embedding *embeddingService
 }
 
 var (
 	ErrMaterialNil          = errors.New("material cannot be nil")
 	ErrMismatchedMaterialID = errors.New("mismatched material ID")
+	ErrMaterialNotFound     = errors.New("material not found")
+	ErrRevisionNotFound     = errors.New("revision not found")
+	// ErrUnsupportedTargetLanguage is returned for materials and learners in a language that cannot be learned
+	ErrUnsupportedTargetLanguage = errors.New("language is not supported")
+	ErrEnglishOnly               = errors.New("difficulty estimates are only available for English materials")
 )
 
+// processingStaleAfter is when a material left processing is taken to be
+// abandoned, as by a restart mid-job, well past the time generation is given
+const processingStaleAfter = 10 * time.Minute
+
+// revisionDiffContext is the number of unchanged lines kept around each change of a revision diff
+const revisionDiffContext = 3
+
 func (s *materialService) CreateMaterial(material *models.Material) (uint, error) {
 	if material == nil {
 		return 0, errors.New("material cannot be nil")
 	}
-	return s.store.CreateMaterial(material)
+	if err := resolveLanguage(material); err != nil {
+		return 0, err
+	}
+	id, err := s.store.CreateMaterial(material)
+	if err != nil {
+		return 0, err
+	}
+	s.recordRevision(nil, material, nil)
+	s.search.IndexMaterial(material)
+	return id, nil
 }
 
 func (s *materialService) GetMaterialByID(id uint, UserUID string) (*models.Material, error) {
@@ -51,25 +92,160 @@ func (s *materialService) UpdateMaterial(id uint, material *models.Material) err
 	if id != material.ID {
 		return ErrMismatchedMaterialID
 	}
-	return s.store.UpdateMaterial(id, material)
+	previous, err := s.store.GetMaterialByID(id, material.UserUID)
+	if err != nil {
+		return fmt.Errorf("failed to get material by ID: %w", err)
+	}
+	if err := resolveLanguage(material); err != nil {
+		return err
+	}
+	if err := s.store.UpdateMaterial(id, material); err != nil {
+		return err
+	}
+	s.recordRevision(previous, material, nil)
+	s.search.IndexMaterial(material)
+	return nil
 }
 
 func (s *materialService) DeleteMaterial(id uint, UserUID string) error {
-	return s.store.DeleteMaterial(id, UserUID)
+	if _, err := s.store.GetMaterialByID(id, UserUID); err != nil {
+		return ErrMaterialNotFound
+	}
+	s.embedding.RemoveMaterial(id)
+	if err := s.store.DeleteMaterial(id, UserUID); err != nil {
+		return err
+	}
+	s.search.RemoveMaterial(id)
+	return nil
+}
+
+func (s *materialService) GetAllMaterials(query stores.MaterialQuery, UserUID string) (*stores.Page[models.Material], error) {
+	return s.store.GetAllMaterials(query, UserUID)
 }
 
-func (s *materialService) GetAllMaterials(searchQuery string, UserUID string) ([]models.Material, error) {
-	return s.store.GetAllMaterials(searchQuery, UserUID)
+func (s *materialService) AnalyzeMissing() (int, error) {
+	return s.store.AnalyzeMissing()
+}
+
+func (s *materialService) EstimateLevel(material *models.Material) (levels.Estimate, error) {
+	if !language.IsEnglish(material.Language) {
+		return levels.Estimate{}, ErrEnglishOnly
+	}
+	return levels.EstimateLevel(nlp.LemmaCounts(material.Content)), nil
+}
+
+// resolveLanguage checks the language of material, detecting it from the
+// content when none is given
+func resolveLanguage(material *models.Material) error {
+	if material.Language == "" {
+		material.Language = language.Detect(material.Content)
+		return nil
+	}
+	if _, ok := language.Get(material.Language); !ok {
+		return ErrUnsupportedTargetLanguage
+	}
+	return nil
 }
 
 func (s *materialService) UpdateMaterialStatus(id uint, status string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	return s.store.UpdateMaterialStatus(id, status)
 }
 
+func (s *materialService) ClaimProcessing(id uint, UserUID string) error {
+	return claimProcessing(s.store, id, UserUID)
+}
+
+// claimProcessing reserves the phrases of a material for one background job
+// at a time; the job moves the status on to completed or failed when done
+func claimProcessing(store stores.MaterialStore, id uint, UserUID string) error {
+	claimed, err := store.ClaimProcessing(id, UserUID, processingStaleAfter)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMaterialNotFound
+		}
+		return err
+	}
+	if !claimed {
+		return ErrMaterialBusy
+	}
+	return nil
+}
+
 func (s *materialService) GetMaterialStatus(id uint) (string, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	return s.store.GetMaterialStatus(id)
 }
+
+func (s *materialService) ListRevisions(materialID uint, UserUID string, page stores.PageQuery) (*stores.Page[models.MaterialRevision], error) {
+	if _, err := s.store.GetMaterialByID(materialID, UserUID); err != nil {
+		return nil, ErrMaterialNotFound
+	}
+	return s.revisions.ListRevisions(materialID, UserUID, page)
+}
+
+func (s *materialService) GetRevision(materialID uint, number int, UserUID string) (*models.MaterialRevision, error) {
+	revision, err := s.revisions.GetRevision(materialID, number, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, err
+}
+
+func (s *materialService) RestoreRevision(materialID uint, number int, UserUID string) (*models.Material, error) {
+	material, err := s.store.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return nil, ErrMaterialNotFound
+	}
+	revision, err := s.GetRevision(materialID, number, UserUID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *material
+	material.Title = revision.Title
+	material.Content = revision.Content
+	if err := s.store.UpdateMaterial(materialID, material); err != nil {
+		return nil, err
+	}
+	s.recordRevision(&previous, material, &number)
+	s.search.IndexMaterial(material)
+	return material, nil
+}
+
+// recordRevision stores material as a new revision when its title or content
+// differ from previous. Materials created before revisions existed get their
+// previous state recorded first so the history starts from it.
+// Failures are logged: the edit itself has already been saved.
+func (s *materialService) recordRevision(previous, material *models.Material, restoredFrom *int) {
+	if s.revisions == nil {
+		return
+	}
+	var before string
+	if previous != nil {
+		if previous.Title == material.Title && previous.Content == material.Content {
+			return
+		}
+		before = previous.Content
+		if _, err := s.revisions.GetLatestRevision(material.ID); errors.Is(err, gorm.ErrRecordNotFound) {
+			s.createRevision(nil, previous, nil)
+		}
+	}
+	s.createRevision(&before, material, restoredFrom)
+}
+
+func (s *materialService) createRevision(before *string, material *models.Material, restoredFrom *int) {
+	revision := &models.MaterialRevision{
+		MaterialID:   material.ID,
+		UserUID:      material.UserUID,
+		Title:        material.Title,
+		Content:      material.Content,
+		RestoredFrom: restoredFrom,
+	}
+	if before != nil {
+		lines := diff.Lines(*before, material.Content)
+		revision.Diff = diff.Unified(lines, revisionDiffContext)
+		revision.Inserted, revision.Deleted = diff.Stats(lines)
+	}
+	if err := s.revisions.CreateRevision(revision); err != nil {
+		logger.Errorf("Failed to record revision: %v, MaterialID: %v", err, material.ID)
+	}
+}
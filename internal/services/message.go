@@ -1,12 +1,19 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"sync"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/yomek33/talki/internal/gemini"
+	"github.com/yomek33/talki/internal/language"
 	"github.com/yomek33/talki/internal/logger"
 	"github.com/yomek33/talki/internal/models"
 	"github.com/yomek33/talki/internal/stores"
@@ -16,107 +23,454 @@ import (
 // MessageService defines the interface for message-related operations
 type MessageService interface {
 	CreateMessage(chatID uint, message *models.Message) (*models.Message, error)
-	GetMessages(chatID uint) ([]models.Message, error)
+	// GetMessages returns the active branch of one of the user's chats
+	GetMessages(chatID uint, userUID string) ([]models.Message, error)
 	SendMessageToGemini(chatID uint, content, userUID string) (string, error)
+	// EditMessage replaces the learner's last message on the active branch
+	// with a new branch and answers it
+	EditMessage(chatID, id uint, content, userUID string) (*EditedMessage, error)
+	// RegenerateMessage answers the learner's last message again on a new branch
+	RegenerateMessage(chatID, id uint, userUID string) (*models.Message, error)
+	// ActivateBranch continues the chat from the latest leaf below message id
+	// and returns the new active branch
+	ActivateBranch(chatID, id uint, userUID string) ([]models.Message, error)
+	// SuggestReplies proposes what the learner could say next without saving
+	// anything; with hint they get sentence starters instead of whole replies
+	SuggestReplies(chatID uint, userUID string, hint bool) (*ReplySuggestions, error)
 }
 
+// EditedMessage is an edited learner message together with the reply to it
+type EditedMessage struct {
+	Message models.Message `json:"message"`
+	Reply   models.Message `json:"reply"`
+}
+
+// replyStaleAfter is when a pending reply is taken to be abandoned, well past
+// the time the model is given to answer
+const replyStaleAfter = 2 * time.Minute
+
+var (
+	ErrReplyPending       = errors.New("a reply in this chat is still pending")
+	ErrNotLastUserMessage = errors.New("only the last learner message can be edited")
+	ErrNotLastBotMessage  = errors.New("only the latest reply can be regenerated")
+)
+
+// ReplySuggestions are candidate next turns for a learner who is stuck
+type ReplySuggestions struct {
+	Hint        bool     `json:"hint"`
+	Suggestions []string `json:"suggestions"`
+}
+
+const (
+	// suggestionPhraseLimit bounds the target phrases offered to the model
+	suggestionPhraseLimit = 20
+	suggestionTimeout     = 15 * time.Second
+)
+
 type messageService struct {
-	store        stores.MessageStore
-	chatStore    stores.ChatStore
-	geminiClient *gemini.Client
-	mu           sync.Mutex
+	store         stores.MessageStore
+	chatStore     stores.ChatStore
+	materialStore stores.MaterialStore
+	phraseStore   stores.PhraseStore
+	scenarios     *scenarioService
+	usage         *usageService
+	geminiClient  *gemini.Client
+	search        *searchService
 }
 
 // NewMessageService creates a new instance of messageService
-func NewMessageService(ms stores.MessageStore, cs stores.ChatStore, gc *gemini.Client) MessageService {
+func NewMessageService(ms stores.MessageStore, cs stores.ChatStore, mts stores.MaterialStore, ps stores.PhraseStore, ss stores.ScenarioStore, gc *gemini.Client) MessageService {
 	return &messageService{
-		store:        ms,
-		chatStore:    cs,
-		geminiClient: gc,
+		store:         ms,
+		chatStore:     cs,
+		materialStore: mts,
+		phraseStore:   ps,
+		scenarios:     &scenarioService{store: ss},
+		geminiClient:  gc,
 	}
 }
 
 func (s *messageService) CreateMessage(chatID uint, message *models.Message) (*models.Message, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if message.Content == "" {
 		return nil, errors.New("message content cannot be empty")
 	}
 
+	chat, err := s.chatStore.GetChatByChatID(chatID, message.UserUID)
+	if err != nil {
+		return nil, ErrChatNotFound
+	}
+
 	message.ChatID = chatID
-	return s.store.CreateMessage(message)
+	if err := s.store.AppendMessage(message); err != nil {
+		return nil, err
+	}
+	s.search.IndexMessage(message, chat)
+	return message, nil
 }
 
-func (s *messageService) GetMessages(chatID uint) ([]models.Message, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	return s.store.GetMessages(chatID)
+func (s *messageService) GetMessages(chatID uint, userUID string) ([]models.Message, error) {
+	chat, _, err := s.loadChat(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	return chat.Messages, nil
 }
 
 func (s *messageService) SendMessageToGemini(chatID uint, content, userUID string) (string, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	chat, err := s.chatStore.GetChatByChatID(chatID, userUID)
+	release, err := s.claimReply(chatID, userUID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", errors.New("chat not found")
-		}
 		return "", err
 	}
+	defer release()
 
-	// if chat.PendingMessage {
-	// 	return "", errors.New("previous message pending response")
-	// }
+	chat, _, err := s.loadChat(chatID, userUID)
+	if err != nil {
+		return "", err
+	}
+	history := chat.Messages
 
 	userMessage := &models.Message{
 		ChatID:     chatID,
 		UserUID:    userUID,
 		Content:    content,
 		SenderType: "user",
+		ParentID:   chat.ActiveLeafID,
 	}
-
-	if _, err := s.store.CreateMessage(userMessage); err != nil {
-    return "", err
+	botMessage, err := s.answerUserMessage(chat, history, userMessage, nil)
+	if err != nil {
+		return "", err
 	}
 	logger.Infof("User message: %s", content)
+	return botMessage.Content, nil
+}
 
-	chat.PendingMessage ++
-	if err := s.chatStore.UpdateChat(chat); err != nil {
-		return "", err
+func (s *messageService) EditMessage(chatID, id uint, content, userUID string) (*EditedMessage, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("message content cannot be empty")
+	}
+	release, err := s.claimReply(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	chat, _, err := s.loadChat(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	path := chat.Messages
+	i := lastIndexOf(path, func(m models.Message) bool { return m.SenderType == "user" })
+	if i < 0 || path[i].ID != id {
+		if indexOf(path, id) < 0 {
+			return nil, ErrMessageNotFound
+		}
+		return nil, ErrNotLastUserMessage
+	}
+
+	edited := &models.Message{
+		ChatID:     chatID,
+		UserUID:    userUID,
+		Content:    content,
+		SenderType: "user",
+		ParentID:   path[i].ParentID,
+	}
+	reply, err := s.answerUserMessage(chat, path[:i], edited, &path[i])
+	if err != nil {
+		return nil, err
+	}
+	return &EditedMessage{Message: *edited, Reply: *reply}, nil
+}
+
+func (s *messageService) RegenerateMessage(chatID, id uint, userUID string) (*models.Message, error) {
+	release, err := s.claimReply(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	chat, _, err := s.loadChat(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	path := chat.Messages
+	i := indexOf(path, id)
+	if i < 0 {
+		return nil, ErrMessageNotFound
+	}
+	// only the reply to the learner's latest message can be regenerated
+	if i != len(path)-1 || path[i].SenderType != "bot" || i == 0 || path[i-1].SenderType != "user" {
+		return nil, ErrNotLastBotMessage
+	}
+	return s.respond(chat, path[:i-1], &path[i-1])
+}
+
+func (s *messageService) ActivateBranch(chatID, id uint, userUID string) ([]models.Message, error) {
+	release, err := s.claimReply(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	chat, all, err := s.loadChat(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	if indexOf(all, id) < 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	// follow the most recent child down to a leaf
+	children := map[uint][]uint{}
+	for _, m := range all {
+		if m.ParentID != nil {
+			children[*m.ParentID] = append(children[*m.ParentID], m.ID)
+		}
+	}
+	leaf := id
+	for len(children[leaf]) > 0 {
+		leaf = slices.Max(children[leaf])
+	}
+	if err := s.store.SetActiveLeaf(chat.ID, leaf); err != nil {
+		return nil, err
+	}
+	return activePath(all, &leaf), nil
+}
+
+// claimReply reserves the chat for this request until release is called, so
+// that a chat takes one message at a time while different chats proceed in
+// parallel; overlapping requests fail with ErrReplyPending
+func (s *messageService) claimReply(chatID uint, userUID string) (release func(), err error) {
+	token, err := newReplyToken()
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.chatStore.ClaimReply(chatID, userUID, token, replyStaleAfter)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrReplyPending
+	}
+	return func() {
+		// a claim taken over as stale belongs to another request by now
+		if err := s.chatStore.ReleaseReply(chatID, token); err != nil {
+			logger.Errorf("Failed to release pending reply: %v, ChatID: %v", err, chatID)
+		}
+	}, nil
+}
+
+func newReplyToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate reply token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// loadChat reads one of the user's chats with chat.Messages narrowed to its
+// active branch, in order, and also returns all of its messages
+func (s *messageService) loadChat(chatID uint, userUID string) (*models.Chat, []models.Message, error) {
+	chat, err := s.chatStore.GetChatByChatID(chatID, userUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrChatNotFound
+		}
+		return nil, nil, err
+	}
+	if chat.ActiveLeafID == nil && len(chat.Messages) > 0 {
+		// a chat from before branching; link its messages into one branch first
+		if _, err := s.store.GetActiveLeaf(chatID); err != nil {
+			return nil, nil, err
+		}
+		if chat, err = s.chatStore.GetChatByChatID(chatID, userUID); err != nil {
+			return nil, nil, err
+		}
+	}
+	all := chat.Messages
+	chat.Messages = activePath(all, chat.ActiveLeafID)
+	return chat, all, nil
+}
+
+// answerUserMessage saves a learner message as the chat's new active leaf and
+// has the model answer it. A message that gets no answer is discarded, so the
+// chat continues from where it was; one that does is indexed and checked for
+// the phrases the learner practices. replaced is the message it is an edit
+// of, if any.
+func (s *messageService) answerUserMessage(chat *models.Chat, history []models.Message, message, replaced *models.Message) (*models.Message, error) {
+	previousLeaf := chat.ActiveLeafID
+	if err := s.store.AddMessage(message); err != nil {
+		return nil, err
+	}
+	chat.ActiveLeafID = &message.ID
+
+	reply, err := s.respond(chat, history, message)
+	if err != nil {
+		if discardErr := s.store.DiscardMessage(message, previousLeaf); discardErr != nil {
+			logger.Errorf("Failed to discard unanswered message: %v, MessageID: %v, ChatID: %v", discardErr, message.ID, chat.ID)
+		}
+		chat.ActiveLeafID = previousLeaf
+		return nil, err
+	}
+
+	s.search.IndexMessage(message, chat)
+	if replaced != nil {
+		_, err = s.usage.DetectEditUsage(chat, message, replaced)
+	} else {
+		_, err = s.usage.DetectUsage(chat, message)
+	}
+	if err != nil {
+		// usage tracking must not keep the learner from getting a reply
+		logger.Errorf("Failed to detect phrase usage: %v, ChatID: %v", err, chat.ID)
+	}
+	return reply, nil
+}
+
+// respond has the model answer userMessage, which follows history on the
+// chat's active branch, and saves the answer as the new active leaf
+func (s *messageService) respond(chat *models.Chat, history []models.Message, userMessage *models.Message) (*models.Message, error) {
+	setting, err := s.chatSetting(chat)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Infof("UpdateChat")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-
-	response, err := s.geminiClient.SendMessageToGemini(ctx, chat, content)
+	chat.Messages = history
+	response, err := s.geminiClient.SendMessageToGemini(ctx, chat, setting, userMessage.Content)
 	if err != nil {
-		s.revertPendingMessageState(chat)
-		return "", err
+		return nil, err
 	}
 
-	// response:="botbot"
 	botMessage := &models.Message{
-		ChatID:     chatID,
+		ChatID:     chat.ID,
 		Content:    response,
 		SenderType: "bot",
+		ParentID:   &userMessage.ID,
+	}
+	if err := s.store.AddMessage(botMessage); err != nil {
+		return nil, err
 	}
+	chat.ActiveLeafID = &botMessage.ID
+	s.search.IndexMessage(botMessage, chat)
+	return botMessage, nil
+}
 
-	if _, err := s.store.CreateMessage(botMessage); err != nil {
-    return "", err
+// activePath returns the messages from the root of the chat down to leaf,
+// with the alternatives at each step. Without a leaf, the chat is one branch
+// in the order the messages were created.
+func activePath(messages []models.Message, leaf *uint) []models.Message {
+	sorted := slices.Clone(messages)
+	slices.SortFunc(sorted, func(a, b models.Message) int { return cmp.Compare(a.ID, b.ID) })
+	if leaf == nil {
+		return sorted
 	}
-	//chat.PendingMessage = false
-	if err := s.chatStore.UpdateChat(chat); err != nil {
-		return "", err
+
+	byID := make(map[uint]models.Message, len(sorted))
+	siblings := map[uint][]uint{}
+	for _, m := range sorted {
+		byID[m.ID] = m
+		var parent uint
+		if m.ParentID != nil {
+			parent = *m.ParentID
+		}
+		siblings[parent] = append(siblings[parent], m.ID)
+	}
+
+	path := []models.Message{}
+	for id := leaf; id != nil; {
+		m, found := byID[*id]
+		if !found {
+			break
+		}
+		var parent uint
+		if m.ParentID != nil {
+			parent = *m.ParentID
+		}
+		if len(siblings[parent]) > 1 {
+			m.SiblingIDs = siblings[parent]
+		}
+		path = append(path, m)
+		id = m.ParentID
+	}
+	slices.Reverse(path)
+	return path
+}
+
+func indexOf(messages []models.Message, id uint) int {
+	return slices.IndexFunc(messages, func(m models.Message) bool { return m.ID == id })
+}
+
+func lastIndexOf(messages []models.Message, match func(models.Message) bool) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if match(messages[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *messageService) SuggestReplies(chatID uint, userUID string, hint bool) (*ReplySuggestions, error) {
+	chat, _, err := s.loadChat(chatID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := s.chatSetting(chat)
+	if err != nil {
+		return nil, err
+	}
+	phrases, err := s.phraseStore.GetPhrasesByMaterialID(chat.MaterialID)
+	if err != nil {
+		return nil, err
+	}
+	// starred phrases are the ones the learner most wants to practice
+	sort.SliceStable(phrases, func(i, j int) bool { return phrases[i].Starred && !phrases[j].Starred })
+	texts := make([]string, 0, min(len(phrases), suggestionPhraseLimit))
+	for _, phrase := range phrases[:min(len(phrases), suggestionPhraseLimit)] {
+		texts = append(texts, phrase.Text)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), suggestionTimeout)
+	defer cancel()
+	suggestions, err := s.geminiClient.SuggestReplies(ctx, chat, setting, texts, hint)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplySuggestions{Hint: hint, Suggestions: suggestions}, nil
+}
+
+// chatSetting gathers the scenario of a chat and the material it is about
+func (s *messageService) chatSetting(chat *models.Chat) (gemini.ChatSetting, error) {
+	scenario, err := s.scenarios.ScenarioOf(chat)
+	if err != nil {
+		return gemini.ChatSetting{}, err
 	}
+	setting := gemini.ChatSetting{Scenario: *scenario, Language: language.Of(language.Default).Name}
 
-	return response, nil
+	material, err := s.materialStore.GetMaterialByID(chat.MaterialID, chat.UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the material was deleted; the chat goes on without it
+			return setting, nil
+		}
+		return gemini.ChatSetting{}, err
+	}
+	setting.Language = language.Of(material.Language).Name
+	setting.Level = learnerLevel(material)
+	setting.MaterialTitle = material.Title
+	setting.MaterialContent = material.Content
+	return setting, nil
 }
 
-func (s *messageService) revertPendingMessageState(chat *models.Chat) {
-	//chat.PendingMessage = false
-	s.chatStore.UpdateChat(chat)
+// learnerLevel describes the level of the learner reading a material: that
+// it was adapted to, or else its estimated CEFR level
+func learnerLevel(material *models.Material) string {
+	if material.AdaptedLevel != "" {
+		return language.Of(material.Language).Describe(material.AdaptedLevel)
+	}
+	if material.Level != "" {
+		return "CEFR level " + material.Level
+	}
+	return ""
 }
@@ -2,24 +2,64 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/yomek33/talki/internal/gemini"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/levels"
 	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/nlp"
 	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
 )
 
 type PhraseService interface {
 	GeneratePhrases(ctx context.Context, materialID uint, UserUID string) ([]models.Phrase, error)
 	StorePhrases(materialID uint, phrases []models.Phrase) error
+	SyncPhrases(materialID uint, UserUID string, phrases []models.Phrase) error
 	GetPhrasesByMaterialID(materialID uint) ([]models.Phrase, error)
+	ListPhrases(materialID uint, UserUID string, page stores.PageQuery) (*stores.Page[models.Phrase], error)
+	CreatePhrase(materialID uint, UserUID string, phrase *models.Phrase) error
+	UpdatePhrase(materialID, id uint, UserUID string, update PhraseUpdate) (*models.Phrase, error)
+	DeletePhrase(materialID, id uint, UserUID string) error
+	ReorderPhrases(materialID uint, UserUID string, ids []uint) error
+	GeneratePhrasesWithOptions(ctx context.Context, materialID uint, UserUID string, opts gemini.PhraseOptions) ([]models.Phrase, error)
+	ApplyGeneratedPhrases(materialID uint, UserUID string, phrases []models.Phrase, mode string) (added, removed int, err error)
 }
 
+const (
+	GenerateModeAppend  = "append"
+	GenerateModeReplace = "replace"
+)
+
+// PhraseUpdate holds the fields a learner changes on a phrase; nil fields are left as they are
+type PhraseUpdate struct {
+	Text       *string `json:"text"`
+	Importance *string `json:"importance"`
+	Starred    *bool   `json:"starred"`
+}
+
+var (
+	ErrPhraseNotFound      = errors.New("phrase not found")
+	ErrEmptyText           = errors.New("text cannot be empty")
+	ErrInvalidGenerateMode = errors.New("mode must be append or replace")
+	ErrInvalidImportance   = errors.New("importance must be high, medium or low")
+	// validImportances are the ratings levels.Importance gives
+	validImportances = map[string]bool{"high": true, "medium": true, "low": true}
+)
+
 type phraseService struct {
 	store           stores.PhraseStore
 	MaterialService *materialService
 	GeminiClient    *gemini.Client
+	search          *searchService
+	embedding       *embeddingService
+	// merging serializes merges into one material, which read its phrases
+	// before writing, between generation jobs and content reprocessing
+	merging keyedMutex
 }
 
 func (s *phraseService) StorePhrase(phrase *models.Phrase) error {
@@ -30,11 +70,19 @@ func (s *phraseService) GetPhrasesByMaterialID(materialID uint) ([]models.Phrase
 	return s.store.GetPhrasesByMaterialID(materialID)
 }
 
+func (s *phraseService) ListPhrases(materialID uint, UserUID string, page stores.PageQuery) (*stores.Page[models.Phrase], error) {
+	return s.store.ListPhrases(materialID, UserUID, page)
+}
+
 func GeneratePhrases(topic string) ([]string, error) {
 	return []string{}, nil
 }
 
 func (s *phraseService) GeneratePhrases(ctx context.Context, materialID uint, UserUID string) ([]models.Phrase, error) {
+	return s.GeneratePhrasesWithOptions(ctx, materialID, UserUID, gemini.PhraseOptions{})
+}
+
+func (s *phraseService) GeneratePhrasesWithOptions(ctx context.Context, materialID uint, UserUID string, opts gemini.PhraseOptions) ([]models.Phrase, error) {
 	log.Println("Generating phrases")
 
 	log.Println("MaterialID", materialID)
@@ -58,8 +106,12 @@ func (s *phraseService) GeneratePhrases(ctx context.Context, materialID uint, Us
 
 	log.Printf("Generating phrases for material %d", materialID)
 
+	if opts.Language == "" {
+		opts.Language = language.Of(material.Language).Name
+	}
+
 	// Generate phrases using GeminiClientx
-	phraseTexts, err := s.GeminiClient.GeneratePhrases(ctx, material.Content)
+	phraseTexts, err := s.GeminiClient.GeneratePhrasesWithOptions(ctx, material.Content, opts)
 	if err != nil {
 		log.Printf("Failed to generate phrases: %v", err)
 		return nil, fmt.Errorf("failed to generate phrases: %w", err)
@@ -74,7 +126,7 @@ func (s *phraseService) GeneratePhrases(ctx context.Context, materialID uint, Us
 		phrases = append(phrases, models.Phrase{
 			MaterialID: materialID,
 			Text:       phraseText,
-			Importance: determineImportance(phraseText),
+			Importance: determineImportance(phraseText, material.Language),
 		})
 	}
 
@@ -82,15 +134,198 @@ func (s *phraseService) GeneratePhrases(ctx context.Context, materialID uint, Us
 }
 
 func (s *phraseService) StorePhrases(materialID uint, phrases []models.Phrase) error {
-	for _, phrase := range phrases {
-		if err := s.store.CreatePhrase(&phrase); err != nil {
+	for i := range phrases {
+		if err := s.store.CreatePhrase(&phrases[i]); err != nil {
 			return fmt.Errorf("failed to store phrase: %w", err)
 		}
 	}
 
+	s.search.IndexPhrases(phrases)
 	return nil
 }
 
-func determineImportance(_ string) string {
-	return "high"
+// SyncPhrases replaces the phrases of a material with a freshly generated set.
+// Existing phrases that were generated again or still appear in the content are
+// kept as they are, so anything recorded against them carries over. Phrases the
+// learner edited or starred are never removed.
+func (s *phraseService) SyncPhrases(materialID uint, UserUID string, phrases []models.Phrase) error {
+	_, _, err := s.mergePhrases(materialID, UserUID, phrases, mergeSync)
+	return err
+}
+
+// ApplyGeneratedPhrases stores phrases generated on demand. GenerateModeAppend adds
+// the ones the material does not have yet; GenerateModeReplace also removes the
+// previously generated phrases that were not generated again.
+func (s *phraseService) ApplyGeneratedPhrases(materialID uint, UserUID string, phrases []models.Phrase, mode string) (added, removed int, err error) {
+	switch mode {
+	case GenerateModeAppend:
+		return s.mergePhrases(materialID, UserUID, phrases, mergeAppend)
+	case GenerateModeReplace:
+		return s.mergePhrases(materialID, UserUID, phrases, mergeReplace)
+	}
+	return 0, 0, ErrInvalidGenerateMode
+}
+
+type mergeMode int
+
+const (
+	mergeSync mergeMode = iota
+	mergeAppend
+	mergeReplace
+)
+
+func (s *phraseService) mergePhrases(materialID uint, UserUID string, phrases []models.Phrase, mode mergeMode) (added, removed int, err error) {
+	defer s.merging.Lock(fmt.Sprint(materialID))()
+
+	material, err := s.MaterialService.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return 0, 0, err
+	}
+	existing, err := s.store.GetPhrasesByMaterialID(materialID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch phrases: %w", err)
+	}
+
+	generated := make(map[string]bool, len(phrases))
+	for _, phrase := range phrases {
+		generated[normalizePhrase(phrase.Text)] = true
+	}
+	content := normalizePhrase(material.Content)
+
+	kept := make(map[string]bool, len(existing))
+	var stale []uint
+	for _, phrase := range existing {
+		key := normalizePhrase(phrase.Text)
+		keep := mode == mergeAppend || phrase.UserEdited || phrase.Starred || generated[key] ||
+			(mode == mergeSync && strings.Contains(content, key))
+		if keep {
+			kept[key] = true
+			continue
+		}
+		stale = append(stale, uint(phrase.ID))
+	}
+
+	var fresh []models.Phrase
+	for _, phrase := range phrases {
+		key := normalizePhrase(phrase.Text)
+		if kept[key] {
+			continue
+		}
+		kept[key] = true
+		fresh = append(fresh, phrase)
+	}
+
+	if err := s.store.DeletePhrases(stale); err != nil {
+		return 0, 0, fmt.Errorf("failed to delete stale phrases: %w", err)
+	}
+	s.search.RemovePhrases(stale)
+	s.embedding.RemovePhrases(stale)
+	log.Printf("Merged phrases for material %d: kept %d, removed %d, added %d", materialID, len(existing)-len(stale), len(stale), len(fresh))
+	if err := s.StorePhrases(materialID, fresh); err != nil {
+		return 0, len(stale), err
+	}
+	return len(fresh), len(stale), nil
+}
+
+// CreatePhrase adds a learner-written phrase at the end of the material's phrases
+func (s *phraseService) CreatePhrase(materialID uint, UserUID string, phrase *models.Phrase) error {
+	if phrase == nil {
+		return errors.New("phrase cannot be nil")
+	}
+	phrase.Text = strings.TrimSpace(phrase.Text)
+	if phrase.Text == "" {
+		return ErrEmptyText
+	}
+	if phrase.Importance != "" && !validImportances[phrase.Importance] {
+		return ErrInvalidImportance
+	}
+	material, err := s.MaterialService.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return ErrMaterialNotFound
+	}
+	phrase.MaterialID = materialID
+	phrase.Position = 0
+	phrase.UserEdited = true
+	if phrase.Importance == "" {
+		phrase.Importance = determineImportance(phrase.Text, material.Language)
+	}
+	if err := s.store.CreatePhrase(phrase); err != nil {
+		return err
+	}
+	s.search.IndexPhrases([]models.Phrase{*phrase})
+	return nil
+}
+
+func (s *phraseService) UpdatePhrase(materialID, id uint, UserUID string, update PhraseUpdate) (*models.Phrase, error) {
+	if update.Importance != nil && !validImportances[*update.Importance] {
+		return nil, ErrInvalidImportance
+	}
+	phrase, err := s.getPhrase(materialID, id, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Text != nil {
+		text := strings.TrimSpace(*update.Text)
+		if text == "" {
+			return nil, ErrEmptyText
+		}
+		if text != phrase.Text {
+			phrase.Text = text
+			phrase.UserEdited = true
+		}
+	}
+	if update.Importance != nil && *update.Importance != phrase.Importance {
+		phrase.Importance = *update.Importance
+		phrase.UserEdited = true
+	}
+	if update.Starred != nil {
+		phrase.Starred = *update.Starred
+	}
+	if err := s.store.UpdatePhrase(phrase); err != nil {
+		return nil, err
+	}
+	s.search.IndexPhrases([]models.Phrase{*phrase})
+	return phrase, nil
+}
+
+func (s *phraseService) DeletePhrase(materialID, id uint, UserUID string) error {
+	phrase, err := s.getPhrase(materialID, id, UserUID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeletePhrases([]uint{uint(phrase.ID)}); err != nil {
+		return err
+	}
+	s.search.RemovePhrases([]uint{uint(phrase.ID)})
+	s.embedding.RemovePhrases([]uint{uint(phrase.ID)})
+	return nil
+}
+
+func (s *phraseService) ReorderPhrases(materialID uint, UserUID string, ids []uint) error {
+	if _, err := s.MaterialService.GetMaterialByID(materialID, UserUID); err != nil {
+		return ErrMaterialNotFound
+	}
+	return s.store.ReorderPhrases(materialID, ids)
+}
+
+func (s *phraseService) getPhrase(materialID, id uint, UserUID string) (*models.Phrase, error) {
+	phrase, err := s.store.GetPhraseByID(id, materialID, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPhraseNotFound
+	}
+	return phrase, err
+}
+
+func normalizePhrase(text string) string {
+	return nlp.Normalize(text)
+}
+
+// determineImportance rates a phrase from the reference word list rather than
+// trusting the model, so the same phrase always gets the same importance. The
+// list is English; phrases in other languages are all rated medium.
+func determineImportance(text, code string) string {
+	if !language.IsEnglish(code) {
+		return "medium"
+	}
+	return levels.Importance(text)
 }
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"time"
+
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/srs"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+// progressService moves the spaced-repetition schedules of phrases and words
+// forward as they are reviewed or used
+type progressService struct {
+	store stores.ProgressStore
+}
+
+// Review grades the user's review of the items of kind among ids, on srs's scale
+func (s *progressService) Review(UserUID, kind string, ids []uint, grade int) error {
+	return s.update(UserUID, kind, ids, func(p *models.Progress, now time.Time) {
+		review(p, grade, now)
+	})
+}
+
+// Use records that the user used the items of kind among ids. A use of an item
+// that is due counts as a successful review; earlier uses are only counted, as
+// using an item again and again in one session says little about recall.
+func (s *progressService) Use(UserUID, kind string, ids []uint) error {
+	return s.update(UserUID, kind, ids, func(p *models.Progress, now time.Time) {
+		if p.ID != 0 && p.DueAt.After(now) {
+			p.Exposures++
+			return
+		}
+		review(p, srs.Good, now)
+	})
+}
+
+func review(p *models.Progress, grade int, now time.Time) {
+	state := srs.Review(srs.State{Repetitions: p.Repetitions, IntervalDays: p.IntervalDays, Ease: p.Ease}, grade, now)
+	p.Repetitions, p.IntervalDays, p.Ease, p.DueAt = state.Repetitions, state.IntervalDays, state.Ease, state.DueAt
+	p.LastReviewed = now
+}
+
+// update applies apply to the schedule of each item of kind among ids,
+// starting new schedules for items that have none
+func (s *progressService) update(UserUID, kind string, ids []uint, apply func(p *models.Progress, now time.Time)) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	existing, err := s.store.GetProgress(UserUID, kind, ids)
+	if err != nil {
+		return err
+	}
+	byItem := make(map[uint]models.Progress, len(existing))
+	for _, p := range existing {
+		byItem[p.ItemID] = p
+	}
+
+	now := time.Now()
+	progress := make([]models.Progress, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		p, ok := byItem[id]
+		if !ok {
+			p = models.Progress{UserUID: UserUID, Kind: kind, ItemID: id}
+		}
+		apply(&p, now)
+		progress = append(progress, p)
+	}
+	return s.store.SaveProgress(progress)
+}
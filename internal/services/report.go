@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yomek33/talki/internal/annotate"
+	"github.com/yomek33/talki/internal/gemini"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+const (
+	TrendDay   = "day"
+	TrendWeek  = "week"
+	TrendMonth = "month"
+
+	// defaultTrendWindow is how far back trends go when no start is given
+	defaultTrendWindow = 90 * 24 * time.Hour
+	reportTimeout      = 60 * time.Second
+)
+
+type ReportService interface {
+	// CreateReport assesses the learner's messages of a chat and stores the report
+	CreateReport(chatID uint, UserUID string) (*models.ChatReport, error)
+	GetReport(id uint, UserUID string) (*models.ChatReport, error)
+	ListReports(UserUID string, chatID uint, page stores.PageQuery) (*stores.Page[models.ChatReport], error)
+	// GetTrends averages the user's report scores per interval from since on
+	GetTrends(UserUID, interval string, since *time.Time) ([]ReportTrend, error)
+}
+
+// ReportTrend holds the average scores of the reports of one period
+type ReportTrend struct {
+	PeriodStart     time.Time `json:"period_start"`
+	Reports         int       `json:"reports"`
+	Fluency         float64   `json:"fluency"`
+	Accuracy        float64   `json:"accuracy"`
+	VocabularyRange float64   `json:"vocabulary_range"`
+	PhraseUsage     float64   `json:"phrase_usage"`
+	Overall         float64   `json:"overall"`
+}
+
+var (
+	ErrReportNotFound  = errors.New("report not found")
+	ErrEmptyTranscript = errors.New("chat has no learner messages to report on")
+	ErrInvalidInterval = errors.New("interval must be day, week or month")
+)
+
+type reportService struct {
+	store         stores.ReportStore
+	chatStore     stores.ChatStore
+	materialStore stores.MaterialStore
+	phraseStore   stores.PhraseStore
+	geminiClient  *gemini.Client
+}
+
+func NewReportService(rs stores.ReportStore, cs stores.ChatStore, ms stores.MaterialStore, ps stores.PhraseStore, gc *gemini.Client) ReportService {
+	return &reportService{store: rs, chatStore: cs, materialStore: ms, phraseStore: ps, geminiClient: gc}
+}
+
+func (s *reportService) CreateReport(chatID uint, UserUID string) (*models.ChatReport, error) {
+	chat, err := s.chatStore.GetChatByChatID(chatID, UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+	// alternatives the learner edited away are not part of the conversation
+	chat.Messages = activePath(chat.Messages, chat.ActiveLeafID)
+	var learnerTexts []string
+	for _, msg := range chat.Messages {
+		if msg.SenderType == "user" {
+			learnerTexts = append(learnerTexts, msg.Content)
+		}
+	}
+	if len(learnerTexts) == 0 {
+		return nil, ErrEmptyTranscript
+	}
+
+	lang := language.Of(language.Default)
+	var phrases []models.Phrase
+	material, err := s.materialStore.GetMaterialByID(chat.MaterialID, UserUID)
+	switch {
+	case err == nil:
+		lang = language.Of(material.Language)
+		if phrases, err = s.phraseStore.GetPhrasesByMaterialID(material.ID); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	phraseTexts := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		phraseTexts[i] = phrase.Text
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	assessment, err := s.geminiClient.AssessConversation(ctx, chat.Messages, lang.Name, phraseTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ChatReport{
+		UserUID:         UserUID,
+		ChatID:          chat.ID,
+		MaterialID:      chat.MaterialID,
+		Mode:            chat.Mode,
+		Fluency:         assessment.Fluency,
+		Accuracy:        assessment.Accuracy,
+		VocabularyRange: assessment.VocabularyRange,
+		PhrasesUsed:     countUsedPhrases(learnerTexts, phraseTexts, lang.Code),
+		PhrasesTotal:    len(phraseTexts),
+		Summary:         assessment.Summary,
+	}
+	scores := []int{report.Fluency, report.Accuracy, report.VocabularyRange}
+	if report.PhrasesTotal > 0 {
+		// materials without phrases have nothing to practice, so usage does not count
+		report.PhraseUsage = report.PhrasesUsed * 100 / report.PhrasesTotal
+		scores = append(scores, report.PhraseUsage)
+	}
+	total := 0
+	for _, score := range scores {
+		total += score
+	}
+	report.Overall = total / len(scores)
+
+	for _, m := range assessment.Mistakes {
+		report.Mistakes = append(report.Mistakes, models.ReportMistake{
+			Original: m.Original, Correction: m.Correction, Explanation: m.Explanation, Count: max(m.Count, 1),
+		})
+	}
+	for _, suggestion := range assessment.Suggestions {
+		report.Suggestions = append(report.Suggestions, models.ReportSuggestion{Phrase: suggestion.Phrase, Reason: suggestion.Reason})
+	}
+
+	if err := s.store.CreateReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *reportService) GetReport(id uint, UserUID string) (*models.ChatReport, error) {
+	report, err := s.store.GetReportByID(id, UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *reportService) ListReports(UserUID string, chatID uint, page stores.PageQuery) (*stores.Page[models.ChatReport], error) {
+	return s.store.ListReports(UserUID, chatID, page)
+}
+
+func (s *reportService) GetTrends(UserUID, interval string, since *time.Time) ([]ReportTrend, error) {
+	if interval == "" {
+		interval = TrendWeek
+	}
+	if interval != TrendDay && interval != TrendWeek && interval != TrendMonth {
+		return nil, ErrInvalidInterval
+	}
+	from := time.Now().Add(-defaultTrendWindow)
+	if since != nil {
+		from = *since
+	}
+
+	reports, err := s.store.GetReportsSince(UserUID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := []ReportTrend{}
+	for _, report := range reports {
+		start := periodStart(report.CreatedAt, interval)
+		if len(trends) == 0 || !trends[len(trends)-1].PeriodStart.Equal(start) {
+			trends = append(trends, ReportTrend{PeriodStart: start})
+		}
+		trend := &trends[len(trends)-1]
+		trend.Reports++
+		trend.Fluency += float64(report.Fluency)
+		trend.Accuracy += float64(report.Accuracy)
+		trend.VocabularyRange += float64(report.VocabularyRange)
+		trend.PhraseUsage += float64(report.PhraseUsage)
+		trend.Overall += float64(report.Overall)
+	}
+	for i := range trends {
+		n := float64(trends[i].Reports)
+		trends[i].Fluency /= n
+		trends[i].Accuracy /= n
+		trends[i].VocabularyRange /= n
+		trends[i].PhraseUsage /= n
+		trends[i].Overall /= n
+	}
+	return trends, nil
+}
+
+// periodStart truncates t to the start of its day, ISO week (Monday) or month in UTC
+func periodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case TrendWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case TrendMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// countUsedPhrases counts the phrases the learner used in any of their
+// messages, matching them as usage detection does
+func countUsedPhrases(texts, phrases []string, code string) int {
+	items := make([]annotate.Item, len(phrases))
+	for i, phrase := range phrases {
+		items[i] = annotate.Item{Kind: models.VocabularyKindPhrase, ID: uint(i), Text: phrase}
+	}
+	used := map[uint]bool{}
+	for _, text := range texts {
+		for _, o := range annotate.MatchFuzzy(annotate.Segment(text, code), items, code) {
+			used[o.ID] = true
+		}
+	}
+	return len(used)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/srs"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type ReviewService interface {
+	// GetQueue returns the phrases and words due for review, most overdue first.
+	// Starred items are due until their first review.
+	GetQueue(UserUID string, limit int) (*ReviewQueue, error)
+	// RecordResults marks the reviewed words as known or no longer known and
+	// schedules their next review
+	RecordResults(UserUID string, results []ReviewResult) error
+}
+
+// ReviewResult is the outcome of reviewing one word
+type ReviewResult struct {
+	WordID uint `json:"word_id"`
+	Known  bool `json:"known"`
+}
+
+type ReviewQueue struct {
+	Phrases []models.Phrase `json:"phrases"`
+	Words   []models.Word   `json:"words"`
+}
+
+type reviewService struct {
+	phraseStore   stores.PhraseStore
+	wordStore     stores.WordStore
+	materialStore stores.MaterialStore
+	knownWords    *knownWordService
+	progress      *progressService
+}
+
+func NewReviewService(ps stores.PhraseStore, ws stores.WordStore, ms stores.MaterialStore, ks stores.KnownWordStore, us stores.UserStore, prs stores.ProgressStore) ReviewService {
+	return &reviewService{phraseStore: ps, wordStore: ws, materialStore: ms, knownWords: &knownWordService{store: ks, userStore: us}, progress: &progressService{store: prs}}
+}
+
+func (s *reviewService) GetQueue(UserUID string, limit int) (*ReviewQueue, error) {
+	now := time.Now()
+	phrases, err := s.phraseStore.ListDuePhrases(UserUID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	words, err := s.wordStore.ListDueWords(UserUID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewQueue{Phrases: phrases, Words: words}, nil
+}
+
+func (s *reviewService) RecordResults(UserUID string, results []ReviewResult) error {
+	if len(results) == 0 {
+		return ErrNoWords
+	}
+	ids := make([]uint, len(results))
+	for i, result := range results {
+		ids[i] = result.WordID
+	}
+	words, err := s.wordStore.GetWordsByIDs(ids, UserUID)
+	if err != nil {
+		return err
+	}
+	materialIDs := make([]uint, 0, len(words))
+	for _, word := range words {
+		materialIDs = append(materialIDs, word.MaterialID)
+	}
+	materials, err := s.materialStore.GetMaterialsByIDs(materialIDs, UserUID)
+	if err != nil {
+		return err
+	}
+	languages := make(map[uint]string, len(materials))
+	for _, material := range materials {
+		languages[material.ID] = language.Of(material.Language).Code
+	}
+	byID := make(map[uint]models.Word, len(words))
+	for _, word := range words {
+		byID[word.ID] = word
+	}
+
+	// known words are kept per language, that of each word's material
+	known := map[string][]string{}
+	unknown := map[string][]string{}
+	var recalled, forgotten []uint
+	for _, result := range results {
+		word, ok := byID[result.WordID]
+		if !ok {
+			return ErrWordNotFound
+		}
+		code := languages[word.MaterialID]
+		if code == "" {
+			code = language.Default
+		}
+		if result.Known {
+			known[code] = append(known[code], word.Text)
+			recalled = append(recalled, word.ID)
+		} else {
+			unknown[code] = append(unknown[code], word.Text)
+			forgotten = append(forgotten, word.ID)
+		}
+	}
+	for code, texts := range known {
+		if err := s.knownWords.MarkKnown(UserUID, code, texts, models.KnownSourceReview); err != nil && !errors.Is(err, ErrNoWords) {
+			return err
+		}
+	}
+	for code, texts := range unknown {
+		if err := s.knownWords.MarkUnknown(UserUID, code, texts); err != nil && !errors.Is(err, ErrNoWords) {
+			return err
+		}
+	}
+	if err := s.progress.Review(UserUID, models.VocabularyKindWord, recalled, srs.Good); err != nil {
+		return err
+	}
+	return s.progress.Review(UserUID, models.VocabularyKindWord, forgotten, srs.Again)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+// BuiltinScenarios is the scenario library every user can start a chat in,
+// one per built-in mode
+var BuiltinScenarios = []models.Scenario{
+	{
+		Mode:        models.ModeFreeTalk,
+		Title:       "Free talk",
+		Description: "An open conversation about whatever the learner brings up.",
+		Persona:     "a friendly, curious conversation partner who asks follow-up questions",
+		Goals:       "keep the conversation going and try out new words and phrases",
+	},
+	{
+		Mode:            models.ModeJobInterview,
+		Title:           "Job interview",
+		Description:     "A job interview for a role the learner names at the start.",
+		Persona:         "a professional but friendly hiring manager who asks one interview question at a time",
+		Goals:           "introduce yourself, describe your experience with concrete examples and ask the interviewer a question",
+		SuccessCriteria: "the learner has answered at least five questions with examples and asked a question of their own",
+	},
+	{
+		Mode:            models.ModeDebate,
+		Title:           "Debate",
+		Description:     "A debate on a claim from the material, with the bot taking the opposite side.",
+		Persona:         "a polite but persistent debater who argues against the learner's position and asks for evidence",
+		Goals:           "state a position, back it with reasons and respond to counterarguments",
+		SuccessCriteria: "the learner has defended their position against three counterarguments",
+		UsesMaterial:    true,
+	},
+	{
+		Mode:            models.ModeRestaurant,
+		Title:           "Restaurant",
+		Description:     "Eating out, from being seated to paying the bill.",
+		Persona:         "a waiter at a busy restaurant who recommends dishes and asks about allergies",
+		Goals:           "ask about the menu, order a meal with a drink and ask for the bill",
+		SuccessCriteria: "the learner has ordered food and a drink and asked for the bill",
+	},
+	{
+		Mode:            models.ModeSocratic,
+		Title:           "Socratic Q&A",
+		Description:     "Questions on the material that lead the learner to explain it in their own words.",
+		Persona:         "a patient tutor who only asks questions, never lectures, and builds each question on the learner's last answer",
+		Goals:           "explain the main ideas of the material and the reasons behind them",
+		SuccessCriteria: "the learner has explained the main ideas of the material in their own words",
+		UsesMaterial:    true,
+	},
+}
+
+type ScenarioService interface {
+	// ListScenarios returns the built-in scenarios followed by the user's own
+	ListScenarios(UserUID string) ([]models.Scenario, error)
+	GetScenario(id uint, UserUID string) (*models.Scenario, error)
+	CreateScenario(scenario *models.Scenario) error
+	UpdateScenario(scenario *models.Scenario) error
+	DeleteScenario(id uint, UserUID string) error
+	// ScenarioOf returns the scenario a chat plays out
+	ScenarioOf(chat *models.Chat) (*models.Scenario, error)
+	// ValidateMode checks that a chat's mode exists and, for custom chats,
+	// that the user owns its scenario
+	ValidateMode(chat *models.Chat) error
+}
+
+var (
+	ErrScenarioTitleRequired   = errors.New("scenario title cannot be empty")
+	ErrScenarioPersonaRequired = errors.New("scenario persona cannot be empty")
+	ErrScenarioNotFound        = errors.New("scenario not found")
+	ErrInvalidChatMode         = errors.New("mode must be free_talk, job_interview, debate, restaurant, socratic or custom")
+	ErrScenarioRequired        = errors.New("custom chats need a scenario_id")
+)
+
+type scenarioService struct {
+	store stores.ScenarioStore
+}
+
+func NewScenarioService(ss stores.ScenarioStore) ScenarioService {
+	return &scenarioService{store: ss}
+}
+
+func (s *scenarioService) ListScenarios(UserUID string) ([]models.Scenario, error) {
+	custom, err := s.store.GetScenarios(UserUID)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]models.Scenario{}, BuiltinScenarios...), custom...), nil
+}
+
+func (s *scenarioService) GetScenario(id uint, UserUID string) (*models.Scenario, error) {
+	scenario, err := s.store.GetScenarioByID(id, UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScenarioNotFound
+		}
+		return nil, err
+	}
+	return scenario, nil
+}
+
+func (s *scenarioService) CreateScenario(scenario *models.Scenario) error {
+	if err := normalizeScenario(scenario); err != nil {
+		return err
+	}
+	return s.store.CreateScenario(scenario)
+}
+
+func (s *scenarioService) UpdateScenario(scenario *models.Scenario) error {
+	if err := normalizeScenario(scenario); err != nil {
+		return err
+	}
+	if err := s.store.UpdateScenario(scenario); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrScenarioNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *scenarioService) DeleteScenario(id uint, UserUID string) error {
+	if err := s.store.DeleteScenario(id, UserUID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrScenarioNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *scenarioService) ScenarioOf(chat *models.Chat) (*models.Scenario, error) {
+	if chat.Mode == models.ModeCustom {
+		if chat.ScenarioID == nil {
+			return nil, ErrScenarioRequired
+		}
+		return s.GetScenario(*chat.ScenarioID, chat.UserUID)
+	}
+	mode := chat.Mode
+	if mode == "" {
+		mode = models.ModeFreeTalk
+	}
+	scenario, ok := builtinScenario(mode)
+	if !ok {
+		return nil, ErrInvalidChatMode
+	}
+	return &scenario, nil
+}
+
+func (s *scenarioService) ValidateMode(chat *models.Chat) error {
+	if chat.Mode == "" {
+		chat.Mode = models.ModeFreeTalk
+	}
+	if chat.Mode != models.ModeCustom {
+		// only custom chats follow a stored scenario
+		chat.ScenarioID = nil
+	}
+	_, err := s.ScenarioOf(chat)
+	return err
+}
+
+func builtinScenario(mode string) (models.Scenario, bool) {
+	for _, scenario := range BuiltinScenarios {
+		if scenario.Mode == mode {
+			return scenario, true
+		}
+	}
+	return models.Scenario{}, false
+}
+
+// normalizeScenario trims a user's scenario and marks it custom
+func normalizeScenario(scenario *models.Scenario) error {
+	if scenario == nil {
+		return errors.New("scenario cannot be nil")
+	}
+	scenario.Mode = models.ModeCustom
+	scenario.Title = strings.TrimSpace(scenario.Title)
+	scenario.Persona = strings.TrimSpace(scenario.Persona)
+	if scenario.Title == "" {
+		return ErrScenarioTitleRequired
+	}
+	if scenario.Persona == "" {
+		return ErrScenarioPersonaRequired
+	}
+	return nil
+}
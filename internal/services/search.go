@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/search"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type SearchService interface {
+	Search(UserUID string, q search.Query) ([]search.Result, error)
+	Reindex() error
+	IndexMaterial(material *models.Material)
+	RemoveMaterial(id uint)
+	ReindexMaterial(id uint)
+	IndexPhrases(phrases []models.Phrase)
+	RemovePhrases(ids []uint)
+	IndexWords(words []models.Word)
+	RemoveWords(ids []uint)
+	IndexMessage(message *models.Message, chat *models.Chat)
+}
+
+// searchService keeps the search index in sync with writes. Index failures are
+// logged rather than returned so that search never blocks the main write path.
+type searchService struct {
+	index search.Index
+	store stores.SearchStore
+}
+
+func NewSearchService(index search.Index, store stores.SearchStore) SearchService {
+	return &searchService{index: index, store: store}
+}
+
+func (s *searchService) Search(UserUID string, q search.Query) ([]search.Result, error) {
+	return s.index.Search(UserUID, q)
+}
+
+// Reindex loads every searchable row into the index. It is only needed for
+// backends that do not read from the database directly.
+func (s *searchService) Reindex() error {
+	docs, err := s.store.LoadDocuments()
+	if err != nil {
+		return fmt.Errorf("failed to load search documents: %w", err)
+	}
+	if err := s.index.Index(docs...); err != nil {
+		return fmt.Errorf("failed to index documents: %w", err)
+	}
+	logger.Infof("Search index rebuilt, DocumentCount: %v", len(docs))
+	return nil
+}
+
+func (s *searchService) IndexMaterial(material *models.Material) {
+	if s == nil || material == nil {
+		return
+	}
+	s.indexDocs(search.Document{
+		Kind:       search.KindMaterial,
+		ID:         material.ID,
+		UserUID:    material.UserUID,
+		MaterialID: material.ID,
+		Title:      material.Title,
+		Text:       material.Content,
+	})
+}
+
+// RemoveMaterial removes a material with its phrases, words and messages
+func (s *searchService) RemoveMaterial(id uint) {
+	if s == nil {
+		return
+	}
+	if err := s.index.RemoveMaterial(id); err != nil {
+		logger.Errorf("Failed to remove material from search index: %v, MaterialID: %v", err, id)
+	}
+}
+
+// ReindexMaterial indexes a material with its phrases, words and messages again
+func (s *searchService) ReindexMaterial(id uint) {
+	if s == nil {
+		return
+	}
+	docs, err := s.store.LoadMaterialDocuments(id)
+	if err != nil {
+		logger.Errorf("Failed to load material search documents: %v, MaterialID: %v", err, id)
+		return
+	}
+	s.indexDocs(docs...)
+}
+
+func (s *searchService) IndexPhrases(phrases []models.Phrase) {
+	if s == nil || len(phrases) == 0 {
+		return
+	}
+	docs := make([]search.Document, 0, len(phrases))
+	for _, phrase := range phrases {
+		docs = append(docs, search.Document{
+			Kind:       search.KindPhrase,
+			ID:         uint(phrase.ID),
+			MaterialID: phrase.MaterialID,
+			Text:       phrase.Text,
+		})
+	}
+	s.indexDocs(docs...)
+}
+
+func (s *searchService) RemovePhrases(ids []uint) {
+	if s == nil || len(ids) == 0 {
+		return
+	}
+	if err := s.index.Remove(search.KindPhrase, ids...); err != nil {
+		logger.Errorf("Failed to remove phrases from search index: %v, PhraseCount: %v", err, len(ids))
+	}
+}
+
+func (s *searchService) IndexWords(words []models.Word) {
+	if s == nil || len(words) == 0 {
+		return
+	}
+	docs := make([]search.Document, 0, len(words))
+	for _, word := range words {
+		docs = append(docs, search.Document{
+			Kind:       search.KindWord,
+			ID:         word.ID,
+			MaterialID: word.MaterialID,
+			Text:       word.Text,
+		})
+	}
+	s.indexDocs(docs...)
+}
+
+func (s *searchService) RemoveWords(ids []uint) {
+	if s == nil || len(ids) == 0 {
+		return
+	}
+	if err := s.index.Remove(search.KindWord, ids...); err != nil {
+		logger.Errorf("Failed to remove words from search index: %v, WordCount: %v", err, len(ids))
+	}
+}
+
+func (s *searchService) IndexMessage(message *models.Message, chat *models.Chat) {
+	if s == nil || message == nil || chat == nil || chat.UserUID == "" {
+		return
+	}
+	s.indexDocs(search.Document{
+		Kind:       search.KindMessage,
+		ID:         message.ID,
+		UserUID:    chat.UserUID,
+		MaterialID: chat.MaterialID,
+		ChatID:     message.ChatID,
+		Text:       message.Content,
+	})
+}
+
+func (s *searchService) indexDocs(docs ...search.Document) {
+	if err := s.index.Index(docs...); err != nil {
+		logger.Errorf("Failed to update search index: %v", err)
+	}
+}
@@ -1,26 +1,85 @@
 package services
 
 import (
+	"github.com/yomek33/talki/internal/config"
+	"github.com/yomek33/talki/internal/dictionary"
+	"github.com/yomek33/talki/internal/embedding"
 	"github.com/yomek33/talki/internal/gemini"
+	"github.com/yomek33/talki/internal/search"
 	"github.com/yomek33/talki/internal/stores"
 )
 
 type Services struct {
-	UserService     *userService
-	MaterialService *materialService
-	PhraseService   *phraseService
-	ChatService     *chatService
-	MessageService  *messageService
-	GeminiClient    *gemini.Client
+	UserService        *userService
+	MaterialService    *materialService
+	PhraseService      *phraseService
+	ChatService        *chatService
+	MessageService     *messageService
+	ImportService      *importService
+	SearchService      *searchService
+	EmbeddingService   *embeddingService
+	TagService         *tagService
+	CollectionService  *collectionService
+	SharingService     *sharingService
+	TrashService       *trashService
+	WordService        *wordService
+	ReviewService      *reviewService
+	JobService         *jobService
+	VocabularyService  *vocabularyService
+	KnownWordService   *knownWordService
+	AnnotationService  *annotationService
+	DictionaryService  *dictionaryService
+	TranslationService *translationService
+	ScenarioService    *scenarioService
+	ReportService      *reportService
+	UsageService       *usageService
+	GeminiClient       *gemini.Client
 }
 
-func NewServices(s *stores.Stores, geminiClient *gemini.Client) *Services {
+func NewServices(s *stores.Stores, geminiClient *gemini.Client, dict *dictionary.Dictionary, cfg *config.Config) *Services {
+	var index search.Index = s.SearchStore
+	if cfg.SearchBackend == config.SearchBackendMemory {
+		index = search.NewMemoryIndex()
+	}
+	searchService := &searchService{index: index, store: s.SearchStore}
+
+	var embedder embedding.Provider = geminiClient
+	if cfg.EmbeddingProvider == config.EmbeddingProviderFake {
+		embedder = embedding.NewFakeProvider()
+	}
+
+	embeddingService := &embeddingService{provider: embedder, store: s.EmbeddingStore, materialStore: s.MaterialStore, phraseStore: s.PhraseStore}
+	materialService := &materialService{store: s.MaterialStore, revisions: s.RevisionStore, search: searchService, embedding: embeddingService}
+	phraseService := &phraseService{store: s.PhraseStore, MaterialService: materialService, GeminiClient: geminiClient, search: searchService, embedding: embeddingService}
+	knownWordService := &knownWordService{store: s.KnownWordStore, userStore: s.UserStore}
+	scenarioService := &scenarioService{store: s.ScenarioStore}
+	progressService := &progressService{store: s.ProgressStore}
+	usageService := &usageService{store: s.UsageStore, chatStore: s.ChatStore, materialStore: s.MaterialStore, phraseStore: s.PhraseStore, wordStore: s.WordStore, progress: progressService}
+
 	return &Services{
-		UserService:     &userService{store: s.UserStore},
-		MaterialService: &materialService{store: s.MaterialStore},
-		PhraseService:   &phraseService{store: s.PhraseStore, MaterialService: &materialService{store: s.MaterialStore}, GeminiClient: geminiClient},
-		ChatService:     &chatService{chatStore: s.ChatStore, messageStore: s.MessageStore},
-		MessageService:  &messageService{store: s.MessageStore, chatStore: s.ChatStore, geminiClient: geminiClient},
-		GeminiClient:    geminiClient,
+		UserService:        &userService{store: s.UserStore},
+		MaterialService:    materialService,
+		PhraseService:      phraseService,
+		ChatService:        &chatService{chatStore: s.ChatStore, messageStore: s.MessageStore, scenarios: scenarioService},
+		MessageService:     &messageService{store: s.MessageStore, chatStore: s.ChatStore, materialStore: s.MaterialStore, phraseStore: s.PhraseStore, scenarios: scenarioService, usage: usageService, geminiClient: geminiClient, search: searchService},
+		ImportService:      &importService{materialStore: s.MaterialStore, materialService: materialService, phraseStore: s.PhraseStore, highlightStore: s.HighlightStore, search: searchService},
+		SearchService:      searchService,
+		EmbeddingService:   embeddingService,
+		TagService:         &tagService{store: s.TagStore},
+		CollectionService:  &collectionService{store: s.CollectionStore},
+		SharingService:     &sharingService{store: s.MaterialStore, search: searchService},
+		TrashService:       &trashService{store: s.TrashStore, search: searchService},
+		WordService:        &wordService{store: s.WordStore, materialStore: s.MaterialStore, search: searchService, dictionary: dict},
+		ReviewService:      &reviewService{phraseStore: s.PhraseStore, wordStore: s.WordStore, materialStore: s.MaterialStore, knownWords: knownWordService, progress: progressService},
+		JobService:         &jobService{store: s.JobStore, materialStore: s.MaterialStore, materialService: materialService, phraseService: phraseService, embedding: embeddingService},
+		VocabularyService:  &vocabularyService{store: s.VocabularyStore, knownWords: knownWordService},
+		KnownWordService:   knownWordService,
+		AnnotationService:  &annotationService{materialStore: s.MaterialStore, phraseStore: s.PhraseStore, wordStore: s.WordStore},
+		DictionaryService:  &dictionaryService{dictionary: dict},
+		TranslationService: &translationService{store: s.TranslationStore, userStore: s.UserStore, chatStore: s.ChatStore, messageStore: s.MessageStore, materialStore: s.MaterialStore, geminiClient: geminiClient},
+		ScenarioService:    scenarioService,
+		ReportService:      &reportService{store: s.ReportStore, chatStore: s.ChatStore, materialStore: s.MaterialStore, phraseStore: s.PhraseStore, geminiClient: geminiClient},
+		UsageService:       usageService,
+		GeminiClient:       geminiClient,
 	}
 }
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+type SharingService interface {
+	// ShareMaterial changes the visibility of a material. Link materials get a share token,
+	// which is kept while they stay link unless rotate is set; private and public clear it.
+	// Materials made link from public get a new token, as the old one was shown to readers.
+	ShareMaterial(id uint, UserUID, visibility string, rotate bool) (*models.Material, error)
+	GetReadableMaterial(id uint, UserUID string) (*models.Material, error)
+	GetSharedMaterial(token string) (*models.Material, error)
+	GetPublicMaterials(query stores.MaterialQuery) (*stores.Page[models.Material], error)
+	ForkMaterial(id uint, UserUID string) (*models.Material, error)
+	ForkSharedMaterial(token, UserUID string) (*models.Material, error)
+}
+
+type sharingService struct {
+	store  stores.MaterialStore
+	search *searchService
+}
+
+var ErrInvalidVisibility = errors.New("visibility must be private, link or public")
+
+func NewSharingService(ms stores.MaterialStore) SharingService {
+	return &sharingService{store: ms}
+}
+
+func (s *sharingService) ShareMaterial(id uint, UserUID, visibility string, rotate bool) (*models.Material, error) {
+	if visibility != models.VisibilityPrivate && visibility != models.VisibilityLink && visibility != models.VisibilityPublic {
+		return nil, ErrInvalidVisibility
+	}
+	material, err := s.store.GetMaterialByID(id, UserUID)
+	if err != nil {
+		return nil, ErrMaterialNotFound
+	}
+
+	token := material.ShareToken
+	switch {
+	case visibility != models.VisibilityLink:
+		token = nil
+	case token == nil || rotate || material.Visibility == models.VisibilityPublic:
+		generated, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		token = &generated
+	}
+
+	if err := s.store.UpdateMaterialSharing(id, UserUID, visibility, token); err != nil {
+		return nil, fmt.Errorf("failed to update sharing: %w", err)
+	}
+	material.Visibility = visibility
+	material.ShareToken = token
+	logger.Infof("Updated material sharing, MaterialID: %v, Visibility: %v, UserUID: %v", id, visibility, UserUID)
+	return material, nil
+}
+
+func (s *sharingService) GetReadableMaterial(id uint, UserUID string) (*models.Material, error) {
+	material, err := s.store.GetReadableMaterial(id, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMaterialNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return redactForReader(material, UserUID), nil
+}
+
+func (s *sharingService) GetSharedMaterial(token string) (*models.Material, error) {
+	if token == "" {
+		return nil, ErrMaterialNotFound
+	}
+	material, err := s.store.GetMaterialByShareToken(token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMaterialNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return redactForReader(material, ""), nil
+}
+
+func (s *sharingService) GetPublicMaterials(query stores.MaterialQuery) (*stores.Page[models.Material], error) {
+	page, err := s.store.GetPublicMaterials(query)
+	if err != nil {
+		return nil, err
+	}
+	for i := range page.Items {
+		redactForReader(&page.Items[i], "")
+	}
+	return page, nil
+}
+
+// redactForReader hides the owner and the share token of a material from
+// anyone but its owner
+func redactForReader(material *models.Material, UserUID string) *models.Material {
+	if material.UserUID != UserUID {
+		material.UserUID = ""
+		material.ShareToken = nil
+	}
+	return material
+}
+
+// ForkMaterial copies a material the user can read (their own or a public one)
+func (s *sharingService) ForkMaterial(id uint, UserUID string) (*models.Material, error) {
+	source, err := s.GetReadableMaterial(id, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.fork(source, UserUID)
+}
+
+// ForkSharedMaterial copies a material reached through its share link
+func (s *sharingService) ForkSharedMaterial(token, UserUID string) (*models.Material, error) {
+	source, err := s.GetSharedMaterial(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.fork(source, UserUID)
+}
+
+func (s *sharingService) fork(source *models.Material, UserUID string) (*models.Material, error) {
+	fork, err := s.store.ForkMaterial(source, UserUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork material: %w", err)
+	}
+	s.search.IndexMaterial(fork)
+	s.search.IndexPhrases(fork.Phrases)
+	logger.Infof("Forked material, SourceID: %v, MaterialID: %v, UserUID: %v", source.ID, fork.ID, UserUID)
+	return fork, nil
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
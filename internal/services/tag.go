@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+)
+
+type TagService interface {
+	CreateTag(tag *models.Tag) error
+	GetTags(UserUID string) ([]models.Tag, error)
+	UpdateTag(tag *models.Tag) error
+	DeleteTag(id uint, UserUID string) error
+	AssignTags(materialIDs, tagIDs []uint, UserUID string) error
+	UnassignTags(materialIDs, tagIDs []uint, UserUID string) error
+}
+
+type tagService struct {
+	store stores.TagStore
+}
+
+var (
+	ErrTagNameRequired  = errors.New("tag name cannot be empty")
+	ErrEmptyAssignment  = errors.New("material_ids and tag_ids cannot be empty")
+	ErrTooManyMaterials = errors.New("too many materials in one request")
+)
+
+// maxBulkMaterials bounds bulk tag and collection assignments
+const maxBulkMaterials = 500
+
+func NewTagService(ts stores.TagStore) TagService {
+	return &tagService{store: ts}
+}
+
+func (s *tagService) CreateTag(tag *models.Tag) error {
+	if tag == nil {
+		return errors.New("tag cannot be nil")
+	}
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		return ErrTagNameRequired
+	}
+	return s.store.CreateTag(tag)
+}
+
+func (s *tagService) GetTags(UserUID string) ([]models.Tag, error) {
+	return s.store.GetTags(UserUID)
+}
+
+func (s *tagService) UpdateTag(tag *models.Tag) error {
+	if tag == nil {
+		return errors.New("tag cannot be nil")
+	}
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		return ErrTagNameRequired
+	}
+	return s.store.UpdateTag(tag)
+}
+
+func (s *tagService) DeleteTag(id uint, UserUID string) error {
+	return s.store.DeleteTag(id, UserUID)
+}
+
+func (s *tagService) AssignTags(materialIDs, tagIDs []uint, UserUID string) error {
+	if err := validateAssignment(materialIDs, tagIDs); err != nil {
+		return err
+	}
+	return s.store.AssignTags(materialIDs, tagIDs, UserUID)
+}
+
+func (s *tagService) UnassignTags(materialIDs, tagIDs []uint, UserUID string) error {
+	if err := validateAssignment(materialIDs, tagIDs); err != nil {
+		return err
+	}
+	return s.store.UnassignTags(materialIDs, tagIDs, UserUID)
+}
+
+func validateAssignment(materialIDs, tagIDs []uint) error {
+	if len(materialIDs) == 0 || len(tagIDs) == 0 {
+		return ErrEmptyAssignment
+	}
+	if len(materialIDs) > maxBulkMaterials {
+		return ErrTooManyMaterials
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yomek33/talki/internal/gemini"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/nlp"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+// NativeLanguages are the languages phrases can be translated into, by ISO
+// 639-1 code. Readers get no translations of materials in their own language.
+var NativeLanguages = map[string]string{
+	"en": "English",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"ko": "Korean",
+	"es": "Spanish",
+	"pt": "Portuguese",
+	"fr": "French",
+	"de": "German",
+	"it": "Italian",
+	"vi": "Vietnamese",
+	"th": "Thai",
+	"id": "Indonesian",
+}
+
+const (
+	// translationBatchSize is the number of texts sent in one translation request
+	translationBatchSize = 50
+	translationTimeout   = 30 * time.Second
+)
+
+type TranslationService interface {
+	// AttachPhraseTranslations sets the translations of the phrases of one material
+	// into the user's native language
+	AttachPhraseTranslations(phrases []models.Phrase, UserUID string) error
+	// AttachWordTranslations sets the translations of the words of one material
+	// into the user's native language
+	AttachWordTranslations(words []models.Word, UserUID string) error
+	// TranslateMessage translates a bot message of the user's chat
+	TranslateMessage(chatID, id uint, UserUID string) (*MessageTranslation, error)
+}
+
+type MessageTranslation struct {
+	MessageID   uint   `json:"message_id"`
+	Language    string `json:"language"`
+	Translation string `json:"translation"`
+}
+
+var (
+	ErrUnsupportedLanguage = errors.New("unsupported native language")
+	ErrChatNotFound        = errors.New("chat not found")
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrNotBotMessage       = errors.New("only bot messages can be translated")
+)
+
+type translationService struct {
+	store         stores.TranslationStore
+	userStore     stores.UserStore
+	chatStore     stores.ChatStore
+	messageStore  stores.MessageStore
+	materialStore stores.MaterialStore
+	geminiClient  *gemini.Client
+}
+
+func NewTranslationService(ts stores.TranslationStore, us stores.UserStore, cs stores.ChatStore, ms stores.MessageStore, mts stores.MaterialStore, gc *gemini.Client) TranslationService {
+	return &translationService{store: ts, userStore: us, chatStore: cs, messageStore: ms, materialStore: mts, geminiClient: gc}
+}
+
+func (s *translationService) AttachPhraseTranslations(phrases []models.Phrase, UserUID string) error {
+	if len(phrases) == 0 {
+		return nil
+	}
+	texts := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		texts[i] = phrase.Text
+	}
+	translations, err := s.translateFor(UserUID, phrases[0].MaterialID, texts)
+	if err != nil {
+		return err
+	}
+	for i := range phrases {
+		phrases[i].Translation = translations[nlp.Key(phrases[i].Text)]
+	}
+	return nil
+}
+
+func (s *translationService) AttachWordTranslations(words []models.Word, UserUID string) error {
+	if len(words) == 0 {
+		return nil
+	}
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.Text
+	}
+	translations, err := s.translateFor(UserUID, words[0].MaterialID, texts)
+	if err != nil {
+		return err
+	}
+	for i := range words {
+		words[i].Translation = translations[nlp.Key(words[i].Text)]
+	}
+	return nil
+}
+
+func (s *translationService) TranslateMessage(chatID, id uint, UserUID string) (*MessageTranslation, error) {
+	chat, err := s.chatStore.GetChatByChatID(chatID, UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+	message, err := s.messageStore.GetMessage(chatID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if message.SenderType != "bot" {
+		return nil, ErrNotBotMessage
+	}
+
+	language, err := s.nativeLanguage(UserUID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.materialLanguage(chat.MaterialID, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	translation := MessageTranslation{MessageID: message.ID, Language: language, Translation: message.Content}
+	if language == source {
+		return &translation, nil
+	}
+	translations, err := s.translate([]string{message.Content}, source, language)
+	if err != nil {
+		return nil, err
+	}
+	translation.Translation = translations[nlp.Key(message.Content)]
+	return &translation, nil
+}
+
+// translateFor translates texts of a material into the user's native language,
+// keyed by nlp.Key; it returns no translations when the material is in that language
+func (s *translationService) translateFor(UserUID string, materialID uint, texts []string) (map[string]string, error) {
+	language, err := s.nativeLanguage(UserUID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.materialLanguage(materialID, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	if language == source {
+		return map[string]string{}, nil
+	}
+	return s.translate(texts, source, language)
+}
+
+// materialLanguage returns the language code of a material the user can read
+func (s *translationService) materialLanguage(materialID uint, UserUID string) (string, error) {
+	material, err := s.materialStore.GetReadableMaterial(materialID, UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrMaterialNotFound
+		}
+		return "", err
+	}
+	return language.Of(material.Language).Code, nil
+}
+
+func (s *translationService) nativeLanguage(UserUID string) (string, error) {
+	user, err := s.userStore.GetUserByUserUID(UserUID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || user.NativeLanguage == "" {
+		return models.DefaultNativeLanguage, nil
+	}
+	return user.NativeLanguage, nil
+}
+
+// translate returns the translations of texts from source into target keyed
+// by nlp.Key, taking cached ones from the store and translating the rest in batches
+func (s *translationService) translate(texts []string, source, target string) (map[string]string, error) {
+	name, ok := NativeLanguages[target]
+	if !ok {
+		return nil, ErrUnsupportedLanguage
+	}
+	sourceName := language.Of(source).Name
+
+	keys := make([]string, 0, len(texts))
+	pending := make(map[string]string, len(texts))
+	for _, text := range texts {
+		key := nlp.Key(text)
+		if _, ok := pending[key]; ok || nlp.Normalize(text) == "" {
+			continue
+		}
+		keys = append(keys, key)
+		pending[key] = text
+	}
+
+	translations := make(map[string]string, len(keys))
+	cached, err := s.store.GetTranslations(source, target, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range cached {
+		translations[t.Key] = t.Translation
+		delete(pending, t.Key)
+	}
+
+	missing := make([]string, 0, len(pending))
+	for _, key := range keys {
+		if _, ok := pending[key]; ok {
+			missing = append(missing, key)
+		}
+	}
+	for start := 0; start < len(missing); start += translationBatchSize {
+		batch := missing[start:min(start+translationBatchSize, len(missing))]
+		batchTexts := make([]string, len(batch))
+		for i, key := range batch {
+			batchTexts[i] = pending[key]
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), translationTimeout)
+		translated, err := s.geminiClient.TranslateTexts(ctx, batchTexts, sourceName, name)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		rows := make([]models.Translation, len(batch))
+		for i, key := range batch {
+			translations[key] = translated[i]
+			rows[i] = models.Translation{Source: source, Language: target, Key: key, Text: batchTexts[i], Translation: translated[i]}
+		}
+		if err := s.store.SaveTranslations(rows); err != nil {
+			return nil, err
+		}
+	}
+	return translations, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yomek33/talki/internal/logger"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+// trashPurgeInterval is how often trashed materials past their retention are purged
+const trashPurgeInterval = time.Hour
+
+type TrashService interface {
+	ListTrash(UserUID string, page stores.PageQuery) (*stores.Page[models.Material], error)
+	RestoreMaterial(id uint, UserUID string) (*models.Material, error)
+	PurgeMaterial(id uint, UserUID string) error
+	// PurgeExpired permanently deletes materials trashed longer than retention ago
+	PurgeExpired(retention time.Duration) (int, error)
+	// RunScheduledPurge calls PurgeExpired periodically until ctx is done
+	RunScheduledPurge(ctx context.Context, retention time.Duration)
+}
+
+type trashService struct {
+	store  stores.TrashStore
+	search *searchService
+}
+
+func NewTrashService(ts stores.TrashStore) TrashService {
+	return &trashService{store: ts}
+}
+
+func (s *trashService) ListTrash(UserUID string, page stores.PageQuery) (*stores.Page[models.Material], error) {
+	return s.store.ListTrash(UserUID, page)
+}
+
+func (s *trashService) RestoreMaterial(id uint, UserUID string) (*models.Material, error) {
+	material, err := s.store.RestoreMaterial(id, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMaterialNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.search.ReindexMaterial(id)
+	logger.Infof("Restored material, MaterialID: %v, UserUID: %v", id, UserUID)
+	return material, nil
+}
+
+func (s *trashService) PurgeMaterial(id uint, UserUID string) error {
+	err := s.store.PurgeMaterial(id, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMaterialNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.search.RemoveMaterial(id)
+	logger.Infof("Purged material, MaterialID: %v, UserUID: %v", id, UserUID)
+	return nil
+}
+
+func (s *trashService) PurgeExpired(retention time.Duration) (int, error) {
+	ids, err := s.store.PurgeDeletedBefore(time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		s.search.RemoveMaterial(id)
+	}
+	return len(ids), nil
+}
+
+func (s *trashService) RunScheduledPurge(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := s.PurgeExpired(retention)
+		if err != nil {
+			logger.Errorf("Failed to purge trash: %v", err)
+		} else if purged > 0 {
+			logger.Infof("Purged expired trash, MaterialCount: %v", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
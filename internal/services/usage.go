@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/annotate"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+type UsageService interface {
+	// DetectUsage finds the phrases and words of the chat's material in a
+	// learner message, records each as a usage event and counts it as a
+	// successful review
+	DetectUsage(chat *models.Chat, message *models.Message) ([]models.UsageEvent, error)
+	// DetectEditUsage is DetectUsage for a message that replaces an edited one:
+	// the usage events of replaced move to message, and only items replaced
+	// didn't use count as reviews, so an edit doesn't count a use twice
+	DetectEditUsage(chat *models.Chat, message, replaced *models.Message) ([]models.UsageEvent, error)
+	// GetUsage lists the usage events of one of the user's chats
+	GetUsage(chatID uint, UserUID string) ([]models.UsageEvent, error)
+}
+
+type usageService struct {
+	store         stores.UsageStore
+	chatStore     stores.ChatStore
+	materialStore stores.MaterialStore
+	phraseStore   stores.PhraseStore
+	wordStore     stores.WordStore
+	progress      *progressService
+}
+
+func NewUsageService(us stores.UsageStore, cs stores.ChatStore, ms stores.MaterialStore, ps stores.PhraseStore, ws stores.WordStore, prs stores.ProgressStore) UsageService {
+	return &usageService{store: us, chatStore: cs, materialStore: ms, phraseStore: ps, wordStore: ws, progress: &progressService{store: prs}}
+}
+
+func (s *usageService) DetectUsage(chat *models.Chat, message *models.Message) ([]models.UsageEvent, error) {
+	return s.detect(chat, message, nil)
+}
+
+func (s *usageService) DetectEditUsage(chat *models.Chat, message, replaced *models.Message) ([]models.UsageEvent, error) {
+	return s.detect(chat, message, replaced)
+}
+
+func (s *usageService) detect(chat *models.Chat, message, replaced *models.Message) ([]models.UsageEvent, error) {
+	if s == nil || chat == nil || message == nil {
+		return nil, nil
+	}
+	counted := map[annotate.Item]bool{}
+	if replaced != nil {
+		previous, err := s.store.GetMessageUsageEvents(replaced.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range previous {
+			counted[annotate.Item{Kind: e.Kind, ID: e.ItemID}] = true
+		}
+	}
+	material, err := s.materialStore.GetMaterialByID(chat.MaterialID, chat.UserUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// nothing to practice once the material is gone
+			return nil, nil
+		}
+		return nil, err
+	}
+	items, err := materialItems(s.phraseStore, s.wordStore, material.ID)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	occurrences := annotate.MatchFuzzy(annotate.Segment(message.Content, material.Language), items, material.Language)
+	if len(occurrences) == 0 && len(counted) == 0 {
+		return nil, nil
+	}
+
+	content := []rune(message.Content)
+	events := make([]models.UsageEvent, 0, len(occurrences))
+	used := map[string][]uint{}
+	seen := map[annotate.Item]bool{}
+	for _, o := range occurrences {
+		key := annotate.Item{Kind: o.Kind, ID: o.ID}
+		if seen[key] {
+			// one message counts once per item, however often it repeats it
+			continue
+		}
+		seen[key] = true
+		events = append(events, models.UsageEvent{
+			UserUID:    chat.UserUID,
+			ChatID:     chat.ID,
+			MessageID:  message.ID,
+			MaterialID: material.ID,
+			Kind:       o.Kind,
+			ItemID:     o.ID,
+			Text:       string(content[o.Start:o.End]),
+			Exact:      o.Exact,
+		})
+		if !counted[key] {
+			used[o.Kind] = append(used[o.Kind], o.ID)
+		}
+	}
+	if replaced != nil {
+		err = s.store.ReplaceUsageEvents(replaced.ID, events)
+	} else {
+		err = s.store.CreateUsageEvents(events)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for kind, ids := range used {
+		if err := s.progress.Use(chat.UserUID, kind, ids); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+func (s *usageService) GetUsage(chatID uint, UserUID string) ([]models.UsageEvent, error) {
+	if _, err := s.chatStore.GetChatByChatID(chatID, UserUID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+	return s.store.GetUsageEvents(chatID, UserUID)
+}
@@ -5,6 +5,7 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/yomek33/talki/internal/language"
 	"github.com/yomek33/talki/internal/models"
 	"github.com/yomek33/talki/internal/stores"
 )
@@ -15,8 +16,18 @@ type UserService interface {
 	UpdateUser(user *models.User) error
 	DeleteUser(UserUID string) error
 	CheckHashPassword(user *models.User, password string) bool
+	// UpdateSettings changes the languages the user learns and reads translations in
+	UpdateSettings(UserUID string, settings UserSettings) (*models.User, error)
 }
 
+// UserSettings holds the settings a user changes; nil fields are left as they are
+type UserSettings struct {
+	NativeLanguage *string `json:"native_language"`
+	TargetLanguage *string `json:"target_language"`
+}
+
+var ErrUserNotFound = errors.New("user not found")
+
 type userService struct {
 	store stores.UserStore
 }
@@ -46,6 +57,36 @@ func (s *userService) DeleteUser(UserUID string) error {
 	return s.store.DeleteUser(UserUID)
 }
 
+func (s *userService) UpdateSettings(UserUID string, settings UserSettings) (*models.User, error) {
+	if settings.NativeLanguage != nil {
+		if _, ok := NativeLanguages[*settings.NativeLanguage]; !ok {
+			return nil, ErrUnsupportedLanguage
+		}
+	}
+	if settings.TargetLanguage != nil {
+		if _, ok := language.Get(*settings.TargetLanguage); !ok {
+			return nil, ErrUnsupportedTargetLanguage
+		}
+	}
+	user, err := s.store.GetUserByUserUID(UserUID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if settings.NativeLanguage != nil {
+		user.NativeLanguage = *settings.NativeLanguage
+	}
+	if settings.TargetLanguage != nil {
+		user.TargetLanguage = *settings.TargetLanguage
+	}
+	if err := s.store.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func (s *userService) CheckHashPassword(user *models.User, password string) bool {
 	//err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	return true
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/nlp"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
+
+type VocabularyService interface {
+	// ListEntries returns the user's notebook, one entry per normalized phrase or
+	// word of a language together with every material it appears in
+	ListEntries(query stores.VocabularyQuery, UserUID string) (*stores.Page[models.VocabularyEntry], error)
+	// ExportEntries returns every notebook entry matching query
+	ExportEntries(query stores.VocabularyQuery, UserUID string) ([]models.VocabularyEntry, error)
+	UpdateEntry(id uint, UserUID string, update VocabularyUpdate) (*models.VocabularyEntry, error)
+}
+
+// VocabularyUpdate holds the fields a learner changes on an entry; nil fields are left as they are
+type VocabularyUpdate struct {
+	Status *string `json:"status"`
+	Note   *string `json:"note"`
+}
+
+var (
+	ErrVocabularyNotFound = errors.New("vocabulary entry not found")
+	ErrInvalidVocabStatus = errors.New("status must be new, learning or known")
+	ErrInvalidVocabKind   = errors.New("kind must be phrase or word")
+)
+
+type vocabularyService struct {
+	store      stores.VocabularyStore
+	knownWords *knownWordService
+}
+
+func NewVocabularyService(vs stores.VocabularyStore, ks stores.KnownWordStore, us stores.UserStore) VocabularyService {
+	return &vocabularyService{store: vs, knownWords: &knownWordService{store: ks, userStore: us}}
+}
+
+func (s *vocabularyService) ListEntries(q stores.VocabularyQuery, UserUID string) (*stores.Page[models.VocabularyEntry], error) {
+	if err := validateVocabularyQuery(q); err != nil {
+		return nil, err
+	}
+	if err := s.sync(UserUID); err != nil {
+		return nil, err
+	}
+	return s.store.ListEntries(q, UserUID)
+}
+
+func (s *vocabularyService) ExportEntries(q stores.VocabularyQuery, UserUID string) ([]models.VocabularyEntry, error) {
+	if err := validateVocabularyQuery(q); err != nil {
+		return nil, err
+	}
+	if err := s.sync(UserUID); err != nil {
+		return nil, err
+	}
+	return s.store.ExportEntries(q, UserUID)
+}
+
+func (s *vocabularyService) UpdateEntry(id uint, UserUID string, update VocabularyUpdate) (*models.VocabularyEntry, error) {
+	entry, err := s.store.GetEntry(id, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVocabularyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	previous := entry.Status
+	if update.Status != nil {
+		if !validVocabularyStatus(*update.Status) {
+			return nil, ErrInvalidVocabStatus
+		}
+		entry.Status = *update.Status
+	}
+	if update.Note != nil {
+		entry.Note = *update.Note
+	}
+	if err := s.store.UpdateEntry(entry); err != nil {
+		return nil, err
+	}
+
+	// marking a word known in the notebook counts as manual marking
+	if entry.Kind == models.VocabularyKindWord && entry.Status != previous {
+		code := language.Of(entry.Language).Code
+		var err error
+		switch {
+		case entry.Status == models.VocabularyKnown:
+			err = s.knownWords.MarkKnown(UserUID, code, []string{entry.Text}, models.KnownSourceManual)
+		case previous == models.VocabularyKnown:
+			err = s.knownWords.MarkUnknown(UserUID, code, []string{entry.Text})
+		}
+		if err != nil && !errors.Is(err, ErrNoWords) {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// sync brings the notebook up to date with the phrases and words of the user's
+// materials. Entries are never deleted, so a status survives the material it
+// came from being trashed and restored; entries without a live source are
+// simply not listed.
+func (s *vocabularyService) sync(UserUID string) error {
+	items, err := s.store.ListVocabularyItems(UserUID)
+	if err != nil {
+		return err
+	}
+
+	var entries []models.VocabularyEntry
+	index := map[string]int{}
+	for _, item := range items {
+		text := nlp.Normalize(item.Text)
+		if text == "" {
+			continue
+		}
+		entry := models.VocabularyEntry{Kind: item.Kind, Language: language.Of(item.Language).Code, Key: nlp.Key(text), Text: text}
+		key := entry.Kind + ":" + entry.Language + ":" + entry.Key
+		i, ok := index[key]
+		if !ok {
+			i = len(entries)
+			index[key] = i
+			entries = append(entries, entry)
+		}
+		entries[i].Sources = append(entries[i].Sources, models.VocabularySource{ItemID: item.ItemID, MaterialID: item.MaterialID})
+	}
+	return s.store.SyncEntries(UserUID, entries)
+}
+
+func validateVocabularyQuery(q stores.VocabularyQuery) error {
+	if q.Kind != "" && q.Kind != models.VocabularyKindPhrase && q.Kind != models.VocabularyKindWord {
+		return ErrInvalidVocabKind
+	}
+	if q.Status != "" && !validVocabularyStatus(q.Status) {
+		return ErrInvalidVocabStatus
+	}
+	return nil
+}
+
+func validVocabularyStatus(status string) bool {
+	switch status {
+	case models.VocabularyNew, models.VocabularyLearning, models.VocabularyKnown:
+		return true
+	}
+	return false
+}
@@ -1,11 +1,175 @@
 package services
 
-// type WordService interface {
-// 	GenerateWords(topic string) ([]string, error)
-// }
+import (
+	"errors"
+	"strings"
 
-// type wordService struct {
-// 	GeminiClient *gemini.Client
-// }
+	"github.com/yomek33/talki/internal/dictionary"
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/levels"
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/stores"
+	"gorm.io/gorm"
+)
 
+type WordService interface {
+	ListWords(materialID uint, UserUID string, page stores.PageQuery) (*stores.Page[models.Word], error)
+	CreateWord(materialID uint, UserUID string, word *models.Word) error
+	UpdateWord(materialID, id uint, UserUID string, update WordUpdate) (*models.Word, error)
+	DeleteWord(materialID, id uint, UserUID string) error
+	ReorderWords(materialID uint, UserUID string, ids []uint) error
+}
 
+// WordUpdate holds the fields a learner changes on a word; nil fields are left as they are
+type WordUpdate struct {
+	Text       *string `json:"text"`
+	Importance *string `json:"importance"`
+	Level      *string `json:"level"`
+	Starred    *bool   `json:"starred"`
+}
+
+type wordService struct {
+	store         stores.WordStore
+	materialStore stores.MaterialStore
+	search        *searchService
+	dictionary    *dictionary.Dictionary
+}
+
+var ErrWordNotFound = errors.New("word not found")
+
+func NewWordService(ws stores.WordStore, ms stores.MaterialStore, dict *dictionary.Dictionary) WordService {
+	return &wordService{store: ws, materialStore: ms, dictionary: dict}
+}
+
+func (s *wordService) ListWords(materialID uint, UserUID string, page stores.PageQuery) (*stores.Page[models.Word], error) {
+	return s.store.ListWords(materialID, UserUID, page)
+}
+
+// CreateWord adds a learner-written word at the end of the material's words
+func (s *wordService) CreateWord(materialID uint, UserUID string, word *models.Word) error {
+	if word == nil {
+		return errors.New("word cannot be nil")
+	}
+	word.Text = strings.TrimSpace(word.Text)
+	if word.Text == "" {
+		return ErrEmptyText
+	}
+	if word.Importance != "" && !validImportances[word.Importance] {
+		return ErrInvalidImportance
+	}
+	material, err := s.materialStore.GetMaterialByID(materialID, UserUID)
+	if err != nil {
+		return ErrMaterialNotFound
+	}
+	word.ID = 0
+	word.Level = resolveWordLevel(word.Text, word.Level, material.Language)
+	word.MaterialID = materialID
+	word.Position = 0
+	word.UserEdited = true
+	s.define(word, material.Language)
+	if err := s.store.CreateWord(word); err != nil {
+		return err
+	}
+	s.search.IndexWords([]models.Word{*word})
+	return nil
+}
+
+func (s *wordService) UpdateWord(materialID, id uint, UserUID string, update WordUpdate) (*models.Word, error) {
+	if update.Importance != nil && !validImportances[*update.Importance] {
+		return nil, ErrInvalidImportance
+	}
+	word, err := s.getWord(materialID, id, UserUID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Text != nil {
+		text := strings.TrimSpace(*update.Text)
+		if text == "" {
+			return nil, ErrEmptyText
+		}
+		if text != word.Text {
+			material, err := s.materialStore.GetMaterialByID(materialID, UserUID)
+			if err != nil {
+				return nil, ErrMaterialNotFound
+			}
+			word.Text = text
+			word.UserEdited = true
+			if update.Level == nil {
+				word.Level = resolveWordLevel(text, "", material.Language)
+			}
+			word.Definition, word.Pronunciation = "", ""
+			s.define(word, material.Language)
+		}
+	}
+	if update.Importance != nil && *update.Importance != word.Importance {
+		word.Importance = *update.Importance
+		word.UserEdited = true
+	}
+	if update.Level != nil && *update.Level != word.Level {
+		word.Level = *update.Level
+		word.UserEdited = true
+	}
+	if update.Starred != nil {
+		word.Starred = *update.Starred
+	}
+	if err := s.store.UpdateWord(word); err != nil {
+		return nil, err
+	}
+	s.search.IndexWords([]models.Word{*word})
+	return word, nil
+}
+
+func (s *wordService) DeleteWord(materialID, id uint, UserUID string) error {
+	word, err := s.getWord(materialID, id, UserUID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteWords([]uint{word.ID}); err != nil {
+		return err
+	}
+	s.search.RemoveWords([]uint{word.ID})
+	return nil
+}
+
+func (s *wordService) ReorderWords(materialID uint, UserUID string, ids []uint) error {
+	if _, err := s.materialStore.GetMaterialByID(materialID, UserUID); err != nil {
+		return ErrMaterialNotFound
+	}
+	return s.store.ReorderWords(materialID, ids)
+}
+
+// resolveWordLevel checks a level provided for word against the English
+// reference list; levels of words in other languages are kept as given
+func resolveWordLevel(word, provided, code string) string {
+	if !language.IsEnglish(code) {
+		return provided
+	}
+	return levels.Resolve(word, provided)
+}
+
+// define fills in the definition and pronunciation of word from the English
+// dictionary, leaving them empty for words it does not contain and for words
+// of materials in other languages
+func (s *wordService) define(word *models.Word, code string) {
+	if s.dictionary == nil || !language.IsEnglish(code) {
+		return
+	}
+	entry, ok := s.dictionary.Lookup(word.Text)
+	if !ok {
+		return
+	}
+	if len(entry.Senses) > 0 {
+		word.Definition = entry.Senses[0].Definition
+	}
+	if len(entry.Pronunciations) > 0 {
+		word.Pronunciation = entry.Pronunciations[0]
+	}
+}
+
+func (s *wordService) getWord(materialID, id uint, UserUID string) (*models.Word, error) {
+	word, err := s.store.GetWordByID(id, materialID, UserUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWordNotFound
+	}
+	return word, err
+}
@@ -0,0 +1,57 @@
+// Package srs schedules reviews of phrases and words with the SM-2 spaced
+// repetition algorithm.
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+// Review grades from SM-2's 0 to 5 scale. Grades below Hard are lapses that
+// start the item over.
+const (
+	Again = 1
+	Hard  = 3
+	Good  = 4
+	Easy  = 5
+)
+
+const (
+	DefaultEase = 2.5
+	minEase     = 1.3
+)
+
+// State is the schedule of one item. The zero value is a new item.
+type State struct {
+	Repetitions  int       `json:"repetitions"`
+	IntervalDays int       `json:"interval_days"`
+	Ease         float64   `json:"ease"`
+	DueAt        time.Time `json:"due_at"`
+}
+
+// Review returns the state after reviewing the item at now with grade
+func Review(s State, grade int, now time.Time) State {
+	if s.Ease == 0 {
+		s.Ease = DefaultEase
+	}
+
+	if grade < Hard {
+		s.Repetitions = 0
+		s.IntervalDays = 1
+	} else {
+		switch s.Repetitions {
+		case 0:
+			s.IntervalDays = 1
+		case 1:
+			s.IntervalDays = 6
+		default:
+			s.IntervalDays = int(math.Round(float64(s.IntervalDays) * s.Ease))
+		}
+		s.Repetitions++
+	}
+
+	q := float64(5 - grade)
+	s.Ease = math.Max(minEase, s.Ease+0.1-q*(0.08+q*0.02))
+	s.DueAt = now.AddDate(0, 0, s.IntervalDays)
+	return s
+}
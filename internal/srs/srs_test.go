@@ -0,0 +1,31 @@
+package srs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReview(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := Review(State{}, Good, now)
+	assert.Equal(t, 1, s.Repetitions)
+	assert.Equal(t, 1, s.IntervalDays)
+	assert.Equal(t, DefaultEase, s.Ease)
+	assert.Equal(t, now.AddDate(0, 0, 1), s.DueAt)
+
+	s = Review(s, Good, now)
+	assert.Equal(t, 6, s.IntervalDays)
+
+	s = Review(s, Easy, now)
+	assert.Equal(t, 3, s.Repetitions)
+	assert.Equal(t, 15, s.IntervalDays)
+	assert.InDelta(t, 2.6, s.Ease, 1e-9)
+
+	s = Review(s, Again, now)
+	assert.Equal(t, 0, s.Repetitions)
+	assert.Equal(t, 1, s.IntervalDays)
+	assert.InDelta(t, 2.06, s.Ease, 1e-9)
+}
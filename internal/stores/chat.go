@@ -3,6 +3,7 @@ package stores
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/yomek33/talki/internal/models"
 	"gorm.io/gorm"
@@ -13,6 +14,14 @@ type ChatStore interface {
 	GetChatByChatID(id uint, UserUID string) (*models.Chat, error)
 	GetChatsByMaterialID(materialID uint, userUID string) ([]models.Chat, error)
 	UpdateChat(chat *models.Chat) error
+	ListChatsByMaterialID(materialID uint, userUID string, page PageQuery) (*Page[models.Chat], error)
+	// ClaimReply marks a reply in the chat as pending under token unless one
+	// already is, reporting whether it did. A claim older than staleAfter is
+	// taken over, as the request that made it is gone.
+	ClaimReply(chatID uint, UserUID, token string, staleAfter time.Duration) (bool, error)
+	// ReleaseReply clears the pending reply of the chat if it is still the
+	// one claimed under token
+	ReleaseReply(chatID uint, token string) error
 }
 
 type chatStore struct {
@@ -53,3 +62,35 @@ func (r *chatStore) UpdateChat(chat *models.Chat) error {
 		return tx.Model(&models.Chat{}).Where("id = ?", chat.ID).Updates(chat).Error
 	})
 }
+
+func (s *chatStore) ListChatsByMaterialID(materialID uint, userUID string, page PageQuery) (*Page[models.Chat], error) {
+	query := s.DB.Model(&models.Chat{}).Where("material_id = ? AND user_uid = ?", materialID, userUID)
+	return paginateByID(query, "chats", page, func(c models.Chat) uint { return c.ID })
+}
+
+func (s *chatStore) ClaimReply(chatID uint, UserUID, token string, staleAfter time.Duration) (bool, error) {
+	result := s.DB.Model(&models.Chat{}).
+		Where("id = ? AND user_uid = ? AND (pending_message = 0 OR updated_at < ?)", chatID, UserUID, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{"pending_message": 1, "reply_token": token})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Chat{}).Where("id = ? AND user_uid = ?", chatID, UserUID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, gorm.ErrRecordNotFound
+	}
+	return false, nil
+}
+
+func (s *chatStore) ReleaseReply(chatID uint, token string) error {
+	return s.DB.Model(&models.Chat{}).
+		Where("id = ? AND reply_token = ?", chatID, token).
+		Updates(map[string]interface{}{"pending_message": 0, "reply_token": ""}).Error
+}
@@ -0,0 +1,105 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type CollectionStore interface {
+	CreateCollection(collection *models.Collection) error
+	GetCollections(UserUID string) ([]models.Collection, error)
+	GetCollectionByID(id uint, UserUID string) (*models.Collection, error)
+	UpdateCollection(collection *models.Collection) error
+	DeleteCollection(id uint, UserUID string) error
+	AddMaterials(collectionID uint, materialIDs []uint, UserUID string) error
+	RemoveMaterials(collectionID uint, materialIDs []uint, UserUID string) error
+}
+
+type collectionStore struct {
+	BaseStore
+}
+
+func (s *collectionStore) CreateCollection(collection *models.Collection) error {
+	if collection == nil {
+		return errors.New("collection cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Omit("Materials").Create(collection).Error
+	})
+}
+
+func (s *collectionStore) GetCollections(UserUID string) ([]models.Collection, error) {
+	var collections []models.Collection
+	err := s.DB.Where("user_uid = ?", UserUID).Order("name").Find(&collections).Error
+	return collections, err
+}
+
+func (s *collectionStore) GetCollectionByID(id uint, UserUID string) (*models.Collection, error) {
+	var collection models.Collection
+	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).First(&collection).Error
+	return &collection, err
+}
+
+func (s *collectionStore) UpdateCollection(collection *models.Collection) error {
+	if collection == nil {
+		return errors.New("collection cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Collection{}).Where("id = ? AND user_uid = ?", collection.ID, collection.UserUID).
+			Updates(map[string]interface{}{"name": collection.Name, "parent_id": collection.ParentID}).Error
+	})
+}
+
+// DeleteCollection removes a collection and moves its sub-collections up to its parent.
+// Materials inside are only unlinked, never deleted.
+func (s *collectionStore) DeleteCollection(id uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		var collection models.Collection
+		if err := tx.Where("id = ? AND user_uid = ?", id, UserUID).First(&collection).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Collection{}).Where("parent_id = ?", id).Update("parent_id", collection.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM collection_materials WHERE collection_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+}
+
+func (s *collectionStore) AddMaterials(collectionID uint, materialIDs []uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		collection, materials, err := loadOwnedCollectionAndMaterials(tx, collectionID, materialIDs, UserUID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(collection).Omit("Materials.*").Association("Materials").Append(materials)
+	})
+}
+
+func (s *collectionStore) RemoveMaterials(collectionID uint, materialIDs []uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if _, _, err := loadOwnedCollectionAndMaterials(tx, collectionID, materialIDs, UserUID); err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM collection_materials WHERE collection_id = ? AND material_id IN ?", collectionID, materialIDs).Error
+	})
+}
+
+func loadOwnedCollectionAndMaterials(tx *gorm.DB, collectionID uint, materialIDs []uint, UserUID string) (*models.Collection, []models.Material, error) {
+	var collection models.Collection
+	if err := tx.Where("id = ? AND user_uid = ?", collectionID, UserUID).First(&collection).Error; err != nil {
+		return nil, nil, err
+	}
+	var materials []models.Material
+	if err := tx.Select("id").Where("id IN ? AND user_uid = ?", materialIDs, UserUID).Find(&materials).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(materials) != len(uniqueIDs(materialIDs)) {
+		return nil, nil, ErrUnknownMaterialsOrTags
+	}
+	return &collection, materials, nil
+}
@@ -0,0 +1,88 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/embedding"
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// embeddingStore is a brute-force vector store: vectors live in the embeddings
+// table and similarity is computed in Go over the requesting user's rows.
+type embeddingStore struct {
+	BaseStore
+}
+
+func (s *embeddingStore) Upsert(model string, vectors ...embedding.Vector) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+	rows := make([]models.Embedding, 0, len(vectors))
+	for _, v := range vectors {
+		rows = append(rows, models.Embedding{
+			Kind:       v.Kind,
+			RefID:      v.ID,
+			ModelName:  model,
+			UserUID:    v.UserUID,
+			MaterialID: v.MaterialID,
+			Vector:     embedding.EncodeVector(v.Values),
+		})
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kind"}, {Name: "ref_id"}, {Name: "model_name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_uid", "material_id", "vector", "updated_at"}),
+		}).Create(&rows).Error
+	})
+}
+
+func (s *embeddingStore) Delete(kind string, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Unscoped().Where("kind = ? AND ref_id IN ?", kind, ids).Delete(&models.Embedding{}).Error
+	})
+}
+
+func (s *embeddingStore) GetVector(model, kind string, id uint) (*embedding.Vector, error) {
+	var row models.Embedding
+	err := s.DB.Where("model_name = ? AND kind = ? AND ref_id = ?", model, kind, id).First(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, embedding.ErrVectorNotFound
+		}
+		return nil, err
+	}
+	return toVector(row), nil
+}
+
+func (s *embeddingStore) Nearest(model, UserUID string, query []float32, k int, filter embedding.Filter) ([]embedding.Match, error) {
+	var rows []models.Embedding
+	q := s.DB.Select("kind", "ref_id", "user_uid", "material_id", "vector").
+		Where("model_name = ? AND user_uid = ?", model, UserUID)
+	if len(filter.Kinds) > 0 {
+		q = q.Where("kind IN ?", filter.Kinds)
+	}
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	candidates := make([]embedding.Vector, 0, len(rows))
+	for _, row := range rows {
+		candidates = append(candidates, *toVector(row))
+	}
+	return embedding.Rank(query, candidates, k, filter), nil
+}
+
+func toVector(row models.Embedding) *embedding.Vector {
+	return &embedding.Vector{
+		Kind:       row.Kind,
+		ID:         row.RefID,
+		UserUID:    row.UserUID,
+		MaterialID: row.MaterialID,
+		Values:     embedding.DecodeVector(row.Vector),
+	}
+}
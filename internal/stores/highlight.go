@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type HighlightStore interface {
+	CreateHighlights(highlights []models.Highlight) error
+	GetHighlightHashes(materialID uint) (map[string]bool, error)
+	GetHighlightsByMaterialID(materialID uint) ([]models.Highlight, error)
+}
+
+type highlightStore struct {
+	BaseStore
+}
+
+func (s *highlightStore) CreateHighlights(highlights []models.Highlight) error {
+	if len(highlights) == 0 {
+		return nil
+	}
+	for _, highlight := range highlights {
+		if highlight.MaterialID == 0 {
+			return errors.New("highlight MaterialID cannot be empty")
+		}
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Create(&highlights).Error
+	})
+}
+
+func (s *highlightStore) GetHighlightHashes(materialID uint) (map[string]bool, error) {
+	var hashes []string
+	err := s.DB.Model(&models.Highlight{}).Where("material_id = ?", materialID).Pluck("hash", &hashes).Error
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		set[hash] = true
+	}
+	return set, nil
+}
+
+func (s *highlightStore) GetHighlightsByMaterialID(materialID uint) ([]models.Highlight, error) {
+	var highlights []models.Highlight
+	err := s.DB.Where("material_id = ?", materialID).Order("added_at, id").Find(&highlights).Error
+	return highlights, err
+}
@@ -0,0 +1,45 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type JobStore interface {
+	CreateJob(job *models.Job) error
+	GetJob(id uint, UserUID string) (*models.Job, error)
+	UpdateJob(job *models.Job) error
+}
+
+type jobStore struct {
+	BaseStore
+}
+
+func (s *jobStore) CreateJob(job *models.Job) error {
+	if job == nil {
+		return errors.New("job cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Create(job).Error
+	})
+}
+
+func (s *jobStore) GetJob(id uint, UserUID string) (*models.Job, error) {
+	var job models.Job
+	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *jobStore) UpdateJob(job *models.Job) error {
+	if job == nil {
+		return errors.New("job cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Save(job).Error
+	})
+}
@@ -0,0 +1,66 @@
+package stores
+
+import (
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KnownWordStore keeps known lemmas per user and language
+type KnownWordStore interface {
+	ListKnownWords(UserUID, language string, page PageQuery) (*Page[models.KnownWord], error)
+	// AddKnownWords marks lemmas as known; lemmas already known keep their source
+	AddKnownWords(UserUID, language string, lemmas []string, source string) error
+	RemoveKnownWords(UserUID, language string, lemmas []string) error
+	// FilterKnown returns which of lemmas the user knows
+	FilterKnown(UserUID, language string, lemmas []string) (map[string]bool, error)
+}
+
+type knownWordStore struct {
+	BaseStore
+}
+
+func (s *knownWordStore) ListKnownWords(UserUID, language string, page PageQuery) (*Page[models.KnownWord], error) {
+	query := s.DB.Model(&models.KnownWord{}).Where("known_words.user_uid = ? AND known_words.language = ?", UserUID, language)
+	return paginateByID(query, "known_words", page, func(w models.KnownWord) uint { return w.ID })
+}
+
+func (s *knownWordStore) AddKnownWords(UserUID, language string, lemmas []string, source string) error {
+	if len(lemmas) == 0 {
+		return nil
+	}
+	words := make([]models.KnownWord, len(lemmas))
+	for i, lemma := range lemmas {
+		words[i] = models.KnownWord{UserUID: UserUID, Language: language, Lemma: lemma, Source: source}
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(words, 500).Error
+	})
+}
+
+func (s *knownWordStore) RemoveKnownWords(UserUID, language string, lemmas []string) error {
+	if len(lemmas) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Where("user_uid = ? AND language = ? AND lemma IN ?", UserUID, language, lemmas).Delete(&models.KnownWord{}).Error
+	})
+}
+
+func (s *knownWordStore) FilterKnown(UserUID, language string, lemmas []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	if len(lemmas) == 0 {
+		return known, nil
+	}
+	var found []string
+	err := s.DB.Model(&models.KnownWord{}).
+		Where("user_uid = ? AND language = ? AND lemma IN ?", UserUID, language, lemmas).
+		Pluck("lemma", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, lemma := range found {
+		known[lemma] = true
+	}
+	return known, nil
+}
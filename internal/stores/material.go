@@ -2,10 +2,18 @@ package stores
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"time"
 
+	"github.com/yomek33/talki/internal/language"
+	"github.com/yomek33/talki/internal/levels"
 	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/nlp"
+	"github.com/yomek33/talki/internal/readability"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -16,13 +24,81 @@ const (
 type MaterialStore interface {
 	CreateMaterial(material *models.Material) (uint, error)
 	GetMaterialByID(id uint, UserUID string) (*models.Material, error)
+	// UpdateMaterial saves material, leaving its status to UpdateMaterialStatus and ClaimProcessing
 	UpdateMaterial(id uint, material *models.Material) error
 	DeleteMaterial(id uint, UserUID string) error
-	GetAllMaterials(searchQuery string, UserUID string) ([]models.Material, error)
+	GetAllMaterials(query MaterialQuery, UserUID string) (*Page[models.Material], error)
 	UpdateMaterialStatus(id uint, status string) error
 	GetMaterialStatus(id uint) (string, error)
+	// ClaimProcessing marks one of the user's materials as processing unless it
+	// already is, reporting whether it did. A claim older than staleAfter is
+	// taken over, as the work that made it is gone.
+	ClaimProcessing(id uint, UserUID string, staleAfter time.Duration) (bool, error)
+	GetMaterialBySource(title, source, UserUID string) (*models.Material, error)
+	GetMaterialsByIDs(ids []uint, UserUID string) ([]models.Material, error)
+	// GetReadableMaterial returns a material the user owns or that is public
+	GetReadableMaterial(id uint, UserUID string) (*models.Material, error)
+	GetMaterialByShareToken(token string) (*models.Material, error)
+	GetPublicMaterials(query MaterialQuery) (*Page[models.Material], error)
+	UpdateMaterialSharing(id uint, UserUID, visibility string, shareToken *string) error
+	// ForkMaterial copies source with its phrases and words into the user's library
+	ForkMaterial(source *models.Material, UserUID string) (*models.Material, error)
+	// AnalyzeMissing analyzes the content of materials stored before their
+	// lemmas and readability were recorded
+	AnalyzeMissing() (int, error)
 }
 
+const (
+	SortCreated = "created"
+	SortUpdated = "updated"
+	SortTitle   = "title"
+	// SortCoverage orders by the share of the content the user knows
+	SortCoverage = "coverage"
+	// SortLevel orders by estimated CEFR level, whose names sort in level order
+	SortLevel = "level"
+	// SortReadability orders by Flesch reading ease
+	SortReadability = "readability"
+	SortLength      = "length"
+)
+
+// MaterialQuery holds the filters, sort and page of a material listing
+type MaterialQuery struct {
+	PageQuery
+	Search        string
+	Status        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	TagID         uint
+	CollectionIDs []uint
+	// Language is the ISO 639-1 code of the materials' content
+	Language string
+	// MinLevel and MaxLevel bound the estimated CEFR level
+	MinLevel  string
+	MaxLevel  string
+	MaxGrade  *float64
+	Sort      string
+	Ascending bool
+}
+
+var materialSortColumns = map[string]string{
+	SortCreated: "materials.created_at",
+	SortUpdated: "materials.updated_at",
+	SortTitle:   "materials.title",
+	// coverage only exists in the subquery built by withCoverage
+	SortCoverage:    "materials.coverage",
+	SortLevel:       "materials.level",
+	SortReadability: "materials.flesch_reading_ease",
+	SortLength:      "materials.token_count",
+}
+
+// coverageSQL computes the percentage of a material's words the user knows in
+// the material's language
+const coverageSQL = `CASE WHEN materials.token_count = 0 THEN 0 ELSE ROUND(100 * COALESCE((
+	SELECT SUM(material_lemmas.count) FROM material_lemmas
+	JOIN known_words ON known_words.lemma = material_lemmas.lemma AND known_words.user_uid = ?
+		AND known_words.language = materials.language
+	WHERE material_lemmas.material_id = materials.id), 0) / materials.token_count, 1) END`
+
 type materialStore struct {
 	BaseStore
 }
@@ -32,7 +108,10 @@ func (s *materialStore) CreateMaterial(material *models.Material) (uint, error)
 		return 0, errors.New(ErrMaterialCannotBeNil)
 	}
 	err := s.PerformDBTransaction(func(tx *gorm.DB) error {
-		return tx.Create(material).Error
+		if err := tx.Omit(clause.Associations).Create(material).Error; err != nil {
+			return err
+		}
+		return analyzeContent(tx, material)
 	})
 	if err != nil {
 		return 0, err
@@ -43,7 +122,7 @@ func (s *materialStore) CreateMaterial(material *models.Material) (uint, error)
 func (s *materialStore) GetMaterialByID(id uint, UserUID string) (*models.Material, error) {
 	log.Println("store material id", id)
 	var material models.Material
-	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).Preload("Phrases").Preload("Chats").First(&material).Error
+	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).Preload("Tags").First(&material).Error
 	return &material, err
 }
 
@@ -55,24 +134,243 @@ func (s *materialStore) UpdateMaterial(id uint, material *models.Material) error
 		return errors.New(ErrMismatchedMaterialID)
 	}
 	return s.PerformDBTransaction(func(tx *gorm.DB) error {
-		return tx.Model(&models.Material{}).Where("id = ?", id).Updates(material).Error
+		if err := tx.Model(&models.Material{}).Where("id = ?", id).Omit(clause.Associations, "Status").Updates(material).Error; err != nil {
+			return err
+		}
+		if material.Content == "" {
+			return nil
+		}
+		return analyzeContent(tx, material)
 	})
 }
 
+// analyzeContent replaces the lemma counts of a material with those of its
+// content and stores its readability metrics and estimated level
+func analyzeContent(tx *gorm.DB, material *models.Material) error {
+	if err := tx.Where("material_id = ?", material.ID).Delete(&models.MaterialLemma{}).Error; err != nil {
+		return err
+	}
+	counts := nlp.LemmaCountsIn(material.Content, material.Language)
+	lemmas := make([]models.MaterialLemma, 0, len(counts))
+	for lemma, count := range counts {
+		lemmas = append(lemmas, models.MaterialLemma{MaterialID: material.ID, Lemma: lemma, Count: count})
+	}
+	if len(lemmas) > 0 {
+		if err := tx.CreateInBatches(lemmas, 500).Error; err != nil {
+			return err
+		}
+	}
+
+	metrics := readability.AnalyzeIn(material.Content, material.Language)
+	material.TokenCount = metrics.Words
+	material.SentenceCount = metrics.Sentences
+	material.LexicalDiversity = metrics.LexicalDiversity
+	material.FleschReadingEase = metrics.FleschReadingEase
+	material.FleschKincaidGrade = metrics.FleschKincaidGrade
+	material.AutomatedReadabilityIndex = metrics.AutomatedReadabilityIndex
+	if language.IsEnglish(material.Language) {
+		material.Level = levels.EstimateLevel(counts).Level
+	} else {
+		material.Level = language.Of(material.Language).CEFR(material.AdaptedLevel)
+	}
+	return tx.Model(&models.Material{}).Where("id = ?", material.ID).Updates(map[string]interface{}{
+		"token_count":                 material.TokenCount,
+		"sentence_count":              material.SentenceCount,
+		"lexical_diversity":           material.LexicalDiversity,
+		"flesch_reading_ease":         material.FleschReadingEase,
+		"flesch_kincaid_grade":        material.FleschKincaidGrade,
+		"automated_readability_index": material.AutomatedReadabilityIndex,
+		"level":                       material.Level,
+	}).Error
+}
+
+func (s *materialStore) AnalyzeMissing() (int, error) {
+	var materials []models.Material
+	err := s.DB.Select("id", "content", "language", "adapted_level").
+		Where("(token_count = 0 OR (level = '' AND language = ?)) AND content <> ''", language.Default).
+		Find(&materials).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range materials {
+		err := s.PerformDBTransaction(func(tx *gorm.DB) error {
+			return analyzeContent(tx, &materials[i])
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(materials), nil
+}
+
+// DeleteMaterial moves the material to the trash along with its phrases, words and chats
 func (s *materialStore) DeleteMaterial(id uint, UserUID string) error {
 	return s.PerformDBTransaction(func(tx *gorm.DB) error {
-		return tx.Where("id = ? AND user_uid = ?", id, UserUID).Delete(&models.Material{}).Error
+		return trashMaterials(tx, "id = ? AND user_uid = ?", id, UserUID)
 	})
 }
 
-func (s *materialStore) GetAllMaterials(searchQuery string, UserUID string) ([]models.Material, error) {
-	var materials []models.Material
-	query := s.DB.Where("user_uid = ?", UserUID)
-	if searchQuery != "" {
-		query = query.Where("title LIKE ?", "%"+searchQuery+"%")
+func (s *materialStore) GetAllMaterials(q MaterialQuery, UserUID string) (*Page[models.Material], error) {
+	scope := s.DB.Model(&models.Material{}).Where("materials.user_uid = ?", UserUID)
+	return s.listMaterials(s.withCoverage(scope, UserUID), q, true)
+}
+
+// withCoverage wraps scope so that its materials carry the user's coverage
+// as a column that can be sorted on
+func (s *materialStore) withCoverage(scope *gorm.DB, UserUID string) *gorm.DB {
+	scope = scope.Select("materials.*, ("+coverageSQL+") AS coverage", UserUID)
+	return s.DB.Model(&models.Material{}).Table("(?) AS materials", scope)
+}
+
+// GetPublicMaterials lists the public library. Tags and collections belong to
+// their owners, so those filters are ignored here.
+func (s *materialStore) GetPublicMaterials(q MaterialQuery) (*Page[models.Material], error) {
+	q.TagID = 0
+	q.CollectionIDs = nil
+	if q.Sort == SortCoverage {
+		q.Sort = SortCreated
+	}
+	return s.listMaterials(s.DB.Model(&models.Material{}).Where("materials.visibility = ?", models.VisibilityPublic), q, false)
+}
+
+func (s *materialStore) listMaterials(scope *gorm.DB, q MaterialQuery, withTags bool) (*Page[models.Material], error) {
+	column, ok := materialSortColumns[q.Sort]
+	if !ok {
+		column = materialSortColumns[SortCreated]
+		q.Sort = SortCreated
+	}
+
+	query := applyMaterialFilters(scope, q).Session(&gorm.Session{})
+
+	page := &Page[models.Material]{Items: []models.Material{}}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	direction, op := "DESC", "<"
+	if q.Ascending {
+		direction, op = "ASC", ">"
+	}
+
+	paged := query
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		value, err := materialCursorValue(q.Sort, c.Value)
+		if err != nil {
+			return nil, err
+		}
+		paged = paged.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND materials.id %s ?))", column, op, column, op),
+			value, value, c.ID,
+		)
+	}
+
+	if withTags {
+		paged = paged.Preload("Tags")
+	}
+
+	limit := q.limit()
+	err := paged.
+		Order(fmt.Sprintf("%s %s, materials.id %s", column, direction, direction)).
+		Limit(limit + 1).
+		Find(&page.Items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = materialCursor(q.Sort, page.Items[limit-1])
+	}
+	return page, nil
+}
+
+func applyMaterialFilters(query *gorm.DB, q MaterialQuery) *gorm.DB {
+	if q.Search != "" {
+		query = query.Where("materials.title LIKE ?", "%"+q.Search+"%")
+	}
+	if q.Status != "" {
+		query = query.Where("materials.status = ?", q.Status)
+	}
+	if q.CreatedAfter != nil {
+		query = query.Where("materials.created_at >= ?", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		query = query.Where("materials.created_at < ?", *q.CreatedBefore)
+	}
+	if q.Language != "" {
+		query = query.Where("materials.language = ?", q.Language)
+	}
+	if q.MinLevel != "" || q.MaxLevel != "" {
+		query = query.Where("materials.level <> ''")
+	}
+	if q.MinLevel != "" {
+		query = query.Where("materials.level >= ?", q.MinLevel)
+	}
+	if q.MaxLevel != "" {
+		query = query.Where("materials.level <= ?", q.MaxLevel)
+	}
+	if q.MaxGrade != nil {
+		// grades are only computed for English
+		query = query.Where("materials.flesch_kincaid_grade <= ? AND materials.language = ?", *q.MaxGrade, language.Default)
+	}
+	if q.TagID != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM material_tags WHERE material_tags.material_id = materials.id AND material_tags.tag_id = ?)", q.TagID)
+	}
+	if len(q.CollectionIDs) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM collection_materials WHERE collection_materials.material_id = materials.id AND collection_materials.collection_id IN ?)", q.CollectionIDs)
+	}
+	return query
+}
+
+func materialCursor(sort string, material models.Material) string {
+	switch sort {
+	case SortTitle:
+		return encodeCursor(cursor{Value: material.Title, ID: material.ID})
+	case SortLevel:
+		return encodeCursor(cursor{Value: material.Level, ID: material.ID})
+	case SortReadability:
+		return encodeCursor(cursor{Value: strconv.FormatFloat(material.FleschReadingEase, 'f', -1, 64), ID: material.ID})
+	case SortLength:
+		return encodeCursor(cursor{Value: strconv.Itoa(material.TokenCount), ID: material.ID})
+	case SortUpdated:
+		return encodeTimeCursor(material.UpdatedAt, material.ID)
+	case SortCoverage:
+		var coverage float64
+		if material.Coverage != nil {
+			coverage = *material.Coverage
+		}
+		return encodeCursor(cursor{Value: strconv.FormatFloat(coverage, 'f', -1, 64), ID: material.ID})
+	default:
+		return encodeTimeCursor(material.CreatedAt, material.ID)
 	}
-	err := query.Find(&materials).Error
-	return materials, err
+}
+
+func materialCursorValue(sort string, value string) (interface{}, error) {
+	switch sort {
+	case SortTitle, SortLevel:
+		return value, nil
+	case SortCoverage, SortReadability:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return f, nil
+	case SortLength:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return n, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return t, nil
 }
 
 func (s *materialStore) UpdateMaterialStatus(id uint, status string) error {
@@ -84,8 +382,146 @@ func (s *materialStore) UpdateMaterialStatus(id uint, status string) error {
 	})
 }
 
+func (s *materialStore) ClaimProcessing(id uint, UserUID string, staleAfter time.Duration) (bool, error) {
+	result := s.DB.Model(&models.Material{}).
+		Where("id = ? AND user_uid = ? AND (status <> ? OR updated_at < ?)", id, UserUID, models.StatusProcessing, time.Now().Add(-staleAfter)).
+		Update("status", models.StatusProcessing)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Material{}).Where("id = ? AND user_uid = ?", id, UserUID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, gorm.ErrRecordNotFound
+	}
+	return false, nil
+}
+
 func (s *materialStore) GetMaterialStatus(id uint) (string, error) {
 	var material models.Material
 	err := s.DB.Select("status").Where("id = ?", id).First(&material).Error
 	return material.Status, err
 }
+
+func (s *materialStore) GetMaterialBySource(title, source, UserUID string) (*models.Material, error) {
+	var material models.Material
+	err := s.DB.Where("title = ? AND source = ? AND user_uid = ?", title, source, UserUID).First(&material).Error
+	if err != nil {
+		return nil, err
+	}
+	return &material, nil
+}
+
+func (s *materialStore) GetMaterialsByIDs(ids []uint, UserUID string) ([]models.Material, error) {
+	var materials []models.Material
+	if len(ids) == 0 {
+		return materials, nil
+	}
+	err := s.DB.Where("id IN ? AND user_uid = ?", ids, UserUID).Find(&materials).Error
+	return materials, err
+}
+
+func (s *materialStore) GetReadableMaterial(id uint, UserUID string) (*models.Material, error) {
+	var material models.Material
+	err := s.DB.Where("id = ? AND (user_uid = ? OR visibility = ?)", id, UserUID, models.VisibilityPublic).First(&material).Error
+	if err != nil {
+		return nil, err
+	}
+	if material.UserUID == UserUID {
+		if err := s.DB.Model(&material).Association("Tags").Find(&material.Tags); err != nil {
+			return nil, err
+		}
+	}
+	return &material, nil
+}
+
+func (s *materialStore) GetMaterialByShareToken(token string) (*models.Material, error) {
+	var material models.Material
+	err := s.DB.Where("share_token = ? AND visibility IN ?", token, []string{models.VisibilityLink, models.VisibilityPublic}).
+		Preload("Phrases").
+		Preload("Words").
+		First(&material).Error
+	if err != nil {
+		return nil, err
+	}
+	return &material, nil
+}
+
+func (s *materialStore) UpdateMaterialSharing(id uint, UserUID, visibility string, shareToken *string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Material{}).
+			Where("id = ? AND user_uid = ?", id, UserUID).
+			Updates(map[string]interface{}{"visibility": visibility, "share_token": shareToken})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (s *materialStore) ForkMaterial(source *models.Material, UserUID string) (*models.Material, error) {
+	if source == nil {
+		return nil, errors.New(ErrMaterialCannotBeNil)
+	}
+	// the fork gets the finished phrases and words of source, so it is
+	// completed whatever job may still be running on source
+	fork := &models.Material{
+		UserUID:      UserUID,
+		Title:        source.Title,
+		Author:       source.Author,
+		Source:       source.Source,
+		Content:      source.Content,
+		Language:     source.Language,
+		Status:       models.StatusCompleted,
+		Visibility:   models.VisibilityPrivate,
+		ForkedFromID: &source.ID,
+	}
+	err := s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(fork).Error; err != nil {
+			return err
+		}
+		if err := analyzeContent(tx, fork); err != nil {
+			return err
+		}
+
+		var phrases []models.Phrase
+		if err := tx.Where("material_id = ?", source.ID).Find(&phrases).Error; err != nil {
+			return err
+		}
+		// UserEdited is kept so that regenerating the fork keeps hand-written
+		// items; Starred is not, as it picks items for its owner's review queue
+		for _, phrase := range phrases {
+			copied := models.Phrase{MaterialID: fork.ID, Text: phrase.Text, Importance: phrase.Importance, Position: phrase.Position, UserEdited: phrase.UserEdited}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+			fork.Phrases = append(fork.Phrases, copied)
+		}
+
+		var words []models.Word
+		if err := tx.Where("material_id = ?", source.ID).Find(&words).Error; err != nil {
+			return err
+		}
+		for _, word := range words {
+			copied := models.Word{MaterialID: fork.ID, Text: word.Text, Importance: word.Importance, Level: word.Level, Position: word.Position, Definition: word.Definition, Pronunciation: word.Pronunciation, UserEdited: word.UserEdited}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+			fork.Words = append(fork.Words, copied)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
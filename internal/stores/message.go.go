@@ -5,11 +5,26 @@ import (
 
 	"github.com/yomek33/talki/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageStore interface {
 	CreateMessage(message *models.Message) (*models.Message, error)
 	GetMessages(chatID uint) ([]models.Message, error)
+	GetMessage(chatID, id uint) (*models.Message, error)
+	// AppendMessage adds message to the end of its chat's active branch
+	AppendMessage(message *models.Message) error
+	// AddMessage adds message under its ParentID, starting a new branch when
+	// the parent already has children, and makes it the active leaf
+	AddMessage(message *models.Message) error
+	// SetActiveLeaf makes the chat continue from leafID
+	SetActiveLeaf(chatID, leafID uint) error
+	// DiscardMessage deletes a message that got no reply and makes the chat
+	// continue from leaf again
+	DiscardMessage(message *models.Message, leaf *uint) error
+	// GetActiveLeaf returns the active leaf of a chat, first linking the
+	// messages of chats started before branching into one branch
+	GetActiveLeaf(chatID uint) (*uint, error)
 }
 
 type messageStore struct {
@@ -45,3 +60,96 @@ func (s *messageStore) GetMessages(chatID uint) ([]models.Message, error) {
 	err := s.DB.Where("chat_id = ?", chatID).Find(&messages).Error
 	return messages, err
 }
+
+func (s *messageStore) GetMessage(chatID, id uint) (*models.Message, error) {
+	var message models.Message
+	if err := s.DB.Where("id = ? AND chat_id = ?", id, chatID).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (s *messageStore) AppendMessage(message *models.Message) error {
+	if message == nil {
+		return errors.New("message cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		leaf, err := activeLeaf(tx, message.ChatID)
+		if err != nil {
+			return err
+		}
+		message.ParentID = leaf
+		return addMessage(tx, message)
+	})
+}
+
+func (s *messageStore) AddMessage(message *models.Message) error {
+	if message == nil {
+		return errors.New("message cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return addMessage(tx, message)
+	})
+}
+
+func (s *messageStore) SetActiveLeaf(chatID, leafID uint) error {
+	return s.DB.Model(&models.Chat{}).Where("id = ?", chatID).Update("active_leaf_id", leafID).Error
+}
+
+func (s *messageStore) DiscardMessage(message *models.Message, leaf *uint) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Chat{}).Where("id = ?", message.ChatID).Update("active_leaf_id", leaf).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Message{}, message.ID).Error
+	})
+}
+
+func (s *messageStore) GetActiveLeaf(chatID uint) (*uint, error) {
+	var leaf *uint
+	err := s.PerformDBTransaction(func(tx *gorm.DB) error {
+		var err error
+		leaf, err = activeLeaf(tx, chatID)
+		return err
+	})
+	return leaf, err
+}
+
+func addMessage(tx *gorm.DB, message *models.Message) error {
+	if err := tx.Create(message).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Chat{}).Where("id = ?", message.ChatID).Update("active_leaf_id", message.ID).Error
+}
+
+// activeLeaf reads the active leaf of a chat. Chats started before branching
+// have none; their messages are chained in the order they were created.
+func activeLeaf(tx *gorm.DB, chatID uint) (*uint, error) {
+	var chat models.Chat
+	// the row lock serializes appends to one chat until the transaction ends
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "active_leaf_id").Where("id = ?", chatID).First(&chat).Error
+	if err != nil {
+		return nil, err
+	}
+	if chat.ActiveLeafID != nil {
+		return chat.ActiveLeafID, nil
+	}
+
+	var ids []uint
+	if err := tx.Model(&models.Message{}).Where("chat_id = ?", chatID).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	for i := 1; i < len(ids); i++ {
+		if err := tx.Model(&models.Message{}).Where("id = ?", ids[i]).Update("parent_id", ids[i-1]).Error; err != nil {
+			return nil, err
+		}
+	}
+	leaf := ids[len(ids)-1]
+	if err := tx.Model(&models.Chat{}).Where("id = ?", chatID).Update("active_leaf_id", leaf).Error; err != nil {
+		return nil, err
+	}
+	return &leaf, nil
+}
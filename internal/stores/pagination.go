@@ -0,0 +1,134 @@
+package stores
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageQuery selects one page of a listing; Cursor is the opaque value returned as NextCursor
+type PageQuery struct {
+	Cursor string
+	Limit  int
+}
+
+// Page is one page of a listing together with the total number of matching rows
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type cursor struct {
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+func (q PageQuery) limit() int {
+	if q.Limit <= 0 {
+		return DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return q.Limit
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func encodeTimeCursor(t time.Time, id uint) string {
+	return encodeCursor(cursor{Value: t.UTC().Format(time.RFC3339Nano), ID: id})
+}
+
+// paginateByID fetches one page of query ordered by id ascending
+func paginateByID[T any](query *gorm.DB, table string, page PageQuery, idOf func(T) uint) (*Page[T], error) {
+	query = query.Session(&gorm.Session{})
+	result := &Page[T]{Items: []T{}}
+	if err := query.Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	limit := page.limit()
+	paged := query
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		paged = paged.Where(fmt.Sprintf("%s.id > ?", table), c.ID)
+	}
+	if err := paged.Order(fmt.Sprintf("%s.id", table)).Limit(limit + 1).Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) > limit {
+		result.Items = result.Items[:limit]
+		result.NextCursor = encodeCursor(cursor{ID: idOf(result.Items[limit-1])})
+	}
+	return result, nil
+}
+
+// paginateByPosition fetches one page of query ordered by position, then id.
+// It is used for listings the user can reorder.
+func paginateByPosition[T any](query *gorm.DB, table string, page PageQuery, keyOf func(T) (int, uint)) (*Page[T], error) {
+	query = query.Session(&gorm.Session{})
+	result := &Page[T]{Items: []T{}}
+	if err := query.Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	limit := page.limit()
+	paged := query
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		position, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		paged = paged.Where(
+			fmt.Sprintf("(%[1]s.position > ? OR (%[1]s.position = ? AND %[1]s.id > ?))", table),
+			position, position, c.ID,
+		)
+	}
+	order := fmt.Sprintf("%[1]s.position, %[1]s.id", table)
+	if err := paged.Order(order).Limit(limit + 1).Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) > limit {
+		result.Items = result.Items[:limit]
+		position, id := keyOf(result.Items[limit-1])
+		result.NextCursor = encodeCursor(cursor{Value: strconv.Itoa(position), ID: id})
+	}
+	return result, nil
+}
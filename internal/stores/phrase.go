@@ -2,14 +2,31 @@ package stores
 
 import (
 	"errors"
+	"time"
 
 	"github.com/yomek33/talki/internal/models"
 	"gorm.io/gorm"
 )
 
+var ErrInvalidOrder = errors.New("order must list every item of the material exactly once")
+
 type PhraseStore interface {
 	CreatePhrase(phrase *models.Phrase) error
 	GetPhrasesByMaterialID(materialID uint) ([]models.Phrase, error)
+	ListPhrases(materialID uint, UserUID string, page PageQuery) (*Page[models.Phrase], error)
+	GetPhrasesByIDs(ids []uint) ([]models.Phrase, error)
+	// DeletePhrases deletes phrases with their review progress, usage events
+	// and notebook sources
+	DeletePhrases(ids []uint) error
+	// GetPhraseByID returns a phrase of a material owned by the user
+	GetPhraseByID(id, materialID uint, UserUID string) (*models.Phrase, error)
+	UpdatePhrase(phrase *models.Phrase) error
+	// ReorderPhrases sets the positions of a material's phrases to the order of ids
+	ReorderPhrases(materialID uint, ids []uint) error
+	// ListDuePhrases returns the user's phrases due for review at now, most
+	// overdue first: scheduled ones past their due date and starred ones never
+	// reviewed
+	ListDuePhrases(UserUID string, now time.Time, limit int) ([]models.Phrase, error)
 }
 
 type phraseStore struct {
@@ -31,11 +48,137 @@ func (s *phraseStore) CreatePhrase(phrase *models.Phrase) error {
 	}
 
 	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if phrase.Position == 0 {
+			if err := tx.Model(&models.Phrase{}).Where("material_id = ?", phrase.MaterialID).
+				Select("COALESCE(MAX(position), 0) + 1").Scan(&phrase.Position).Error; err != nil {
+				return err
+			}
+		}
 		return tx.Create(phrase).Error
 	})
 }
 func (s *phraseStore) GetPhrasesByMaterialID(materialID uint) ([]models.Phrase, error) {
 	var phrases []models.Phrase
-	err := s.DB.Where("material_id = ?", materialID).Find(&phrases).Error
+	err := s.DB.Where("material_id = ?", materialID).Order("position, id").Find(&phrases).Error
+	return phrases, err
+}
+
+func (s *phraseStore) ListPhrases(materialID uint, UserUID string, page PageQuery) (*Page[models.Phrase], error) {
+	query := s.DB.Model(&models.Phrase{}).
+		Joins("JOIN materials ON materials.id = phrases.material_id AND materials.deleted_at IS NULL").
+		Where("phrases.material_id = ? AND (materials.user_uid = ? OR materials.visibility = ?)", materialID, UserUID, models.VisibilityPublic)
+	return paginateByPosition(query, "phrases", page, func(p models.Phrase) (int, uint) { return p.Position, uint(p.ID) })
+}
+
+func (s *phraseStore) GetPhrasesByIDs(ids []uint) ([]models.Phrase, error) {
+	var phrases []models.Phrase
+	if len(ids) == 0 {
+		return phrases, nil
+	}
+	err := s.DB.Where("id IN ?", ids).Find(&phrases).Error
+	return phrases, err
+}
+
+func (s *phraseStore) DeletePhrases(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return deleteItems(tx, &models.Phrase{}, models.VocabularyKindPhrase, ids)
+	})
+}
+
+func (s *phraseStore) GetPhraseByID(id, materialID uint, UserUID string) (*models.Phrase, error) {
+	var phrase models.Phrase
+	err := s.DB.Joins("JOIN materials ON materials.id = phrases.material_id AND materials.deleted_at IS NULL").
+		Where("phrases.id = ? AND phrases.material_id = ? AND materials.user_uid = ?", id, materialID, UserUID).
+		First(&phrase).Error
+	if err != nil {
+		return nil, err
+	}
+	return &phrase, nil
+}
+
+func (s *phraseStore) UpdatePhrase(phrase *models.Phrase) error {
+	if phrase == nil {
+		return errors.New("phrase cannot be nil")
+	}
+	if phrase.Text == "" {
+		return errors.New("phrase Text cannot be empty")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Phrase{}).Where("id = ?", phrase.ID).Updates(map[string]interface{}{
+			"text":        phrase.Text,
+			"importance":  phrase.Importance,
+			"starred":     phrase.Starred,
+			"user_edited": phrase.UserEdited,
+		}).Error
+	})
+}
+
+func (s *phraseStore) ReorderPhrases(materialID uint, ids []uint) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return reorder(tx, &models.Phrase{}, materialID, ids)
+	})
+}
+
+func (s *phraseStore) ListDuePhrases(UserUID string, now time.Time, limit int) ([]models.Phrase, error) {
+	var phrases []models.Phrase
+	err := s.DB.Joins("JOIN materials ON materials.id = phrases.material_id AND materials.deleted_at IS NULL").
+		Joins("LEFT JOIN progresses ON progresses.user_uid = materials.user_uid AND progresses.kind = ? AND progresses.item_id = phrases.id", models.VocabularyKindPhrase).
+		Where("materials.user_uid = ? AND (phrases.starred = ? OR progresses.id IS NOT NULL)", UserUID, true).
+		Where("progresses.due_at IS NULL OR progresses.due_at <= ?", now).
+		Order("COALESCE(progresses.due_at, phrases.updated_at)").
+		Limit(limit).
+		Find(&phrases).Error
 	return phrases, err
 }
+
+// deleteItems deletes the phrases or words among ids, of kind, along with
+// the rows that point at them by kind and item ID
+func deleteItems(tx *gorm.DB, model interface{}, kind string, ids []uint) error {
+	entryIDs := tx.Session(&gorm.Session{NewDB: true}).Model(&models.VocabularyEntry{}).Select("id").Where("kind = ?", kind)
+	deletes := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{&models.Progress{}, "kind = ? AND item_id IN ?", []interface{}{kind, ids}},
+		{&models.UsageEvent{}, "kind = ? AND item_id IN ?", []interface{}{kind, ids}},
+		{&models.VocabularySource{}, "entry_id IN (?) AND item_id IN ?", []interface{}{entryIDs, ids}},
+	}
+	for _, d := range deletes {
+		if err := tx.Where(d.where, d.args...).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN ?", ids).Delete(model).Error
+}
+
+// reorder gives the rows of a material the positions 1..n in the order of ids.
+// ids must list every row of the material exactly once.
+func reorder(tx *gorm.DB, model interface{}, materialID uint, ids []uint) error {
+	var existing []uint
+	if err := tx.Model(model).Where("material_id = ?", materialID).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	if len(uniqueIDs(ids)) != len(ids) || len(ids) != len(existing) {
+		return ErrInvalidOrder
+	}
+	known := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return ErrInvalidOrder
+		}
+	}
+
+	for i, id := range ids {
+		if err := tx.Model(model).Where("id = ?", id).UpdateColumn("position", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
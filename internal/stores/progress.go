@@ -0,0 +1,39 @@
+package stores
+
+import (
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProgressStore interface {
+	// GetProgress returns the schedules the user has for the items of kind among ids
+	GetProgress(UserUID, kind string, ids []uint) ([]models.Progress, error)
+	// SaveProgress creates or replaces schedules by user, kind and item
+	SaveProgress(progress []models.Progress) error
+}
+
+type progressStore struct {
+	BaseStore
+}
+
+func (s *progressStore) GetProgress(UserUID, kind string, ids []uint) ([]models.Progress, error) {
+	var progress []models.Progress
+	if len(ids) == 0 {
+		return progress, nil
+	}
+	err := s.DB.Where("user_uid = ? AND kind = ? AND item_id IN ?", UserUID, kind, ids).Find(&progress).Error
+	return progress, err
+}
+
+func (s *progressStore) SaveProgress(progress []models.Progress) error {
+	if len(progress) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_uid"}, {Name: "kind"}, {Name: "item_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"repetitions", "interval_days", "ease", "due_at", "last_reviewed", "exposures", "updated_at"}),
+		}).Create(&progress).Error
+	})
+}
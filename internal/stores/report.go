@@ -0,0 +1,56 @@
+package stores
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type ReportStore interface {
+	CreateReport(report *models.ChatReport) error
+	GetReportByID(id uint, UserUID string) (*models.ChatReport, error)
+	// ListReports pages through the user's reports without their mistakes and
+	// suggestions; chatID 0 lists the reports of all chats
+	ListReports(UserUID string, chatID uint, page PageQuery) (*Page[models.ChatReport], error)
+	// GetReportsSince returns the scores of the user's reports created from since on, oldest first
+	GetReportsSince(UserUID string, since time.Time) ([]models.ChatReport, error)
+}
+
+type reportStore struct {
+	BaseStore
+}
+
+func (s *reportStore) CreateReport(report *models.ChatReport) error {
+	if report == nil {
+		return errors.New("report cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Create(report).Error
+	})
+}
+
+func (s *reportStore) GetReportByID(id uint, UserUID string) (*models.ChatReport, error) {
+	var report models.ChatReport
+	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).
+		Preload("Mistakes", func(db *gorm.DB) *gorm.DB { return db.Order("count DESC, id") }).
+		Preload("Suggestions").
+		First(&report).Error
+	return &report, err
+}
+
+func (s *reportStore) ListReports(UserUID string, chatID uint, page PageQuery) (*Page[models.ChatReport], error) {
+	query := s.DB.Model(&models.ChatReport{}).Where("user_uid = ?", UserUID)
+	if chatID != 0 {
+		query = query.Where("chat_id = ?", chatID)
+	}
+	return paginateByID(query, "chat_reports", page, func(r models.ChatReport) uint { return r.ID })
+}
+
+func (s *reportStore) GetReportsSince(UserUID string, since time.Time) ([]models.ChatReport, error) {
+	var reports []models.ChatReport
+	err := s.DB.Where("user_uid = ? AND created_at >= ?", UserUID, since).
+		Order("created_at, id").Find(&reports).Error
+	return reports, err
+}
@@ -0,0 +1,70 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevisionStore interface {
+	// CreateRevision stores revision as the next revision number of its material
+	CreateRevision(revision *models.MaterialRevision) error
+	GetLatestRevision(materialID uint) (*models.MaterialRevision, error)
+	GetRevision(materialID uint, number int, UserUID string) (*models.MaterialRevision, error)
+	// ListRevisions pages through revisions oldest first, without their content
+	ListRevisions(materialID uint, UserUID string, page PageQuery) (*Page[models.MaterialRevision], error)
+}
+
+type revisionStore struct {
+	BaseStore
+}
+
+func (s *revisionStore) CreateRevision(revision *models.MaterialRevision) error {
+	if revision == nil {
+		return errors.New("revision cannot be nil")
+	}
+	if revision.MaterialID == 0 {
+		return errors.New("revision MaterialID cannot be empty")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		// lock the material so concurrent edits get distinct numbers
+		var material models.Material
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&material, revision.MaterialID).Error; err != nil {
+			return err
+		}
+		var latest int
+		if err := tx.Model(&models.MaterialRevision{}).Where("material_id = ?", revision.MaterialID).
+			Select("COALESCE(MAX(number), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		revision.Number = latest + 1
+		return tx.Create(revision).Error
+	})
+}
+
+func (s *revisionStore) GetLatestRevision(materialID uint) (*models.MaterialRevision, error) {
+	var revision models.MaterialRevision
+	err := s.DB.Where("material_id = ?", materialID).Order("number DESC").First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (s *revisionStore) GetRevision(materialID uint, number int, UserUID string) (*models.MaterialRevision, error) {
+	var revision models.MaterialRevision
+	err := s.DB.Where("material_id = ? AND number = ? AND user_uid = ?", materialID, number, UserUID).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (s *revisionStore) ListRevisions(materialID uint, UserUID string, page PageQuery) (*Page[models.MaterialRevision], error) {
+	query := s.DB.Model(&models.MaterialRevision{}).
+		Omit("content").
+		Where("material_revisions.material_id = ? AND material_revisions.user_uid = ?", materialID, UserUID)
+	return paginateByID(query, "material_revisions", page, func(r models.MaterialRevision) uint { return r.ID })
+}
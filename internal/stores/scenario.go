@@ -0,0 +1,80 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type ScenarioStore interface {
+	CreateScenario(scenario *models.Scenario) error
+	GetScenarios(UserUID string) ([]models.Scenario, error)
+	GetScenarioByID(id uint, UserUID string) (*models.Scenario, error)
+	UpdateScenario(scenario *models.Scenario) error
+	DeleteScenario(id uint, UserUID string) error
+}
+
+type scenarioStore struct {
+	BaseStore
+}
+
+func (s *scenarioStore) CreateScenario(scenario *models.Scenario) error {
+	if scenario == nil {
+		return errors.New("scenario cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Create(scenario).Error
+	})
+}
+
+func (s *scenarioStore) GetScenarios(UserUID string) ([]models.Scenario, error) {
+	var scenarios []models.Scenario
+	err := s.DB.Where("user_uid = ?", UserUID).Order("title").Find(&scenarios).Error
+	return scenarios, err
+}
+
+// GetScenarioByID also finds deleted scenarios, so that the chats that follow
+// one keep their setup
+func (s *scenarioStore) GetScenarioByID(id uint, UserUID string) (*models.Scenario, error) {
+	var scenario models.Scenario
+	err := s.DB.Unscoped().Where("id = ? AND user_uid = ?", id, UserUID).First(&scenario).Error
+	return &scenario, err
+}
+
+func (s *scenarioStore) UpdateScenario(scenario *models.Scenario) error {
+	if scenario == nil {
+		return errors.New("scenario cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Scenario{}).Where("id = ? AND user_uid = ?", scenario.ID, scenario.UserUID).
+			Updates(map[string]interface{}{
+				"title":            scenario.Title,
+				"description":      scenario.Description,
+				"persona":          scenario.Persona,
+				"goals":            scenario.Goals,
+				"success_criteria": scenario.SuccessCriteria,
+				"uses_material":    scenario.UsesMaterial,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (s *scenarioStore) DeleteScenario(id uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_uid = ?", id, UserUID).Delete(&models.Scenario{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,189 @@
+package stores
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yomek33/talki/internal/models"
+	"github.com/yomek33/talki/internal/search"
+	"gorm.io/gorm"
+)
+
+// SearchStore searches the database directly with MySQL FULLTEXT indexes.
+// Index and Remove are no-ops because the tables themselves are the index.
+type SearchStore interface {
+	search.Index
+	EnsureFulltextIndexes() error
+	LoadDocuments() ([]search.Document, error)
+	// LoadMaterialDocuments reads the searchable rows of one material
+	LoadMaterialDocuments(materialID uint) ([]search.Document, error)
+}
+
+type searchStore struct {
+	BaseStore
+}
+
+type fulltextIndex struct {
+	table   string
+	name    string
+	columns string
+}
+
+var fulltextIndexes = []fulltextIndex{
+	{"materials", "idx_materials_fulltext", "title, content"},
+	{"phrases", "idx_phrases_fulltext", "text"},
+	{"words", "idx_words_fulltext", "text"},
+	{"messages", "idx_messages_fulltext", "content"},
+}
+
+type searchRow struct {
+	ID         uint
+	MaterialID uint
+	ChatID     uint
+	UserUID    string
+	Title      string
+	Text       string
+	Score      float64
+}
+
+func (s *searchStore) Index(docs ...search.Document) error {
+	return nil
+}
+
+func (s *searchStore) Remove(kind string, ids ...uint) error {
+	return nil
+}
+
+func (s *searchStore) RemoveMaterial(id uint) error {
+	return nil
+}
+
+// EnsureFulltextIndexes creates the FULLTEXT indexes the search queries rely on
+func (s *searchStore) EnsureFulltextIndexes() error {
+	for _, index := range fulltextIndexes {
+		if s.DB.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		sql := fmt.Sprintf("ALTER TABLE %s ADD FULLTEXT INDEX %s (%s)", index.table, index.name, index.columns)
+		if err := s.DB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create %s: %w", index.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *searchStore) Search(UserUID string, q search.Query) ([]search.Result, error) {
+	terms := search.QueryTerms(q.Text)
+	if len(terms) == 0 {
+		return []search.Result{}, nil
+	}
+	limit := search.NormalizeLimit(q.Limit)
+
+	queries := map[string]string{
+		search.KindMaterial: `SELECT materials.id, materials.id AS material_id, materials.title, materials.content AS text,
+				MATCH(materials.title, materials.content) AGAINST (@q IN NATURAL LANGUAGE MODE) AS score
+			FROM materials
+			WHERE materials.user_uid = @uid AND materials.deleted_at IS NULL
+				AND MATCH(materials.title, materials.content) AGAINST (@q IN NATURAL LANGUAGE MODE)`,
+		search.KindPhrase: `SELECT phrases.id, phrases.material_id, materials.title, phrases.text,
+				MATCH(phrases.text) AGAINST (@q IN NATURAL LANGUAGE MODE) AS score
+			FROM phrases JOIN materials ON materials.id = phrases.material_id
+			WHERE materials.user_uid = @uid AND materials.deleted_at IS NULL AND phrases.deleted_at IS NULL
+				AND MATCH(phrases.text) AGAINST (@q IN NATURAL LANGUAGE MODE)`,
+		search.KindWord: `SELECT words.id, words.material_id, materials.title, words.text,
+				MATCH(words.text) AGAINST (@q IN NATURAL LANGUAGE MODE) AS score
+			FROM words JOIN materials ON materials.id = words.material_id
+			WHERE materials.user_uid = @uid AND materials.deleted_at IS NULL AND words.deleted_at IS NULL
+				AND MATCH(words.text) AGAINST (@q IN NATURAL LANGUAGE MODE)`,
+		search.KindMessage: `SELECT messages.id, chats.material_id, messages.chat_id, messages.content AS text,
+				MATCH(messages.content) AGAINST (@q IN NATURAL LANGUAGE MODE) AS score
+			FROM messages JOIN chats ON chats.id = messages.chat_id
+			WHERE chats.user_uid = @uid AND chats.deleted_at IS NULL AND messages.deleted_at IS NULL
+				AND MATCH(messages.content) AGAINST (@q IN NATURAL LANGUAGE MODE)`,
+	}
+
+	results := []search.Result{}
+	for _, kind := range search.Kinds {
+		if !q.WantsKind(kind) {
+			continue
+		}
+		var rows []searchRow
+		sql := queries[kind] + " ORDER BY score DESC LIMIT @limit"
+		err := s.DB.Raw(sql, map[string]interface{}{"q": q.Text, "uid": UserUID, "limit": limit}).Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %ss: %w", kind, err)
+		}
+		for _, row := range rows {
+			results = append(results, search.Result{
+				Kind:       kind,
+				ID:         row.ID,
+				MaterialID: row.MaterialID,
+				ChatID:     row.ChatID,
+				Title:      row.Title,
+				Snippet:    search.Snippet(row.Text, terms),
+				Score:      row.Score,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// LoadDocuments reads every searchable row, used to fill an in-memory index on start-up
+func (s *searchStore) LoadDocuments() ([]search.Document, error) {
+	return s.loadDocuments(func(db *gorm.DB, column string) *gorm.DB { return db })
+}
+
+func (s *searchStore) LoadMaterialDocuments(materialID uint) ([]search.Document, error) {
+	return s.loadDocuments(func(db *gorm.DB, column string) *gorm.DB {
+		return db.Where(column+" = ?", materialID)
+	})
+}
+
+// loadDocuments reads the searchable rows that scope, given the column holding
+// their material ID, keeps
+func (s *searchStore) loadDocuments(scope func(db *gorm.DB, column string) *gorm.DB) ([]search.Document, error) {
+	var docs []search.Document
+
+	var materials []models.Material
+	if err := scope(s.DB, "id").Select("id", "user_uid", "title", "content").Find(&materials).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range materials {
+		docs = append(docs, search.Document{Kind: search.KindMaterial, ID: m.ID, UserUID: m.UserUID, MaterialID: m.ID, Title: m.Title, Text: m.Content})
+	}
+
+	var phrases []models.Phrase
+	if err := scope(s.DB, "material_id").Select("id", "material_id", "text").Find(&phrases).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range phrases {
+		docs = append(docs, search.Document{Kind: search.KindPhrase, ID: uint(p.ID), MaterialID: p.MaterialID, Text: p.Text})
+	}
+
+	var words []models.Word
+	if err := scope(s.DB, "material_id").Select("id", "material_id", "text").Find(&words).Error; err != nil {
+		return nil, err
+	}
+	for _, w := range words {
+		docs = append(docs, search.Document{Kind: search.KindWord, ID: w.ID, MaterialID: w.MaterialID, Text: w.Text})
+	}
+
+	var rows []searchRow
+	err := scope(s.DB, "chats.material_id").Table("messages").
+		Select("messages.id, messages.chat_id, chats.material_id, chats.user_uid, messages.content AS text").
+		Joins("JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL").
+		Where("messages.deleted_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		docs = append(docs, search.Document{Kind: search.KindMessage, ID: row.ID, UserUID: row.UserUID, MaterialID: row.MaterialID, ChatID: row.ChatID, Text: row.Text})
+	}
+	return docs, nil
+}
@@ -1,26 +1,59 @@
 package stores
 
 import (
+	"github.com/yomek33/talki/internal/embedding"
 	"gorm.io/gorm"
 )
 
 type Stores struct {
-	DB            *gorm.DB
-	UserStore     UserStore
-	MaterialStore MaterialStore
-	PhraseStore   PhraseStore
-	ChatStore     ChatStore
-	MessageStore  MessageStore
+	DB               *gorm.DB
+	UserStore        UserStore
+	MaterialStore    MaterialStore
+	PhraseStore      PhraseStore
+	ChatStore        ChatStore
+	MessageStore     MessageStore
+	HighlightStore   HighlightStore
+	SearchStore      SearchStore
+	EmbeddingStore   embedding.VectorStore
+	TagStore         TagStore
+	CollectionStore  CollectionStore
+	RevisionStore    RevisionStore
+	TrashStore       TrashStore
+	WordStore        WordStore
+	JobStore         JobStore
+	VocabularyStore  VocabularyStore
+	KnownWordStore   KnownWordStore
+	TranslationStore TranslationStore
+	ScenarioStore    ScenarioStore
+	ReportStore      ReportStore
+	ProgressStore    ProgressStore
+	UsageStore       UsageStore
 }
 
 func NewStores(db *gorm.DB) *Stores {
 	return &Stores{
-		DB:            db,
-		UserStore:     &userStore{BaseStore{DB: db}},
-		MaterialStore: &materialStore{BaseStore{DB: db}},
-		PhraseStore:   &phraseStore{BaseStore{DB: db}},
-		ChatStore:     &chatStore{BaseStore{DB: db}},
-		MessageStore:  &messageStore{BaseStore{DB: db}},
+		DB:               db,
+		UserStore:        &userStore{BaseStore{DB: db}},
+		MaterialStore:    &materialStore{BaseStore{DB: db}},
+		PhraseStore:      &phraseStore{BaseStore{DB: db}},
+		ChatStore:        &chatStore{BaseStore{DB: db}},
+		MessageStore:     &messageStore{BaseStore{DB: db}},
+		HighlightStore:   &highlightStore{BaseStore{DB: db}},
+		SearchStore:      &searchStore{BaseStore{DB: db}},
+		EmbeddingStore:   &embeddingStore{BaseStore{DB: db}},
+		TagStore:         &tagStore{BaseStore{DB: db}},
+		CollectionStore:  &collectionStore{BaseStore{DB: db}},
+		RevisionStore:    &revisionStore{BaseStore{DB: db}},
+		TrashStore:       &trashStore{BaseStore{DB: db}},
+		WordStore:        &wordStore{BaseStore{DB: db}},
+		JobStore:         &jobStore{BaseStore{DB: db}},
+		VocabularyStore:  &vocabularyStore{BaseStore{DB: db}},
+		KnownWordStore:   &knownWordStore{BaseStore{DB: db}},
+		TranslationStore: &translationStore{BaseStore{DB: db}},
+		ScenarioStore:    &scenarioStore{BaseStore{DB: db}},
+		ReportStore:      &reportStore{BaseStore{DB: db}},
+		ProgressStore:    &progressStore{BaseStore{DB: db}},
+		UsageStore:       &usageStore{BaseStore{DB: db}},
 	}
 }
 
@@ -0,0 +1,120 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownMaterialsOrTags = errors.New("one or more materials or tags do not exist")
+
+type TagStore interface {
+	CreateTag(tag *models.Tag) error
+	GetTags(UserUID string) ([]models.Tag, error)
+	GetTagByID(id uint, UserUID string) (*models.Tag, error)
+	UpdateTag(tag *models.Tag) error
+	DeleteTag(id uint, UserUID string) error
+	AssignTags(materialIDs, tagIDs []uint, UserUID string) error
+	UnassignTags(materialIDs, tagIDs []uint, UserUID string) error
+}
+
+type tagStore struct {
+	BaseStore
+}
+
+func (s *tagStore) CreateTag(tag *models.Tag) error {
+	if tag == nil {
+		return errors.New("tag cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Create(tag).Error
+	})
+}
+
+func (s *tagStore) GetTags(UserUID string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := s.DB.Where("user_uid = ?", UserUID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (s *tagStore) GetTagByID(id uint, UserUID string) (*models.Tag, error) {
+	var tag models.Tag
+	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).First(&tag).Error
+	return &tag, err
+}
+
+func (s *tagStore) UpdateTag(tag *models.Tag) error {
+	if tag == nil {
+		return errors.New("tag cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Tag{}).Where("id = ? AND user_uid = ?", tag.ID, tag.UserUID).
+			Updates(map[string]interface{}{"name": tag.Name, "color": tag.Color}).Error
+	})
+}
+
+// DeleteTag removes the tag for good so that its name can be reused
+func (s *tagStore) DeleteTag(id uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND user_uid = ?", id, UserUID).Delete(&models.Tag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Exec("DELETE FROM material_tags WHERE tag_id = ?", id).Error
+	})
+}
+
+func (s *tagStore) AssignTags(materialIDs, tagIDs []uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		materials, tags, err := loadOwnedMaterialsAndTags(tx, materialIDs, tagIDs, UserUID)
+		if err != nil {
+			return err
+		}
+		for i := range materials {
+			if err := tx.Model(&materials[i]).Omit("Tags.*").Association("Tags").Append(tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *tagStore) UnassignTags(materialIDs, tagIDs []uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if _, _, err := loadOwnedMaterialsAndTags(tx, materialIDs, tagIDs, UserUID); err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM material_tags WHERE material_id IN ? AND tag_id IN ?", materialIDs, tagIDs).Error
+	})
+}
+
+func loadOwnedMaterialsAndTags(tx *gorm.DB, materialIDs, tagIDs []uint, UserUID string) ([]models.Material, []models.Tag, error) {
+	var materials []models.Material
+	if err := tx.Select("id").Where("id IN ? AND user_uid = ?", materialIDs, UserUID).Find(&materials).Error; err != nil {
+		return nil, nil, err
+	}
+	var tags []models.Tag
+	if err := tx.Where("id IN ? AND user_uid = ?", tagIDs, UserUID).Find(&tags).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(materials) != len(uniqueIDs(materialIDs)) || len(tags) != len(uniqueIDs(tagIDs)) {
+		return nil, nil, ErrUnknownMaterialsOrTags
+	}
+	return materials, tags, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
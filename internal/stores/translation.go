@@ -0,0 +1,36 @@
+package stores
+
+import (
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TranslationStore interface {
+	// GetTranslations returns the cached translations from source into language among keys
+	GetTranslations(source, language string, keys []string) ([]models.Translation, error)
+	// SaveTranslations caches translations; ones already cached are kept
+	SaveTranslations(translations []models.Translation) error
+}
+
+type translationStore struct {
+	BaseStore
+}
+
+func (s *translationStore) GetTranslations(source, language string, keys []string) ([]models.Translation, error) {
+	var translations []models.Translation
+	if len(keys) == 0 {
+		return translations, nil
+	}
+	err := s.DB.Where("source = ? AND language = ? AND `key` IN ?", source, language, keys).Find(&translations).Error
+	return translations, err
+}
+
+func (s *translationStore) SaveTranslations(translations []models.Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(translations, 500).Error
+	})
+}
@@ -0,0 +1,166 @@
+package stores
+
+import (
+	"time"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type TrashStore interface {
+	ListTrash(UserUID string, page PageQuery) (*Page[models.Material], error)
+	// RestoreMaterial undeletes a material together with the rows trashed with it
+	RestoreMaterial(id uint, UserUID string) (*models.Material, error)
+	// PurgeMaterial permanently deletes a trashed material and everything attached to it
+	PurgeMaterial(id uint, UserUID string) error
+	// PurgeDeletedBefore permanently deletes every material trashed before cutoff
+	// and returns their IDs
+	PurgeDeletedBefore(cutoff time.Time) ([]uint, error)
+}
+
+type trashStore struct {
+	BaseStore
+}
+
+// trashMaterials soft-deletes the materials matched by where and their phrases,
+// words, highlights, chats and messages, all with the same deleted_at. Restoring
+// then only brings back rows carrying that timestamp, not ones deleted earlier.
+func trashMaterials(tx *gorm.DB, where string, args ...interface{}) error {
+	var ids []uint
+	if err := tx.Model(&models.Material{}).Where(where, args...).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return setMaterialsDeletedAt(tx, ids, time.Now(), nil)
+}
+
+// setMaterialsDeletedAt moves the deleted_at of materials and their children from
+// one value (nil meaning not deleted) to another
+func setMaterialsDeletedAt(tx *gorm.DB, ids []uint, to interface{}, from *time.Time) error {
+	scoped := func(model interface{}) *gorm.DB {
+		query := tx.Unscoped().Model(model)
+		if from == nil {
+			return query.Where("deleted_at IS NULL")
+		}
+		return query.Where("deleted_at = ?", *from)
+	}
+
+	chatIDs := tx.Unscoped().Model(&models.Chat{}).Select("id").Where("material_id IN ?", ids)
+	updates := []*gorm.DB{
+		scoped(&models.Message{}).Where("chat_id IN (?)", chatIDs),
+		scoped(&models.Chat{}).Where("material_id IN ?", ids),
+		scoped(&models.Phrase{}).Where("material_id IN ?", ids),
+		scoped(&models.Word{}).Where("material_id IN ?", ids),
+		scoped(&models.Highlight{}).Where("material_id IN ?", ids),
+		scoped(&models.Material{}).Where("id IN ?", ids),
+	}
+	for _, update := range updates {
+		if err := update.Update("deleted_at", to).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *trashStore) ListTrash(UserUID string, page PageQuery) (*Page[models.Material], error) {
+	query := s.DB.Unscoped().Model(&models.Material{}).
+		Where("materials.user_uid = ? AND materials.deleted_at IS NOT NULL", UserUID)
+	return paginateByID(query, "materials", page, func(m models.Material) uint { return m.ID })
+}
+
+func (s *trashStore) RestoreMaterial(id uint, UserUID string) (*models.Material, error) {
+	var material models.Material
+	err := s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND user_uid = ? AND deleted_at IS NOT NULL", id, UserUID).First(&material).Error; err != nil {
+			return err
+		}
+		deletedAt := material.DeletedAt.Time
+		return setMaterialsDeletedAt(tx, []uint{id}, nil, &deletedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	material.DeletedAt = gorm.DeletedAt{}
+	return &material, nil
+}
+
+func (s *trashStore) PurgeMaterial(id uint, UserUID string) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		var material models.Material
+		if err := tx.Unscoped().Where("id = ? AND user_uid = ? AND deleted_at IS NOT NULL", id, UserUID).First(&material).Error; err != nil {
+			return err
+		}
+		return purgeMaterials(tx, []uint{id})
+	})
+}
+
+func (s *trashStore) PurgeDeletedBefore(cutoff time.Time) ([]uint, error) {
+	var ids []uint
+	err := s.DB.Unscoped().Model(&models.Material{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	err = s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return purgeMaterials(tx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// purgeMaterials hard-deletes materials and every row that belongs to them,
+// whether it was trashed along with the material or not
+func purgeMaterials(tx *gorm.DB, ids []uint) error {
+	tx = tx.Unscoped().Session(&gorm.Session{})
+	chatIDs := tx.Model(&models.Chat{}).Select("id").Where("material_id IN ?", ids)
+	reportIDs := tx.Model(&models.ChatReport{}).Select("id").Where("material_id IN ?", ids)
+	phraseIDs := tx.Model(&models.Phrase{}).Select("id").Where("material_id IN ?", ids)
+	wordIDs := tx.Model(&models.Word{}).Select("id").Where("material_id IN ?", ids)
+
+	deletes := []struct {
+		model interface{}
+		where string
+		arg   interface{}
+	}{
+		{&models.UsageEvent{}, "material_id IN ?", ids},
+		{&models.ReportMistake{}, "report_id IN (?)", reportIDs},
+		{&models.ReportSuggestion{}, "report_id IN (?)", reportIDs},
+		{&models.ChatReport{}, "material_id IN ?", ids},
+		{&models.Message{}, "chat_id IN (?)", chatIDs},
+		{&models.Chat{}, "material_id IN ?", ids},
+		// phrase vectors carry their material ID too
+		{&models.Embedding{}, "material_id IN ?", ids},
+		{&models.Phrase{}, "material_id IN ?", ids},
+		{&models.Word{}, "material_id IN ?", ids},
+		{&models.Highlight{}, "material_id IN ?", ids},
+		{&models.MaterialRevision{}, "material_id IN ?", ids},
+		{&models.VocabularySource{}, "material_id IN ?", ids},
+		{&models.MaterialLemma{}, "material_id IN ?", ids},
+	}
+	// progress points at phrases and words by kind, so it goes before them
+	for kind, itemIDs := range map[string]*gorm.DB{models.VocabularyKindPhrase: phraseIDs, models.VocabularyKindWord: wordIDs} {
+		if err := tx.Where("kind = ? AND item_id IN (?)", kind, itemIDs).Delete(&models.Progress{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, d := range deletes {
+		if err := tx.Where(d.where, d.arg).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"material_tags", "collection_materials"} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE material_id IN ?", ids).Error; err != nil {
+			return err
+		}
+	}
+	// leveled rewrites outlive the material they were made from
+	if err := tx.Model(&models.Material{}).Where("parent_id IN ?", ids).Update("parent_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", ids).Delete(&models.Material{}).Error
+}
@@ -0,0 +1,53 @@
+package stores
+
+import (
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type UsageStore interface {
+	CreateUsageEvents(events []models.UsageEvent) error
+	// ReplaceUsageEvents swaps the usage events of a message for events
+	ReplaceUsageEvents(messageID uint, events []models.UsageEvent) error
+	// GetMessageUsageEvents returns the usage events recorded for a message
+	GetMessageUsageEvents(messageID uint) ([]models.UsageEvent, error)
+	// GetUsageEvents returns the usage events of one of the user's chats, oldest first
+	GetUsageEvents(chatID uint, UserUID string) ([]models.UsageEvent, error)
+}
+
+type usageStore struct {
+	BaseStore
+}
+
+func (s *usageStore) CreateUsageEvents(events []models.UsageEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Create(&events).Error
+	})
+}
+
+func (s *usageStore) ReplaceUsageEvents(messageID uint, events []models.UsageEvent) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Delete(&models.UsageEvent{}).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+func (s *usageStore) GetMessageUsageEvents(messageID uint) ([]models.UsageEvent, error) {
+	var events []models.UsageEvent
+	err := s.DB.Where("message_id = ?", messageID).Find(&events).Error
+	return events, err
+}
+
+func (s *usageStore) GetUsageEvents(chatID uint, UserUID string) ([]models.UsageEvent, error) {
+	var events []models.UsageEvent
+	err := s.DB.Where("chat_id = ? AND user_uid = ?", chatID, UserUID).Order("id").Find(&events).Error
+	return events, err
+}
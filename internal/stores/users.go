@@ -69,8 +69,8 @@ func (store *userStore) UpdateUser(user *models.User) error {
 
 func (store *userStore) DeleteUser(UserUID string) error {
 	return store.PerformDBTransaction(func(tx *gorm.DB) error {
-		// Delete the materials related to the user
-		if err := tx.Where("user_uid = ?", UserUID).Delete(&models.Material{}).Error; err != nil {
+		// Delete the materials related to the user, with their phrases, words and chats
+		if err := trashMaterials(tx, "user_uid = ?", UserUID); err != nil {
 			return err
 		}
 		// Delete the user
@@ -0,0 +1,243 @@
+package stores
+
+import (
+	"errors"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VocabularyItem is a phrase or word of one of the user's materials
+type VocabularyItem struct {
+	Kind       string
+	ItemID     uint
+	MaterialID uint
+	Language   string
+	Text       string
+}
+
+// VocabularyQuery holds the filters and page of a notebook listing
+type VocabularyQuery struct {
+	PageQuery
+	Kind       string
+	Status     string
+	Search     string
+	MaterialID uint
+}
+
+type VocabularyStore interface {
+	// ListVocabularyItems returns the phrases and words of the user's live materials
+	ListVocabularyItems(UserUID string) ([]VocabularyItem, error)
+	// SyncEntries makes the notebook match entries: missing entries are created and
+	// sources are added or removed. Existing entries keep their status and note.
+	SyncEntries(UserUID string, entries []models.VocabularyEntry) error
+	ListEntries(query VocabularyQuery, UserUID string) (*Page[models.VocabularyEntry], error)
+	// ExportEntries returns every entry matching query, ignoring its page
+	ExportEntries(query VocabularyQuery, UserUID string) ([]models.VocabularyEntry, error)
+	GetEntry(id uint, UserUID string) (*models.VocabularyEntry, error)
+	UpdateEntry(entry *models.VocabularyEntry) error
+}
+
+type vocabularyStore struct {
+	BaseStore
+}
+
+func (s *vocabularyStore) ListVocabularyItems(UserUID string) ([]VocabularyItem, error) {
+	var items []VocabularyItem
+	err := s.DB.Raw(`SELECT ? AS kind, phrases.id AS item_id, phrases.material_id, materials.language, phrases.text
+			FROM phrases JOIN materials ON materials.id = phrases.material_id
+			WHERE materials.user_uid = ? AND materials.deleted_at IS NULL AND phrases.deleted_at IS NULL
+		UNION ALL
+		SELECT ? AS kind, words.id AS item_id, words.material_id, materials.language, words.text
+			FROM words JOIN materials ON materials.id = words.material_id
+			WHERE materials.user_uid = ? AND materials.deleted_at IS NULL AND words.deleted_at IS NULL`,
+		models.VocabularyKindPhrase, UserUID, models.VocabularyKindWord, UserUID,
+	).Scan(&items).Error
+	return items, err
+}
+
+func (s *vocabularyStore) SyncEntries(UserUID string, entries []models.VocabularyEntry) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		var existing []models.VocabularyEntry
+		if err := tx.Select("id", "kind", "language", "key").Where("user_uid = ?", UserUID).Find(&existing).Error; err != nil {
+			return err
+		}
+		ids := make(map[string]uint, len(existing))
+		for _, entry := range existing {
+			ids[entryKey(entry)] = entry.ID
+		}
+
+		type sourceKey struct{ entryID, itemID uint }
+		wanted := map[sourceKey]models.VocabularySource{}
+		for _, entry := range entries {
+			id, ok := ids[entryKey(entry)]
+			if !ok {
+				var err error
+				if id, err = createEntry(tx, UserUID, entry); err != nil {
+					return err
+				}
+				ids[entryKey(entry)] = id
+			}
+			for _, source := range entry.Sources {
+				wanted[sourceKey{id, source.ItemID}] = models.VocabularySource{EntryID: id, ItemID: source.ItemID, MaterialID: source.MaterialID}
+			}
+		}
+
+		var current []models.VocabularySource
+		err := tx.Model(&models.VocabularySource{}).
+			Joins("JOIN vocabulary_entries ON vocabulary_entries.id = vocabulary_sources.entry_id").
+			Where("vocabulary_entries.user_uid = ?", UserUID).
+			Select("vocabulary_sources.id", "vocabulary_sources.entry_id", "vocabulary_sources.item_id").
+			Find(&current).Error
+		if err != nil {
+			return err
+		}
+		var stale []uint
+		for _, source := range current {
+			key := sourceKey{source.EntryID, source.ItemID}
+			if _, ok := wanted[key]; ok {
+				delete(wanted, key)
+				continue
+			}
+			stale = append(stale, source.ID)
+		}
+
+		if len(stale) > 0 {
+			if err := tx.Where("id IN ?", stale).Delete(&models.VocabularySource{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(wanted) > 0 {
+			fresh := make([]models.VocabularySource, 0, len(wanted))
+			for _, source := range wanted {
+				fresh = append(fresh, source)
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(fresh, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *vocabularyStore) ListEntries(q VocabularyQuery, UserUID string) (*Page[models.VocabularyEntry], error) {
+	page, err := paginateByID(s.entriesQuery(q, UserUID), "vocabulary_entries", q.PageQuery, func(e models.VocabularyEntry) uint { return e.ID })
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadSources(page.Items); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *vocabularyStore) ExportEntries(q VocabularyQuery, UserUID string) ([]models.VocabularyEntry, error) {
+	var entries []models.VocabularyEntry
+	if err := s.entriesQuery(q, UserUID).Order("vocabulary_entries.id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if err := s.loadSources(entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// loadSources fills the sources of entries with the titles of their live materials
+func (s *vocabularyStore) loadSources(entries []models.VocabularyEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+
+	var sources []models.VocabularySource
+	err := s.DB.Model(&models.VocabularySource{}).
+		Select("vocabulary_sources.*, materials.title AS material_title").
+		Joins("JOIN materials ON materials.id = vocabulary_sources.material_id AND materials.deleted_at IS NULL").
+		Where("vocabulary_sources.entry_id IN ?", ids).
+		Order("vocabulary_sources.id").
+		Find(&sources).Error
+	if err != nil {
+		return err
+	}
+
+	byEntry := make(map[uint][]models.VocabularySource, len(entries))
+	for _, source := range sources {
+		byEntry[source.EntryID] = append(byEntry[source.EntryID], source)
+	}
+	for i := range entries {
+		entries[i].Sources = byEntry[entries[i].ID]
+	}
+	return nil
+}
+
+// entriesQuery selects the entries that still have a source in a live material
+func (s *vocabularyStore) entriesQuery(q VocabularyQuery, UserUID string) *gorm.DB {
+	sources := `SELECT 1 FROM vocabulary_sources
+		JOIN materials ON materials.id = vocabulary_sources.material_id AND materials.deleted_at IS NULL
+		WHERE vocabulary_sources.entry_id = vocabulary_entries.id`
+	query := s.DB.Model(&models.VocabularyEntry{}).
+		Where("vocabulary_entries.user_uid = ?", UserUID)
+
+	if q.MaterialID != 0 {
+		query = query.Where("EXISTS ("+sources+" AND vocabulary_sources.material_id = ?)", q.MaterialID)
+	} else {
+		query = query.Where("EXISTS (" + sources + ")")
+	}
+	if q.Kind != "" {
+		query = query.Where("vocabulary_entries.kind = ?", q.Kind)
+	}
+	if q.Status != "" {
+		query = query.Where("vocabulary_entries.status = ?", q.Status)
+	}
+	if q.Search != "" {
+		query = query.Where("vocabulary_entries.text LIKE ?", "%"+q.Search+"%")
+	}
+	return query
+}
+
+func (s *vocabularyStore) GetEntry(id uint, UserUID string) (*models.VocabularyEntry, error) {
+	var entry models.VocabularyEntry
+	err := s.DB.Where("id = ? AND user_uid = ?", id, UserUID).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *vocabularyStore) UpdateEntry(entry *models.VocabularyEntry) error {
+	if entry == nil {
+		return errors.New("vocabulary entry cannot be nil")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.VocabularyEntry{}).Where("id = ? AND user_uid = ?", entry.ID, entry.UserUID).
+			Updates(map[string]interface{}{"status": entry.Status, "note": entry.Note}).Error
+	})
+}
+
+// createEntry adds entry to the notebook and returns its ID. A sync running at
+// the same time may have added it first, in which case that entry is used.
+func createEntry(tx *gorm.DB, UserUID string, entry models.VocabularyEntry) (uint, error) {
+	created := models.VocabularyEntry{UserUID: UserUID, Kind: entry.Kind, Language: entry.Language, Key: entry.Key, Text: entry.Text, Status: models.VocabularyNew}
+	result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&created)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return created.ID, nil
+	}
+	// a locking read sees the row the other transaction committed
+	var existing models.VocabularyEntry
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("user_uid = ? AND kind = ? AND language = ? AND `key` = ?", UserUID, entry.Kind, entry.Language, entry.Key).
+		First(&existing).Error
+	return existing.ID, err
+}
+
+// entryKey identifies an entry within a user's notebook
+func entryKey(entry models.VocabularyEntry) string {
+	return entry.Kind + ":" + entry.Language + ":" + entry.Key
+}
@@ -0,0 +1,133 @@
+package stores
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yomek33/talki/internal/models"
+	"gorm.io/gorm"
+)
+
+type WordStore interface {
+	CreateWord(word *models.Word) error
+	GetWordsByMaterialID(materialID uint) ([]models.Word, error)
+	ListWords(materialID uint, UserUID string, page PageQuery) (*Page[models.Word], error)
+	// GetWordByID returns a word of a material owned by the user
+	GetWordByID(id, materialID uint, UserUID string) (*models.Word, error)
+	UpdateWord(word *models.Word) error
+	// DeleteWords deletes words like DeletePhrases deletes phrases
+	DeleteWords(ids []uint) error
+	// ReorderWords sets the positions of a material's words to the order of ids
+	ReorderWords(materialID uint, ids []uint) error
+	// ListDueWords returns the user's words due for review at now, like ListDuePhrases
+	ListDueWords(UserUID string, now time.Time, limit int) ([]models.Word, error)
+	// GetWordsByIDs returns the words among ids that belong to the user's materials
+	GetWordsByIDs(ids []uint, UserUID string) ([]models.Word, error)
+}
+
+type wordStore struct {
+	BaseStore
+}
+
+func (s *wordStore) CreateWord(word *models.Word) error {
+	if word == nil {
+		return errors.New("word cannot be nil")
+	}
+	if word.Text == "" {
+		return errors.New("word Text cannot be empty")
+	}
+	if word.MaterialID == 0 {
+		return errors.New("word MaterialID cannot be empty")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		if word.Position == 0 {
+			if err := tx.Model(&models.Word{}).Where("material_id = ?", word.MaterialID).
+				Select("COALESCE(MAX(position), 0) + 1").Scan(&word.Position).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(word).Error
+	})
+}
+
+func (s *wordStore) GetWordsByMaterialID(materialID uint) ([]models.Word, error) {
+	var words []models.Word
+	err := s.DB.Where("material_id = ?", materialID).Order("position, id").Find(&words).Error
+	return words, err
+}
+
+func (s *wordStore) ListWords(materialID uint, UserUID string, page PageQuery) (*Page[models.Word], error) {
+	query := s.DB.Model(&models.Word{}).
+		Joins("JOIN materials ON materials.id = words.material_id AND materials.deleted_at IS NULL").
+		Where("words.material_id = ? AND (materials.user_uid = ? OR materials.visibility = ?)", materialID, UserUID, models.VisibilityPublic)
+	return paginateByPosition(query, "words", page, func(w models.Word) (int, uint) { return w.Position, w.ID })
+}
+
+func (s *wordStore) GetWordByID(id, materialID uint, UserUID string) (*models.Word, error) {
+	var word models.Word
+	err := s.DB.Joins("JOIN materials ON materials.id = words.material_id AND materials.deleted_at IS NULL").
+		Where("words.id = ? AND words.material_id = ? AND materials.user_uid = ?", id, materialID, UserUID).
+		First(&word).Error
+	if err != nil {
+		return nil, err
+	}
+	return &word, nil
+}
+
+func (s *wordStore) UpdateWord(word *models.Word) error {
+	if word == nil {
+		return errors.New("word cannot be nil")
+	}
+	if word.Text == "" {
+		return errors.New("word Text cannot be empty")
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Word{}).Where("id = ?", word.ID).Updates(map[string]interface{}{
+			"text":          word.Text,
+			"importance":    word.Importance,
+			"level":         word.Level,
+			"starred":       word.Starred,
+			"user_edited":   word.UserEdited,
+			"definition":    word.Definition,
+			"pronunciation": word.Pronunciation,
+		}).Error
+	})
+}
+
+func (s *wordStore) DeleteWords(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return deleteItems(tx, &models.Word{}, models.VocabularyKindWord, ids)
+	})
+}
+
+func (s *wordStore) ReorderWords(materialID uint, ids []uint) error {
+	return s.PerformDBTransaction(func(tx *gorm.DB) error {
+		return reorder(tx, &models.Word{}, materialID, ids)
+	})
+}
+
+func (s *wordStore) ListDueWords(UserUID string, now time.Time, limit int) ([]models.Word, error) {
+	var words []models.Word
+	err := s.DB.Joins("JOIN materials ON materials.id = words.material_id AND materials.deleted_at IS NULL").
+		Joins("LEFT JOIN progresses ON progresses.user_uid = materials.user_uid AND progresses.kind = ? AND progresses.item_id = words.id", models.VocabularyKindWord).
+		Where("materials.user_uid = ? AND (words.starred = ? OR progresses.id IS NOT NULL)", UserUID, true).
+		Where("progresses.due_at IS NULL OR progresses.due_at <= ?", now).
+		Order("COALESCE(progresses.due_at, words.updated_at)").
+		Limit(limit).
+		Find(&words).Error
+	return words, err
+}
+
+func (s *wordStore) GetWordsByIDs(ids []uint, UserUID string) ([]models.Word, error) {
+	var words []models.Word
+	if len(ids) == 0 {
+		return words, nil
+	}
+	err := s.DB.Joins("JOIN materials ON materials.id = words.material_id AND materials.deleted_at IS NULL").
+		Where("words.id IN ? AND materials.user_uid = ?", ids, UserUID).
+		Find(&words).Error
+	return words, err
+}